@@ -1,21 +1,28 @@
 package main
 
 import (
+	"database/sql"
 	"errors"
 	"flag"
 	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
 
 	"github.com/golang-migrate/migrate/v4"
 	_ "github.com/golang-migrate/migrate/v4/database/sqlite3"
 	_ "github.com/golang-migrate/migrate/v4/source/file"
+	_ "github.com/mattn/go-sqlite3"
 )
 
 func main() {
-	var storagePath, migrationsPath, migrationsTable string
+	var storagePath, migrationsPath, migrationsTable, seedPath, adminEmail string
 
 	flag.StringVar(&storagePath, "storage-path", "", "path to storage")
 	flag.StringVar(&migrationsPath, "migrations-path", "", "path to migrations")
 	flag.StringVar(&migrationsTable, "migrations-table", "migrations", "name of migrations table")
+	flag.StringVar(&seedPath, "seed-path", "", "path to sql files with sample data, applied after migrations")
+	flag.StringVar(&adminEmail, "admin-email", "", "grant admin role to the registered user with this email")
 	flag.Parse()
 
 	if storagePath == "" {
@@ -34,16 +41,109 @@ func main() {
 	}
 
 	if err := m.Up(); err != nil {
-		if errors.Is(err, migrate.ErrNoChange) {
-			fmt.Println("no migrations to apply")
+		if !errors.Is(err, migrate.ErrNoChange) {
+			panic(err)
+		}
+
+		fmt.Println("no migrations to apply")
+	} else {
+		fmt.Println("migrations applied")
+	}
 
-			return
+	if seedPath != "" {
+		if err := seed(storagePath, seedPath); err != nil {
+			panic(err)
 		}
 
-		panic(err)
+		fmt.Println("seeds applied")
+	}
+
+	if adminEmail != "" {
+		if err := grantAdmin(storagePath, adminEmail); err != nil {
+			panic(err)
+		}
+
+		fmt.Println("admin role granted to", adminEmail)
+	}
+}
+
+// grantAdmin gives admin role to already registered user. Roles are managed only by holders
+// of roles:manage permission, so the first admin has to be appointed here.
+func grantAdmin(storagePath, email string) error {
+	db, err := sql.Open("sqlite3", storagePath+"?_foreign_keys=on")
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	var userID int64
+	err = db.QueryRow(`SELECT id FROM users WHERE email = ?`, email).Scan(&userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("user %s is not registered", email)
+		}
+
+		return err
+	}
+
+	// Insert below would silently do nothing without the role, e.g. if migrations are out of date.
+	var roleID int64
+	err = db.QueryRow(`SELECT id FROM roles WHERE name = 'admin'`).Scan(&roleID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return errors.New("admin role does not exist, check that migrations are applied")
+		}
+
+		return err
+	}
+
+	_, err = db.Exec(`
+		INSERT INTO user_roles(user_id, role_id) VALUES (?, ?)
+		ON CONFLICT DO NOTHING
+	`, userID, roleID)
+
+	return err
+}
+
+// seed executes *.sql files from seedPath in lexical order, each one in its own transaction.
+// Seeds are run on every call, so they must be idempotent.
+func seed(storagePath, seedPath string) error {
+	files, err := filepath.Glob(filepath.Join(seedPath, "*.sql"))
+	if err != nil {
+		return err
+	}
+	sort.Strings(files)
+
+	db, err := sql.Open("sqlite3", storagePath+"?_foreign_keys=on")
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	for _, file := range files {
+		query, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+
+		tx, err := db.Begin()
+		if err != nil {
+			return err
+		}
+
+		if _, err := tx.Exec(string(query)); err != nil {
+			_ = tx.Rollback()
+			return fmt.Errorf("%s: %w", file, err)
+		}
+
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("%s: %w", file, err)
+		}
+
+		fmt.Println("seed applied:", filepath.Base(file))
 	}
 
-	fmt.Println("migrations applied")
+	return nil
 }
 
 // Log represents the logger
@@ -6,7 +6,15 @@ import (
 	"os/signal"
 	"sso/internal/app"
 	"sso/internal/config"
+	"sso/internal/lib/blob/fsblob"
 	"sso/internal/lib/logger/handlers/slogpretty"
+	"sso/internal/lib/mail/filemail"
+	"sso/internal/lib/mail/smtpmail"
+	"sso/internal/services/auth"
+	"sso/internal/services/core"
+	"sso/internal/services/keys"
+	"sso/internal/services/throttle"
+	"sso/internal/storage/sqlite"
 	"syscall"
 )
 
@@ -16,13 +24,51 @@ const (
 	envProd  = "prod"
 )
 
+const (
+	mailDriverSMTP = "smtp"
+	mailDriverFile = "file"
+)
+
+const mediaDriverFS = "fs"
+
 func main() {
 	cfg := config.MustLoad()
 
 	log := setupLogger(cfg.Env)
 
-	grpcApplication := app.NewGrpc(log, cfg.GRPC.Port, cfg.StoragePath, cfg.TokenTTL)
-	restApplication := app.NewRest(log, cfg.REST.Port, cfg.StoragePath, cfg.TokenTTL)
+	storage, err := sqlite.New(cfg.StoragePath)
+	if err != nil {
+		panic(err)
+	}
+
+	mailer := setupMailer(log, cfg.Env, cfg.Mail)
+	loginLimiter := setupLoginLimiter(log, storage, cfg.BruteForce)
+	blobStore := setupBlobStore(cfg.Media)
+
+	// gRPC and REST servers share services, so in-memory caches like revoked sessions are common.
+	keysService := keys.New(log, storage, cfg.Signing.Algorithm, cfg.Signing.RotationPeriod, cfg.Signing.GracePeriod)
+	authService := auth.New(log, storage, keysService, mailer, loginLimiter, auth.Config{
+		TokenTTL:           cfg.TokenTTL,
+		RefreshTokenTTL:    cfg.RefreshTokenTTL,
+		RevocationCacheTTL: cfg.RevocationCacheTTL,
+		Verification: auth.VerificationConfig{
+			Required:  cfg.EmailVerification.Required,
+			TokenTTL:  cfg.EmailVerification.TokenTTL,
+			URL:       cfg.EmailVerification.URL,
+			ChangeURL: cfg.EmailVerification.ChangeURL,
+		},
+		PasswordReset: auth.PasswordResetConfig{
+			TokenTTL: cfg.PasswordReset.TokenTTL,
+			URL:      cfg.PasswordReset.URL,
+		},
+		MFA: auth.MFAConfig{
+			Issuer:       cfg.MFA.Issuer,
+			ChallengeTTL: cfg.MFA.ChallengeTTL,
+		},
+	})
+
+	grpcApplication := app.NewGrpc(log, cfg.GRPC.Port, authService, cfg.BruteForce.TrustProxy)
+	restApplication := app.NewRest(log, cfg, storage, keysService, authService, blobStore)
 
 	go func() {
 		grpcApplication.GRPCServer.MustRun()
@@ -32,6 +78,8 @@ func main() {
 		restApplication.RestServer.MustRun()
 	}()
 
+	go restApplication.Scheduler.Run()
+
 	// Graceful shutdown
 
 	stop := make(chan os.Signal, 1)
@@ -40,6 +88,7 @@ func main() {
 	<-stop
 
 	grpcApplication.GRPCServer.Stop()
+	restApplication.Scheduler.Stop()
 	log.Info("Gracefully stopped")
 }
 
@@ -62,6 +111,58 @@ func setupLogger(env string) *slog.Logger {
 	return log
 }
 
+func setupMailer(log *slog.Logger, env string, cfg config.MailConfig) auth.Mailer {
+	// Verification links and reset codes must reach users, not files or logs on the server.
+	if env == envProd && cfg.Driver != mailDriverSMTP {
+		panic("mail driver must be smtp in prod, got: " + cfg.Driver)
+	}
+
+	switch cfg.Driver {
+	case mailDriverSMTP:
+		if cfg.SMTP.Host == "" {
+			panic("mail smtp host is required")
+		}
+
+		return smtpmail.New(cfg.SMTP.Host, cfg.SMTP.Port, cfg.SMTP.Username, cfg.SMTP.Password, cfg.From)
+	case mailDriverFile:
+		return filemail.New(log, cfg.Dir, cfg.From)
+	default:
+		panic("unknown mail driver: " + cfg.Driver)
+	}
+}
+
+func setupBlobStore(cfg config.MediaConfig) core.BlobStore {
+	switch cfg.Driver {
+	case mediaDriverFS:
+		return fsblob.New(cfg.Dir, cfg.BaseURL)
+	default:
+		panic("unknown media driver: " + cfg.Driver)
+	}
+}
+
+// setupLoginLimiter creates brute force protection shared by gRPC and REST servers.
+func setupLoginLimiter(log *slog.Logger, storage *sqlite.Storage, cfg config.BruteForceConfig) *throttle.Throttle {
+	var store throttle.Store = throttle.NewMemoryStore()
+	if cfg.Persist {
+		store = storage
+	}
+
+	account := throttle.Policy{
+		FreeAttempts:    cfg.Account.FreeAttempts,
+		BaseDelay:       cfg.Account.BaseDelay,
+		MaxDelay:        cfg.Account.MaxDelay,
+		LockoutAfter:    cfg.Account.LockoutAfter,
+		LockoutDuration: cfg.Account.LockoutDuration,
+	}
+	ip := throttle.Policy{
+		FreeAttempts: cfg.IP.FreeAttempts,
+		BaseDelay:    cfg.IP.BaseDelay,
+		MaxDelay:     cfg.IP.MaxDelay,
+	}
+
+	return throttle.New(log, store, account, ip, cfg.Window)
+}
+
 func setupPrettySlog() *slog.Logger {
 	opts := slogpretty.PrettyHandlerOptions{
 		SlogOpts: &slog.HandlerOptions{
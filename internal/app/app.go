@@ -2,34 +2,35 @@ package app
 
 import (
 	"log/slog"
+	"net/http"
 	grpcapp "sso/internal/app/grpc"
 	restapp "sso/internal/app/rest"
+	"sso/internal/config"
 	"sso/internal/services/auth"
 	"sso/internal/services/core"
+	"sso/internal/services/keys"
+	"sso/internal/services/oidc"
+	"sso/internal/services/scheduler"
 	"sso/internal/storage/sqlite"
-	"time"
 )
 
 type App struct {
 	log        *slog.Logger
 	GRPCServer *grpcapp.App
 	RestServer *restapp.App
+	Scheduler  *scheduler.Scheduler
 	port       int
 }
 
+// NewGrpc creates gRPC server app. Services are shared with the REST app,
+// so both see the same caches, e.g. session revocation is immediate on both.
 func NewGrpc(
 	log *slog.Logger,
 	port int,
-	storagePath string,
-	tokenTTL time.Duration,
+	authService *auth.Auth,
+	trustProxy bool,
 ) *App {
-	storage, err := sqlite.New(storagePath)
-	if err != nil {
-		panic(err)
-	}
-
-	authService := auth.New(log, storage, storage, storage, tokenTTL)
-	grpcApp := grpcapp.New(log, authService, port)
+	grpcApp := grpcapp.New(log, authService, port, trustProxy)
 
 	return &App{
 		log:        log,
@@ -38,23 +39,34 @@ func NewGrpc(
 	}
 }
 
+// NewRest creates REST server app and content publishing scheduler on top of shared storage and services.
 func NewRest(
 	log *slog.Logger,
-	port int,
-	storagePath string,
-	tokenTTL time.Duration,
+	cfg *config.Config,
+	storage *sqlite.Storage,
+	keysService *keys.Keys,
+	authService *auth.Auth,
+	blobStore core.BlobStore,
 ) *App {
-	storage, err := sqlite.New(storagePath)
-	if err != nil {
-		panic(err)
-	}
-
-	coreService := core.New(log, storage, storage, storage, storage, tokenTTL)
-	restApp := restapp.New(log, coreService, port)
+	oidcService := oidc.New(log, authService, storage, keysService, oidc.Config{
+		Issuer:           cfg.OIDC.Issuer,
+		SigningAlgorithm: cfg.Signing.Algorithm,
+		CodeTTL:          cfg.OIDC.CodeTTL,
+		TokenTTL:         cfg.TokenTTL,
+		TrustProxy:       cfg.BruteForce.TrustProxy,
+	})
+	coreService := core.New(log, storage, authService, blobStore, core.Config{
+		MaxAvatarSize: cfg.Media.MaxAvatarSize,
+		TrustProxy:    cfg.BruteForce.TrustProxy,
+	})
+	// Stores keeping files locally serve them through the REST server.
+	media, _ := blobStore.(http.Handler)
+	restApp := restapp.New(log, coreService, keysService, oidcService, authService, media, cfg.REST.Port)
 
 	return &App{
 		log:        log,
 		RestServer: restApp,
-		port:       port,
+		Scheduler:  scheduler.New(log, storage, cfg.Publishing.Interval),
+		port:       cfg.REST.Port,
 	}
 }
@@ -24,13 +24,12 @@ func New(
 	log *slog.Logger,
 	authService authgrpc.Auth,
 	port int,
+	trustProxy bool,
 ) *App {
+	// Payloads are never logged: requests and responses carry passwords, tokens,
+	// TOTP secrets and recovery codes. Only method, status code and duration are.
 	loggingOpts := []logging.Option{
-		logging.WithLogOnEvents(
-			//logging.StartCall, logging.FinishCall,
-			logging.PayloadReceived, logging.PayloadSent,
-		),
-		// Add any other option (check functions starting with logging.With).
+		logging.WithLogOnEvents(logging.FinishCall),
 	}
 
 	recoveryOpts := []recovery.Option{
@@ -44,9 +43,10 @@ func New(
 	gRPCServer := grpc.NewServer(grpc.ChainUnaryInterceptor(
 		recovery.UnaryServerInterceptor(recoveryOpts...),
 		logging.UnaryServerInterceptor(InterceptorLogger(log), loggingOpts...),
+		authgrpc.PermissionInterceptor(authService),
 	))
 
-	authgrpc.Register(gRPCServer, authService)
+	authgrpc.Register(gRPCServer, authService, trustProxy)
 
 	return &App{
 		log:        log,
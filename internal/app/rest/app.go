@@ -4,30 +4,53 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"github.com/golang-jwt/jwt/v5"
 	"github.com/gorilla/mux"
 	"log/slog"
 	"net/http"
+	"sso/internal/domain/models"
+	"sso/internal/lib/jwt"
+	"sso/internal/lib/logger/sl"
+	"sso/internal/services/auth"
 	"sso/internal/services/core"
+	"sso/internal/services/keys"
+	"sso/internal/services/oidc"
 	"strings"
 )
 
+type TokenValidator interface {
+	ValidateToken(ctx context.Context, token string) (jwt.Claims, error)
+	HasPermission(ctx context.Context, roles []string, permission string) (bool, error)
+}
+
 type App struct {
-	log         *slog.Logger
-	httpServer  *http.Server
-	port        int
-	coreService *core.Core
+	log            *slog.Logger
+	httpServer     *http.Server
+	port           int
+	coreService    *core.Core
+	keysService    *keys.Keys
+	oidcService    *oidc.OIDC
+	tokenValidator TokenValidator
+	// media serves uploaded files under /media/, nil if they are served elsewhere.
+	media http.Handler
 }
 
 func New(
 	log *slog.Logger,
 	coreService *core.Core,
+	keysService *keys.Keys,
+	oidcService *oidc.OIDC,
+	tokenValidator TokenValidator,
+	media http.Handler,
 	port int,
 ) *App {
 	return &App{
-		log:         log,
-		port:        port,
-		coreService: coreService,
+		log:            log,
+		port:           port,
+		coreService:    coreService,
+		keysService:    keysService,
+		oidcService:    oidcService,
+		tokenValidator: tokenValidator,
+		media:          media,
 	}
 }
 
@@ -40,7 +63,8 @@ func (a *App) MustRun() {
 
 type MiddlewareFunc func(http.Handler) http.Handler
 
-// AuthMiddleware middleware function for JWT token validation and UID extraction
+// AuthMiddleware middleware function for JWT token validation and UID extraction.
+// Revoked tokens and tokens of deleted users are rejected.
 func (a *App) AuthMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		authHeader := r.Header.Get("Authorization")
@@ -51,40 +75,66 @@ func (a *App) AuthMiddleware(next http.Handler) http.Handler {
 
 		tokenString := strings.Replace(authHeader, "Bearer ", "", 1)
 
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			return []byte("test-secret"), nil
-		})
+		claims, err := a.tokenValidator.ValidateToken(r.Context(), tokenString)
 		if err != nil {
-			http.Error(w, fmt.Sprintf("Failed to parse JWT token: %v", err), http.StatusUnauthorized)
-			return
-		}
-
-		claims, ok := token.Claims.(jwt.MapClaims)
-		if !ok {
-			http.Error(w, "Invalid JWT token claims", http.StatusUnauthorized)
-			return
-		}
+			if errors.Is(err, auth.ErrInvalidToken) {
+				http.Error(w, "Invalid or revoked JWT token", http.StatusUnauthorized)
+				return
+			}
 
-		uidFloat64, ok := claims["uid"].(float64)
-		if !ok {
-			http.Error(w, "UID not found in JWT token claims", http.StatusUnauthorized)
+			a.log.Error("failed to validate token", sl.Err(err))
+			http.Error(w, "Failed to validate JWT token", http.StatusInternalServerError)
 			return
 		}
 
-		uid := int64(uidFloat64)
-
-		ctx := context.WithValue(r.Context(), "uid", uid)
+		ctx := context.WithValue(r.Context(), "uid", claims.UID)
+		ctx = context.WithValue(ctx, "roles", claims.Roles)
+		ctx = context.WithValue(ctx, "sid", claims.SessionID)
 
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
 
+// RequirePermission returns middleware that lets through only users whose roles grant permission.
+// It must be used after AuthMiddleware.
+func (a *App) RequirePermission(permission string) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			roles, _ := r.Context().Value("roles").([]string)
+
+			allowed, err := a.tokenValidator.HasPermission(r.Context(), roles, permission)
+			if err != nil {
+				a.log.Error("failed to check permission", sl.Err(err))
+				http.Error(w, "Failed to check permission", http.StatusInternalServerError)
+				return
+			}
+
+			if !allowed {
+				http.Error(w, "Permission denied", http.StatusForbidden)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
 // Run runs the HTTP server.
 func (a *App) Run() error {
 	const op = "restapp.Run"
 
 	router := mux.NewRouter()
 
+	router.HandleFunc("/.well-known/jwks.json", a.keysService.JWKSHandler).Methods("GET")
+	router.HandleFunc("/.well-known/openid-configuration", a.oidcService.DiscoveryHandler).Methods("GET")
+	router.HandleFunc("/authorize", a.oidcService.AuthorizeHandler).Methods("GET", "POST")
+	router.HandleFunc("/token", a.oidcService.TokenHandler).Methods("POST")
+	router.HandleFunc("/user/email/confirm", a.coreService.ConfirmEmailChangeHandler).Methods("POST")
+
+	if a.media != nil {
+		router.PathPrefix("/media/").Handler(http.StripPrefix("/media", a.media)).Methods("GET", "HEAD")
+	}
+
 	authMiddleware := func(next http.Handler) http.Handler {
 		return a.AuthMiddleware(next)
 	}
@@ -94,9 +144,62 @@ func (a *App) Run() error {
 
 	authRouter.HandleFunc("/user", a.coreService.DeleteUserHandler).Methods("DELETE")
 	authRouter.HandleFunc("/user", a.coreService.GetUserHandler).Methods("GET")
+	authRouter.HandleFunc("/user", a.coreService.UpdateUserHandler).Methods("PATCH")
+	authRouter.HandleFunc("/user/avatar", a.coreService.UpdateAvatarHandler).Methods("PUT")
+	authRouter.HandleFunc("/users/{id:[0-9]+}", a.coreService.GetPublicUserHandler).Methods("GET")
+	authRouter.HandleFunc("/user/password", a.coreService.ChangePasswordHandler).Methods("PUT")
+	authRouter.HandleFunc("/user/email", a.coreService.ChangeEmailHandler).Methods("PUT")
 	authRouter.HandleFunc("/article", a.coreService.GetArticlesHandler).Methods("GET")
+	authRouter.HandleFunc("/article/{id:[0-9]+}", a.coreService.GetArticleHandler).Methods("GET")
+	authRouter.HandleFunc("/article/by-slug/{slug}", a.coreService.GetArticleBySlugHandler).Methods("GET")
 	authRouter.HandleFunc("/course", a.coreService.GetCoursesHandler).Methods("GET")
+	authRouter.HandleFunc("/course/{id:[0-9]+}", a.coreService.GetCourseHandler).Methods("GET")
+	authRouter.HandleFunc("/course/{id:[0-9]+}/lesson/{lessonId:[0-9]+}", a.coreService.GetLessonHandler).Methods("GET")
+	authRouter.HandleFunc("/course/{id:[0-9]+}/enroll", a.coreService.EnrollHandler).Methods("POST")
+	authRouter.HandleFunc("/lesson/{id:[0-9]+}/complete", a.coreService.CompleteLessonHandler).Methods("POST")
+	authRouter.HandleFunc("/me/progress", a.coreService.GetProgressHandler).Methods("GET")
+	authRouter.HandleFunc("/search", a.coreService.SearchHandler).Methods("GET")
+	authRouter.HandleFunc("/me/bookmarks", a.coreService.GetBookmarksHandler).Methods("GET")
+	authRouter.HandleFunc("/me/bookmarks/{type}/{id:[0-9]+}", a.coreService.AddBookmarkHandler).Methods("PUT")
+	authRouter.HandleFunc("/me/bookmarks/{type}/{id:[0-9]+}", a.coreService.RemoveBookmarkHandler).Methods("DELETE")
 	authRouter.HandleFunc("/feed", a.coreService.GetFeedHandler).Methods("GET")
+	authRouter.HandleFunc("/userinfo", a.oidcService.UserInfoHandler).Methods("GET", "POST")
+	authRouter.HandleFunc("/sessions", a.coreService.GetSessionsHandler).Methods("GET")
+	authRouter.HandleFunc("/sessions/{id:[0-9]+}", a.coreService.DeleteSessionHandler).Methods("DELETE")
+
+	// Content management routes for articles, courses and feed require content:write,
+	// reading published content stays open to all authenticated users.
+	contentRouter := authRouter.NewRoute().Subrouter()
+	contentRouter.Use(a.RequirePermission(models.PermissionContentWrite))
+
+	contentRouter.HandleFunc("/article", a.coreService.CreateArticleHandler).Methods("POST")
+	contentRouter.HandleFunc("/article/{id:[0-9]+}", a.coreService.ReplaceArticleHandler).Methods("PUT")
+	contentRouter.HandleFunc("/article/{id:[0-9]+}", a.coreService.PatchArticleHandler).Methods("PATCH")
+	contentRouter.HandleFunc("/article/{id:[0-9]+}", a.coreService.DeleteArticleHandler).Methods("DELETE")
+	contentRouter.HandleFunc("/article/{id:[0-9]+}/preview", a.coreService.PreviewArticleHandler).Methods("GET")
+	contentRouter.HandleFunc("/article/{id:[0-9]+}/revisions", a.coreService.GetArticleRevisionsHandler).Methods("GET")
+	contentRouter.HandleFunc("/article/{id:[0-9]+}/revisions/diff", a.coreService.DiffArticleRevisionsHandler).Methods("GET")
+	contentRouter.HandleFunc("/article/{id:[0-9]+}/revisions/{rev:[0-9]+}", a.coreService.GetArticleRevisionHandler).Methods("GET")
+	contentRouter.HandleFunc("/article/{id:[0-9]+}/revisions/{rev:[0-9]+}/restore", a.coreService.RestoreArticleRevisionHandler).Methods("POST")
+	contentRouter.HandleFunc("/course", a.coreService.CreateCourseHandler).Methods("POST")
+	contentRouter.HandleFunc("/course/{id:[0-9]+}", a.coreService.ReplaceCourseHandler).Methods("PUT")
+	contentRouter.HandleFunc("/course/{id:[0-9]+}", a.coreService.PatchCourseHandler).Methods("PATCH")
+	contentRouter.HandleFunc("/course/{id:[0-9]+}", a.coreService.DeleteCourseHandler).Methods("DELETE")
+	contentRouter.HandleFunc("/course/{id:[0-9]+}/preview", a.coreService.PreviewCourseHandler).Methods("GET")
+	contentRouter.HandleFunc("/course/{id:[0-9]+}/module", a.coreService.CreateModuleHandler).Methods("POST")
+	contentRouter.HandleFunc("/course/{id:[0-9]+}/module/{moduleId:[0-9]+}", a.coreService.ReplaceModuleHandler).Methods("PUT")
+	contentRouter.HandleFunc("/course/{id:[0-9]+}/module/{moduleId:[0-9]+}", a.coreService.PatchModuleHandler).Methods("PATCH")
+	contentRouter.HandleFunc("/course/{id:[0-9]+}/module/{moduleId:[0-9]+}", a.coreService.DeleteModuleHandler).Methods("DELETE")
+	contentRouter.HandleFunc("/course/{id:[0-9]+}/module/{moduleId:[0-9]+}/lesson", a.coreService.CreateLessonHandler).Methods("POST")
+	contentRouter.HandleFunc("/course/{id:[0-9]+}/lesson/{lessonId:[0-9]+}", a.coreService.ReplaceLessonHandler).Methods("PUT")
+	contentRouter.HandleFunc("/course/{id:[0-9]+}/lesson/{lessonId:[0-9]+}", a.coreService.PatchLessonHandler).Methods("PATCH")
+	contentRouter.HandleFunc("/course/{id:[0-9]+}/lesson/{lessonId:[0-9]+}", a.coreService.DeleteLessonHandler).Methods("DELETE")
+	contentRouter.HandleFunc("/course/{id:[0-9]+}/lesson/{lessonId:[0-9]+}/preview", a.coreService.PreviewLessonHandler).Methods("GET")
+	contentRouter.HandleFunc("/feed", a.coreService.CreateFeedHandler).Methods("POST")
+	contentRouter.HandleFunc("/feed/{id:[0-9]+}", a.coreService.ReplaceFeedHandler).Methods("PUT")
+	contentRouter.HandleFunc("/feed/{id:[0-9]+}", a.coreService.PatchFeedHandler).Methods("PATCH")
+	contentRouter.HandleFunc("/feed/{id:[0-9]+}", a.coreService.DeleteFeedHandler).Methods("DELETE")
+	contentRouter.HandleFunc("/feed/{id:[0-9]+}/preview", a.coreService.PreviewFeedHandler).Methods("GET")
 
 	a.httpServer = &http.Server{
 		Addr:    fmt.Sprintf(":%d", a.port),
@@ -8,12 +8,25 @@ import (
 )
 
 type Config struct {
-	Env            string     `yaml:"env" env-default:"local"`
-	StoragePath    string     `yaml:"storage_path" env-required:"true"`
-	GRPC           GRPCConfig `yaml:"grpc"`
-	REST           RESTConfig `yaml:"rest"`
-	MigrationsPath string
-	TokenTTL       time.Duration `yaml:"token_ttl" env-default:"720h"`
+	Env             string     `yaml:"env" env-default:"local"`
+	StoragePath     string     `yaml:"storage_path" env-required:"true"`
+	GRPC            GRPCConfig `yaml:"grpc"`
+	REST            RESTConfig `yaml:"rest"`
+	MigrationsPath  string
+	TokenTTL        time.Duration `yaml:"token_ttl" env-default:"15m"`
+	RefreshTokenTTL time.Duration `yaml:"refresh_token_ttl" env-default:"720h"`
+	// RevocationCacheTTL bounds how long REST may keep accepting a token
+	// after it was revoked through another process.
+	RevocationCacheTTL time.Duration           `yaml:"revocation_cache_ttl" env-default:"10s"`
+	Signing            SigningConfig           `yaml:"signing"`
+	OIDC               OIDCConfig              `yaml:"oidc"`
+	EmailVerification  EmailVerificationConfig `yaml:"email_verification"`
+	PasswordReset      PasswordResetConfig     `yaml:"password_reset"`
+	MFA                MFAConfig               `yaml:"mfa"`
+	BruteForce         BruteForceConfig        `yaml:"brute_force"`
+	Mail               MailConfig              `yaml:"mail"`
+	Media              MediaConfig             `yaml:"media"`
+	Publishing         PublishingConfig        `yaml:"publishing"`
 }
 
 type GRPCConfig struct {
@@ -26,6 +39,106 @@ type RESTConfig struct {
 	Timeout time.Duration `yaml:"timeout"`
 }
 
+type SigningConfig struct {
+	// Algorithm is either EdDSA or RS256.
+	Algorithm      string        `yaml:"algorithm" env-default:"EdDSA"`
+	RotationPeriod time.Duration `yaml:"rotation_period" env-default:"720h"`
+	// GracePeriod is how long retired keys stay valid for verification,
+	// it must be longer than token_ttl.
+	GracePeriod time.Duration `yaml:"grace_period" env-default:"24h"`
+}
+
+type OIDCConfig struct {
+	// Issuer is the public base URL of the REST server.
+	Issuer  string        `yaml:"issuer" env-default:"http://localhost:4042"`
+	CodeTTL time.Duration `yaml:"code_ttl" env-default:"1m"`
+}
+
+type EmailVerificationConfig struct {
+	// Required makes Login refuse users who haven't confirmed their email.
+	Required bool          `yaml:"required" env-default:"false"`
+	TokenTTL time.Duration `yaml:"token_ttl" env-default:"24h"`
+	// URL is the client page that confirms email, token is appended as query parameter.
+	URL string `yaml:"url" env-default:"http://localhost:3000/verify-email"`
+	// ChangeURL is the client page that confirms new email on email change.
+	ChangeURL string `yaml:"change_url" env-default:"http://localhost:3000/confirm-email-change"`
+}
+
+type PasswordResetConfig struct {
+	TokenTTL time.Duration `yaml:"token_ttl" env-default:"1h"`
+	// URL is the client page where user sets a new password, token is appended as query parameter.
+	URL string `yaml:"url" env-default:"http://localhost:3000/reset-password"`
+}
+
+type MFAConfig struct {
+	// Issuer is shown as account issuer in authenticator apps.
+	Issuer string `yaml:"issuer" env-default:"IT-Navigator"`
+	// ChallengeTTL is how long user has to enter TOTP code after password check.
+	ChallengeTTL time.Duration `yaml:"challenge_ttl" env-default:"5m"`
+}
+
+type BruteForceConfig struct {
+	// Window is how long failed login attempts are remembered after the last one.
+	Window time.Duration `yaml:"window" env-default:"15m"`
+	// Persist keeps counters in SQLite, so they survive restarts.
+	Persist bool `yaml:"persist" env-default:"false"`
+	// TrustProxy takes client IP from X-Forwarded-For, enable it only behind a reverse proxy.
+	TrustProxy bool                  `yaml:"trust_proxy" env-default:"false"`
+	Account    AccountThrottleConfig `yaml:"account"`
+	IP         IPThrottleConfig      `yaml:"ip"`
+}
+
+// AccountThrottleConfig limits failed logins per email.
+type AccountThrottleConfig struct {
+	// FreeAttempts is how many failures are allowed before delays start.
+	FreeAttempts int `yaml:"free_attempts" env-default:"3"`
+	// BaseDelay doubles with every next failure up to MaxDelay.
+	BaseDelay time.Duration `yaml:"base_delay" env-default:"1s"`
+	MaxDelay  time.Duration `yaml:"max_delay" env-default:"1m"`
+	// LockoutAfter failures lock account for LockoutDuration, zero disables lockout.
+	LockoutAfter    int           `yaml:"lockout_after" env-default:"10"`
+	LockoutDuration time.Duration `yaml:"lockout_duration" env-default:"15m"`
+}
+
+// IPThrottleConfig limits failed logins per client IP.
+type IPThrottleConfig struct {
+	FreeAttempts int           `yaml:"free_attempts" env-default:"20"`
+	BaseDelay    time.Duration `yaml:"base_delay" env-default:"1s"`
+	MaxDelay     time.Duration `yaml:"max_delay" env-default:"5m"`
+}
+
+type MailConfig struct {
+	// Driver is either smtp or file, prod environment requires smtp.
+	Driver string `yaml:"driver" env-default:"file"`
+	From   string `yaml:"from" env:"MAIL_FROM" env-default:"IT-Navigator <no-reply@localhost>"`
+	// Dir is where file driver writes messages, if empty they are dropped.
+	Dir  string     `yaml:"dir"`
+	SMTP SMTPConfig `yaml:"smtp"`
+}
+
+type SMTPConfig struct {
+	Host     string `yaml:"host" env:"SMTP_HOST"`
+	Port     int    `yaml:"port" env-default:"587"`
+	Username string `yaml:"username" env:"SMTP_USERNAME"`
+	Password string `yaml:"password" env:"SMTP_PASSWORD"`
+}
+
+type MediaConfig struct {
+	// Driver selects where uploaded files are stored, only fs is supported for now.
+	Driver string `yaml:"driver" env-default:"fs"`
+	// Dir is where fs driver keeps files, REST server serves them under /media/.
+	Dir string `yaml:"dir" env-default:"./storage/media"`
+	// BaseURL is the public URL stored files are served from.
+	BaseURL string `yaml:"base_url" env-default:"http://localhost:4042/media"`
+	// MaxAvatarSize is the largest accepted avatar upload in bytes.
+	MaxAvatarSize int64 `yaml:"max_avatar_size" env-default:"5242880"`
+}
+
+type PublishingConfig struct {
+	// Interval is how often scheduled content is checked, it is published at most this late.
+	Interval time.Duration `yaml:"interval" env-default:"1m"`
+}
+
 func MustLoad() *Config {
 	configPath := fetchConfigPath()
 	if configPath == "" {
@@ -1,6 +1,9 @@
 package models
 
 type App struct {
+	ID     int
 	Name   string
 	Secret string
+	// RedirectURIs are registered OpenID Connect redirect URIs of the client.
+	RedirectURIs []string
 }
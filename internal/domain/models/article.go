@@ -1,8 +1,41 @@
 package models
 
+import "time"
+
 type Article struct {
-	ID          int64  `json:"id"`
-	Name        string `json:"name"`
-	Description string `json:"description"`
-	Image       string `json:"image"`
+	ID          int64      `json:"id"`
+	Slug        string     `json:"slug"`
+	Name        string     `json:"name"`
+	Description string     `json:"description"`
+	Image       string     `json:"image"`
+	AuthorID    *int64     `json:"author_id,omitempty"`
+	UpdatedBy   *int64     `json:"updated_by,omitempty"`
+	Difficulty  string     `json:"difficulty,omitempty"`
+	Tags        []string   `json:"tags"`
+	Views       int64      `json:"views"`
+	Status      string     `json:"status"`
+	Version     int64      `json:"version"`
+	PublishedAt *time.Time `json:"published_at,omitempty"`
+	PublishAt   *time.Time `json:"publish_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// ArticleDetails is a full article as shown on its own page, lists contain only Article summaries.
+type ArticleDetails struct {
+	Article
+	// Body is the Markdown source of the article.
+	Body string `json:"body"`
+	// HTML is Body rendered and sanitized.
+	HTML               string           `json:"html"`
+	ReadingTimeMinutes int              `json:"reading_time_minutes"`
+	TableOfContents    []ArticleHeading `json:"table_of_contents"`
+	Related            []Article        `json:"related"`
+}
+
+type ArticleHeading struct {
+	Level int    `json:"level"`
+	Title string `json:"title"`
+	// Anchor is the id of the heading element in HTML.
+	Anchor string `json:"anchor"`
 }
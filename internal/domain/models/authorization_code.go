@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+type AuthorizationCode struct {
+	CodeHash            string
+	AppID               int
+	UserID              int64
+	RedirectURI         string
+	Scope               string
+	Nonce               string
+	CodeChallenge       string
+	CodeChallengeMethod string
+	ExpiresAt           time.Time
+}
@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+// BookmarkQuery selects a page of published content bookmarked by user, the latest bookmarks first.
+type BookmarkQuery struct {
+	UserID int64
+	// Type limits bookmarks to articles or courses, empty means both.
+	Type  string
+	Limit int
+	// Cursor is NextCursor of the previous page, empty for the first page.
+	Cursor string
+}
+
+type Bookmark struct {
+	Type         string    `json:"type"`
+	ID           int64     `json:"id"`
+	Slug         string    `json:"slug"`
+	Name         string    `json:"name"`
+	Description  string    `json:"description"`
+	Image        string    `json:"image"`
+	BookmarkedAt time.Time `json:"bookmarked_at"`
+}
+
+// ArticleListItem is an article of a list with whether the user reading the list bookmarked it.
+type ArticleListItem struct {
+	Article
+	Bookmarked bool `json:"bookmarked"`
+}
+
+// CourseListItem is a course of a list with whether the user reading the list bookmarked it.
+type CourseListItem struct {
+	Course
+	Bookmarked bool `json:"bookmarked"`
+}
@@ -0,0 +1,8 @@
+package models
+
+// Client describes where a login request came from.
+type Client struct {
+	IP         string
+	UserAgent  string
+	DeviceName string
+}
@@ -0,0 +1,41 @@
+package models
+
+// Publish statuses of articles, courses and feed items. Only published content is listed to readers.
+// Content in review waits for approval, it may be scheduled to be published at PublishAt.
+const (
+	ContentStatusDraft     = "draft"
+	ContentStatusInReview  = "in_review"
+	ContentStatusPublished = "published"
+	ContentStatusArchived  = "archived"
+)
+
+const (
+	DifficultyBeginner     = "beginner"
+	DifficultyIntermediate = "intermediate"
+	DifficultyAdvanced     = "advanced"
+)
+
+// Orders of content lists.
+const (
+	// ContentSortDate lists most recently published content first.
+	ContentSortDate = "date"
+	// ContentSortPopularity lists most viewed content first.
+	ContentSortPopularity = "popularity"
+)
+
+// ContentQuery selects a page of published content. Zero values of filters match any content.
+type ContentQuery struct {
+	Limit int
+	// Cursor is NextCursor of the previous page, empty for the first page.
+	Cursor     string
+	Sort       string
+	Tag        string
+	AuthorID   int64
+	Difficulty string
+}
+
+// Page is a part of a list, NextCursor is empty on the last page.
+type Page[T any] struct {
+	Items      []T    `json:"items"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
@@ -1,7 +1,35 @@
 package models
 
+import "time"
+
 type Course struct {
-	ID    int64  `json:"id"`
-	Name  string `json:"name"`
-	Image string `json:"image"`
+	ID          int64      `json:"id"`
+	Slug        string     `json:"slug"`
+	Name        string     `json:"name"`
+	Description string     `json:"description"`
+	Image       string     `json:"image"`
+	AuthorID    *int64     `json:"author_id,omitempty"`
+	Difficulty  string     `json:"difficulty,omitempty"`
+	Tags        []string   `json:"tags"`
+	Views       int64      `json:"views"`
+	Status      string     `json:"status"`
+	Version     int64      `json:"version"`
+	PublishedAt *time.Time `json:"published_at,omitempty"`
+	PublishAt   *time.Time `json:"publish_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// CourseDetails is a course with its modules and lessons, as shown on the course page.
+type CourseDetails struct {
+	Course
+	Modules []CourseModule `json:"modules"`
+}
+
+type CourseModule struct {
+	ID          int64           `json:"id"`
+	Position    int             `json:"position"`
+	Title       string          `json:"title"`
+	Description string          `json:"description"`
+	Lessons     []LessonSummary `json:"lessons"`
 }
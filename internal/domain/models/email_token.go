@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// Purposes of single-use tokens sent by email.
+const (
+	EmailTokenVerify        = "verify_email"
+	EmailTokenPasswordReset = "password_reset"
+	EmailTokenChangeEmail   = "change_email"
+)
+
+type EmailToken struct {
+	TokenHash string
+	UserID    int64
+	Purpose   string
+	// Email is the address token was sent to.
+	Email     string
+	ExpiresAt time.Time
+}
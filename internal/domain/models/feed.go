@@ -1,7 +1,23 @@
 package models
 
+import "time"
+
 type Feed struct {
-	ID    int64  `json:"id"`
-	Name  string `json:"name"`
-	Image string `json:"image"`
+	ID          int64  `json:"id"`
+	Slug        string `json:"slug"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Image       string `json:"image"`
+	// Link points to the article, course or external page the feed item announces, empty if none.
+	Link        string     `json:"link"`
+	AuthorID    *int64     `json:"author_id,omitempty"`
+	Difficulty  string     `json:"difficulty,omitempty"`
+	Tags        []string   `json:"tags"`
+	Views       int64      `json:"views"`
+	Status      string     `json:"status"`
+	Version     int64      `json:"version"`
+	PublishedAt *time.Time `json:"published_at,omitempty"`
+	PublishAt   *time.Time `json:"publish_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
 }
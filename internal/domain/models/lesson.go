@@ -0,0 +1,45 @@
+package models
+
+const (
+	LessonTypeText     = "text"
+	LessonTypeVideo    = "video"
+	LessonTypeQuiz     = "quiz"
+	LessonTypeExternal = "external"
+)
+
+// LessonSummary is a lesson as listed in the course tree, without its content.
+type LessonSummary struct {
+	ID              int64  `json:"id"`
+	Position        int    `json:"position"`
+	Title           string `json:"title"`
+	Type            string `json:"type"`
+	DurationMinutes *int   `json:"duration_minutes,omitempty"`
+}
+
+type Lesson struct {
+	LessonSummary
+	CourseID int64 `json:"course_id"`
+	ModuleID int64 `json:"module_id"`
+	// UpdatedBy is the user who changed the lesson last, it is filled only for editors.
+	UpdatedBy *int64 `json:"updated_by,omitempty"`
+	// Body is Markdown text of text lessons and a description of the others.
+	Body string `json:"body"`
+	// HTML is Body rendered and sanitized.
+	HTML string `json:"html"`
+	// URL is the link of video and external lessons.
+	URL  string         `json:"url,omitempty"`
+	Quiz []QuizQuestion `json:"quiz,omitempty"`
+}
+
+type QuizQuestion struct {
+	ID      int64        `json:"id"`
+	Text    string       `json:"text"`
+	Options []QuizOption `json:"options"`
+}
+
+type QuizOption struct {
+	ID   int64  `json:"id"`
+	Text string `json:"text"`
+	// Correct is never sent to clients.
+	Correct bool `json:"-"`
+}
@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+// LoginFailures counts failed login attempts for a throttling key, e.g. email or IP.
+type LoginFailures struct {
+	Key           string
+	Count         int
+	LastFailureAt time.Time
+	BlockedUntil  time.Time
+}
@@ -0,0 +1,38 @@
+package models
+
+import "time"
+
+// Profile is what user sees about their own account.
+type Profile struct {
+	ID    int64  `json:"id"`
+	Email string `json:"email"`
+	Name  string `json:"name"`
+	Image string `json:"image"`
+	Bio   string `json:"bio"`
+	// CreatedAt is nil for users registered before registration time was recorded.
+	CreatedAt  *time.Time      `json:"created_at"`
+	Roles      []string        `json:"roles"`
+	Verified   bool            `json:"verified"`
+	MFAEnabled bool            `json:"mfa_enabled"`
+	Learning   LearningSummary `json:"learning"`
+}
+
+// PublicProfile is what other users see about the user.
+type PublicProfile struct {
+	ID        int64      `json:"id"`
+	Name      string     `json:"name"`
+	Image     string     `json:"image"`
+	Bio       string     `json:"bio"`
+	CreatedAt *time.Time `json:"created_at"`
+}
+
+// Public returns part of the profile visible to other users.
+func (p Profile) Public() PublicProfile {
+	return PublicProfile{
+		ID:        p.ID,
+		Name:      p.Name,
+		Image:     p.Image,
+		Bio:       p.Bio,
+		CreatedAt: p.CreatedAt,
+	}
+}
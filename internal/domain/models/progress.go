@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// CourseProgress is progress of user in a course they enrolled in.
+type CourseProgress struct {
+	Course            Course    `json:"course"`
+	EnrolledAt        time.Time `json:"enrolled_at"`
+	TotalLessons      int       `json:"total_lessons"`
+	CompletedLessons  int       `json:"completed_lessons"`
+	CompletionPercent int       `json:"completion_percent"`
+	// LastLesson is the lesson opened last, nil if none was opened yet.
+	LastLesson   *LessonSummary `json:"last_lesson,omitempty"`
+	LastOpenedAt *time.Time     `json:"last_opened_at,omitempty"`
+}
+
+// LearningSummary counts courses and lessons of user for their profile.
+type LearningSummary struct {
+	EnrolledCourses int `json:"enrolled_courses"`
+	// CompletedCourses are courses with all lessons completed.
+	CompletedCourses int `json:"completed_courses"`
+	CompletedLessons int `json:"completed_lessons"`
+}
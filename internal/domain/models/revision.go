@@ -0,0 +1,34 @@
+package models
+
+import "time"
+
+// Revision is the text of content as it was once saved, revisions of each article are numbered from 1.
+type Revision struct {
+	Number      int64     `json:"revision"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	AuthorID    *int64    `json:"author_id,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// RevisionDetails is a revision with its Markdown body, lists contain only Revision summaries.
+type RevisionDetails struct {
+	Revision
+	Body string `json:"body"`
+}
+
+// RevisionDiff is a line-level diff of the text fields of two revisions.
+type RevisionDiff struct {
+	From        int64      `json:"from"`
+	To          int64      `json:"to"`
+	Name        []DiffLine `json:"name"`
+	Description []DiffLine `json:"description"`
+	Body        []DiffLine `json:"body"`
+}
+
+type DiffLine struct {
+	// Op is equal for lines of both revisions, delete for lines of the older one
+	// and insert for lines of the newer one.
+	Op   string `json:"op"`
+	Text string `json:"text"`
+}
@@ -0,0 +1,15 @@
+package models
+
+// Built-in roles.
+const (
+	RoleAdmin  = "admin"
+	RoleAuthor = "author"
+)
+
+// Permissions checked by gRPC and REST handlers.
+const (
+	PermissionContentWrite = "content:write"
+	// PermissionContentPublish allows publishing content and scheduling it.
+	PermissionContentPublish = "content:publish"
+	PermissionRolesManage    = "roles:manage"
+)
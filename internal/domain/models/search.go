@@ -0,0 +1,29 @@
+package models
+
+// Content types returned by search.
+const (
+	ContentTypeArticle = "article"
+	ContentTypeCourse  = "course"
+	ContentTypeFeed    = "feed"
+)
+
+// SearchQuery selects a page of published content matching Query.
+type SearchQuery struct {
+	// Query is the text typed by user, every word is matched as a prefix.
+	Query string
+	// Types limits results to the given content types, empty means all of them.
+	Types []string
+	Limit int
+	// Cursor is NextCursor of the previous page, empty for the first page.
+	Cursor string
+}
+
+type SearchResult struct {
+	Type  string `json:"type"`
+	ID    int64  `json:"id"`
+	Slug  string `json:"slug"`
+	Name  string `json:"name"`
+	Image string `json:"image"`
+	// Snippet is an HTML-escaped fragment of the matched text with matches wrapped in <mark>.
+	Snippet string `json:"snippet"`
+}
@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// Session is a single login of user on some device, it lives as long as its refresh token family.
+type Session struct {
+	ID         int64     `json:"id"`
+	UserID     int64     `json:"-"`
+	AppID      int       `json:"app_id"`
+	FamilyID   string    `json:"-"`
+	DeviceName string    `json:"device_name"`
+	UserAgent  string    `json:"user_agent"`
+	IP         string    `json:"ip"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	Revoked    bool      `json:"-"`
+	// Current marks the session the request was made with.
+	Current bool `json:"current"`
+}
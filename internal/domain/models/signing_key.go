@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// SigningKey is an asymmetric key pair used to sign tokens.
+// Keys are stored DER encoded: private key as PKCS #8, public key as PKIX.
+type SigningKey struct {
+	ID         string
+	Algorithm  string
+	PrivateKey []byte
+	PublicKey  []byte
+	CreatedAt  time.Time
+	// RetiredAt is zero for the active key.
+	RetiredAt time.Time
+}
@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+type RefreshToken struct {
+	ID        int64
+	UserID    int64
+	AppID     int
+	FamilyID  string
+	TokenHash string
+	ExpiresAt time.Time
+	Used      bool
+	Revoked   bool
+}
+
+type TokenPair struct {
+	AccessToken  string
+	RefreshToken string
+	// MFAToken is set instead of the tokens above when user has to pass second factor first.
+	MFAToken string
+}
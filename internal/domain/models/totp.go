@@ -0,0 +1,9 @@
+package models
+
+type TOTP struct {
+	UserID int64
+	// Secret is base32 encoded, it is needed in plain form to compute codes.
+	Secret    string
+	Confirmed bool
+	LastStep  int64
+}
@@ -1,9 +1,18 @@
 package models
 
+import "time"
+
 type User struct {
 	ID       int64  `json:"id"`
 	Email    string `json:"email"`
 	PassHash []byte `json:"passHash"`
 	Name     string `json:"name"`
 	Image    string `json:"image"`
+	Verified bool   `json:"verified"`
+	// MFAEnabled is set when user has confirmed TOTP enrollment.
+	MFAEnabled bool `json:"mfa_enabled"`
+	// Roles are loaded only when issuing tokens.
+	Roles []string `json:"roles,omitempty"`
+	// TokensRevokedAt invalidates all access tokens issued before it.
+	TokensRevokedAt time.Time `json:"-"`
 }
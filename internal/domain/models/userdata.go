@@ -3,4 +3,5 @@ package models
 type UserData struct {
 	Name  string `json:"name"`
 	Image string `json:"image"`
+	Bio   string `json:"bio"`
 }
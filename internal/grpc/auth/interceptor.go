@@ -0,0 +1,49 @@
+package authgrpc
+
+import (
+	"context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"path"
+	"sso/internal/domain/models"
+)
+
+// methodPermissions lists Auth methods that require a permission, other methods are open.
+var methodPermissions = map[string]string{
+	"AssignRole": models.PermissionRolesManage,
+	"RevokeRole": models.PermissionRolesManage,
+	"UserRoles":  models.PermissionRolesManage,
+}
+
+// PermissionInterceptor rejects calls to methods listed in methodPermissions
+// unless the bearer token from metadata has a role granting the permission.
+func PermissionInterceptor(auth Auth) grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context,
+		req any,
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (any, error) {
+		permission, ok := methodPermissions[path.Base(info.FullMethod)]
+		if !ok {
+			return handler(ctx, req)
+		}
+
+		claims, err := authenticate(ctx, auth)
+		if err != nil {
+			return nil, err
+		}
+
+		allowed, err := auth.HasPermission(ctx, claims.Roles, permission)
+		if err != nil {
+			return nil, status.Error(codes.Internal, "failed to check permission")
+		}
+
+		if !allowed {
+			return nil, status.Errorf(codes.PermissionDenied, "%s permission required", permission)
+		}
+
+		return handler(ctx, req)
+	}
+}
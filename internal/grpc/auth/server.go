@@ -4,11 +4,19 @@ import (
 	"context"
 	"errors"
 	ssov1 "github.com/DenisPopkov/IT-Navigator-Proto/gen/go/sso"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"sso/internal/domain/models"
+	"sso/internal/lib/clientip"
+	"sso/internal/lib/jwt"
 	"sso/internal/services/auth"
+	"sso/internal/services/throttle"
 	"sso/internal/storage"
+	"strings"
 )
 
 type Auth interface {
@@ -16,21 +24,125 @@ type Auth interface {
 		ctx context.Context,
 		email string,
 		password string,
-	) (token string, err error)
+		appID int,
+		client models.Client,
+	) (tokens models.TokenPair, err error)
+	Refresh(
+		ctx context.Context,
+		refreshToken string,
+	) (tokens models.TokenPair, err error)
+	Logout(
+		ctx context.Context,
+		token string,
+		refreshToken string,
+	) error
 	RegisterNewUser(
 		ctx context.Context,
 		email string,
 		password string,
 	) (userID int64, err error)
+	ConfirmEmail(
+		ctx context.Context,
+		token string,
+	) error
+	ResendVerification(
+		ctx context.Context,
+		email string,
+	) error
+	RequestPasswordReset(
+		ctx context.Context,
+		email string,
+	) error
+	ResetPassword(
+		ctx context.Context,
+		code string,
+		newPassword string,
+	) error
+	ValidateToken(
+		ctx context.Context,
+		token string,
+	) (jwt.Claims, error)
+	EnrollTOTP(
+		ctx context.Context,
+		userID int64,
+	) (secret string, uri string, err error)
+	ConfirmTOTP(
+		ctx context.Context,
+		userID int64,
+		code string,
+		client models.Client,
+	) (recoveryCodes []string, err error)
+	DisableTOTP(
+		ctx context.Context,
+		userID int64,
+		code string,
+		client models.Client,
+	) error
+	VerifyMFA(
+		ctx context.Context,
+		mfaToken string,
+		code string,
+		client models.Client,
+	) (tokens models.TokenPair, err error)
+	Sessions(
+		ctx context.Context,
+		userID int64,
+		currentSessionID int64,
+	) ([]models.Session, error)
+	RevokeSession(
+		ctx context.Context,
+		userID int64,
+		sessionID int64,
+	) error
+	ChangePassword(
+		ctx context.Context,
+		userID int64,
+		sessionID int64,
+		currentPassword string,
+		newPassword string,
+		client models.Client,
+	) error
+	ChangeEmail(
+		ctx context.Context,
+		userID int64,
+		newEmail string,
+	) error
+	ConfirmEmailChange(
+		ctx context.Context,
+		token string,
+	) error
+	HasPermission(
+		ctx context.Context,
+		roles []string,
+		permission string,
+	) (bool, error)
+	UserRoles(
+		ctx context.Context,
+		userID int64,
+	) (roles []string, err error)
+	AssignRole(
+		ctx context.Context,
+		userID int64,
+		role string,
+	) error
+	RevokeRole(
+		ctx context.Context,
+		userID int64,
+		role string,
+	) error
 }
 
+const emptyValue = 0
+
 type serverAPI struct {
 	ssov1.UnimplementedAuthServer
 	auth Auth
+	// trustProxy makes client IP to be taken from x-forwarded-for metadata.
+	trustProxy bool
 }
 
-func Register(gRPCServer *grpc.Server, auth Auth) {
-	ssov1.RegisterAuthServer(gRPCServer, &serverAPI{auth: auth})
+func Register(gRPCServer *grpc.Server, auth Auth, trustProxy bool) {
+	ssov1.RegisterAuthServer(gRPCServer, &serverAPI{auth: auth, trustProxy: trustProxy})
 }
 
 func (s *serverAPI) Login(
@@ -45,16 +157,80 @@ func (s *serverAPI) Login(
 		return nil, status.Error(codes.InvalidArgument, "password is required")
 	}
 
-	token, err := s.auth.Login(ctx, in.GetEmail(), in.GetPassword())
+	if in.GetAppId() == emptyValue {
+		return nil, status.Error(codes.InvalidArgument, "app_id is required")
+	}
+
+	tokens, err := s.auth.Login(ctx, in.GetEmail(), in.GetPassword(), int(in.GetAppId()), s.client(ctx))
 	if err != nil {
+		var blocked *throttle.BlockedError
+		if errors.As(err, &blocked) {
+			return nil, blockedError(blocked)
+		}
+
 		if errors.Is(err, auth.ErrInvalidCredentials) {
 			return nil, status.Error(codes.InvalidArgument, "invalid email or password")
 		}
 
+		if errors.Is(err, auth.ErrInvalidAppID) {
+			return nil, status.Error(codes.InvalidArgument, "invalid app_id")
+		}
+
+		if errors.Is(err, auth.ErrEmailNotVerified) {
+			return nil, status.Error(codes.FailedPrecondition, "email is not verified")
+		}
+
 		return nil, status.Error(codes.Internal, "failed to login")
 	}
 
-	return &ssov1.LoginResponse{Token: token}, nil
+	return &ssov1.LoginResponse{
+		Token:        tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+		MfaToken:     tokens.MFAToken,
+	}, nil
+}
+
+func (s *serverAPI) Refresh(
+	ctx context.Context,
+	in *ssov1.RefreshRequest,
+) (*ssov1.RefreshResponse, error) {
+	if in.RefreshToken == "" {
+		return nil, status.Error(codes.InvalidArgument, "refresh_token is required")
+	}
+
+	tokens, err := s.auth.Refresh(ctx, in.GetRefreshToken())
+	if err != nil {
+		if errors.Is(err, auth.ErrInvalidRefreshToken) || errors.Is(err, auth.ErrRefreshTokenReused) {
+			return nil, status.Error(codes.Unauthenticated, "invalid refresh token")
+		}
+
+		return nil, status.Error(codes.Internal, "failed to refresh token")
+	}
+
+	return &ssov1.RefreshResponse{
+		Token:        tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+	}, nil
+}
+
+func (s *serverAPI) Logout(
+	ctx context.Context,
+	in *ssov1.LogoutRequest,
+) (*ssov1.LogoutResponse, error) {
+	if in.Token == "" {
+		return nil, status.Error(codes.InvalidArgument, "token is required")
+	}
+
+	err := s.auth.Logout(ctx, in.GetToken(), in.GetRefreshToken())
+	if err != nil {
+		if errors.Is(err, auth.ErrInvalidToken) {
+			return nil, status.Error(codes.Unauthenticated, "invalid token")
+		}
+
+		return nil, status.Error(codes.Internal, "failed to logout")
+	}
+
+	return &ssov1.LogoutResponse{}, nil
 }
 
 func (s *serverAPI) Register(
@@ -75,8 +251,477 @@ func (s *serverAPI) Register(
 			return nil, status.Error(codes.AlreadyExists, "user already exists")
 		}
 
+		if errors.Is(err, auth.ErrInvalidEmail) {
+			return nil, status.Error(codes.InvalidArgument, "invalid email")
+		}
+
 		return nil, status.Error(codes.Internal, "failed to register user")
 	}
 
 	return &ssov1.RegisterResponse{UserId: uid}, nil
 }
+
+func (s *serverAPI) ConfirmEmail(
+	ctx context.Context,
+	in *ssov1.ConfirmEmailRequest,
+) (*ssov1.ConfirmEmailResponse, error) {
+	if in.Token == "" {
+		return nil, status.Error(codes.InvalidArgument, "token is required")
+	}
+
+	err := s.auth.ConfirmEmail(ctx, in.GetToken())
+	if err != nil {
+		if errors.Is(err, auth.ErrInvalidEmailToken) {
+			return nil, status.Error(codes.InvalidArgument, "invalid or expired token")
+		}
+
+		return nil, status.Error(codes.Internal, "failed to confirm email")
+	}
+
+	return &ssov1.ConfirmEmailResponse{}, nil
+}
+
+func (s *serverAPI) ResendVerification(
+	ctx context.Context,
+	in *ssov1.ResendVerificationRequest,
+) (*ssov1.ResendVerificationResponse, error) {
+	if in.Email == "" {
+		return nil, status.Error(codes.InvalidArgument, "email is required")
+	}
+
+	if err := s.auth.ResendVerification(ctx, in.GetEmail()); err != nil {
+		return nil, status.Error(codes.Internal, "failed to send verification email")
+	}
+
+	return &ssov1.ResendVerificationResponse{}, nil
+}
+
+func (s *serverAPI) RequestPasswordReset(
+	ctx context.Context,
+	in *ssov1.RequestPasswordResetRequest,
+) (*ssov1.RequestPasswordResetResponse, error) {
+	if in.Email == "" {
+		return nil, status.Error(codes.InvalidArgument, "email is required")
+	}
+
+	if err := s.auth.RequestPasswordReset(ctx, in.GetEmail()); err != nil {
+		return nil, status.Error(codes.Internal, "failed to request password reset")
+	}
+
+	return &ssov1.RequestPasswordResetResponse{}, nil
+}
+
+func (s *serverAPI) ResetPassword(
+	ctx context.Context,
+	in *ssov1.ResetPasswordRequest,
+) (*ssov1.ResetPasswordResponse, error) {
+	if in.Code == "" {
+		return nil, status.Error(codes.InvalidArgument, "code is required")
+	}
+
+	if in.NewPassword == "" {
+		return nil, status.Error(codes.InvalidArgument, "new_password is required")
+	}
+
+	err := s.auth.ResetPassword(ctx, in.GetCode(), in.GetNewPassword())
+	if err != nil {
+		if errors.Is(err, auth.ErrInvalidEmailToken) {
+			return nil, status.Error(codes.InvalidArgument, "invalid or expired code")
+		}
+
+		return nil, status.Error(codes.Internal, "failed to reset password")
+	}
+
+	return &ssov1.ResetPasswordResponse{}, nil
+}
+
+func (s *serverAPI) ChangePassword(
+	ctx context.Context,
+	in *ssov1.ChangePasswordRequest,
+) (*ssov1.ChangePasswordResponse, error) {
+	if in.CurrentPassword == "" {
+		return nil, status.Error(codes.InvalidArgument, "current_password is required")
+	}
+
+	if in.NewPassword == "" {
+		return nil, status.Error(codes.InvalidArgument, "new_password is required")
+	}
+
+	claims, err := authenticate(ctx, s.auth)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.auth.ChangePassword(ctx, claims.UID, claims.SessionID, in.GetCurrentPassword(), in.GetNewPassword(), s.client(ctx))
+	if err != nil {
+		var blocked *throttle.BlockedError
+		if errors.As(err, &blocked) {
+			return nil, blockedError(blocked)
+		}
+
+		if errors.Is(err, auth.ErrInvalidCredentials) {
+			return nil, status.Error(codes.InvalidArgument, "invalid current password")
+		}
+
+		return nil, status.Error(codes.Internal, "failed to change password")
+	}
+
+	return &ssov1.ChangePasswordResponse{}, nil
+}
+
+func (s *serverAPI) ChangeEmail(
+	ctx context.Context,
+	in *ssov1.ChangeEmailRequest,
+) (*ssov1.ChangeEmailResponse, error) {
+	if in.Email == "" {
+		return nil, status.Error(codes.InvalidArgument, "email is required")
+	}
+
+	uid, err := s.userID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.auth.ChangeEmail(ctx, uid, in.GetEmail()); err != nil {
+		return nil, changeEmailError(err)
+	}
+
+	return &ssov1.ChangeEmailResponse{}, nil
+}
+
+func (s *serverAPI) ConfirmEmailChange(
+	ctx context.Context,
+	in *ssov1.ConfirmEmailChangeRequest,
+) (*ssov1.ConfirmEmailChangeResponse, error) {
+	if in.Token == "" {
+		return nil, status.Error(codes.InvalidArgument, "token is required")
+	}
+
+	if err := s.auth.ConfirmEmailChange(ctx, in.GetToken()); err != nil {
+		return nil, changeEmailError(err)
+	}
+
+	return &ssov1.ConfirmEmailChangeResponse{}, nil
+}
+
+func changeEmailError(err error) error {
+	switch {
+	case errors.Is(err, storage.ErrUserExists):
+		return status.Error(codes.AlreadyExists, "email already taken")
+	case errors.Is(err, auth.ErrInvalidEmail):
+		return status.Error(codes.InvalidArgument, "invalid email")
+	case errors.Is(err, auth.ErrInvalidEmailToken):
+		return status.Error(codes.InvalidArgument, "invalid or expired token")
+	default:
+		return status.Error(codes.Internal, "failed to change email")
+	}
+}
+
+func (s *serverAPI) EnrollTOTP(
+	ctx context.Context,
+	in *ssov1.EnrollTOTPRequest,
+) (*ssov1.EnrollTOTPResponse, error) {
+	uid, err := s.userID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, uri, err := s.auth.EnrollTOTP(ctx, uid)
+	if err != nil {
+		if errors.Is(err, auth.ErrMFAAlreadyEnabled) {
+			return nil, status.Error(codes.FailedPrecondition, "two-factor authentication already enabled")
+		}
+
+		return nil, status.Error(codes.Internal, "failed to enroll totp")
+	}
+
+	return &ssov1.EnrollTOTPResponse{
+		Secret: secret,
+		Uri:    uri,
+	}, nil
+}
+
+func (s *serverAPI) ConfirmTOTP(
+	ctx context.Context,
+	in *ssov1.ConfirmTOTPRequest,
+) (*ssov1.ConfirmTOTPResponse, error) {
+	if in.Code == "" {
+		return nil, status.Error(codes.InvalidArgument, "code is required")
+	}
+
+	uid, err := s.userID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	recoveryCodes, err := s.auth.ConfirmTOTP(ctx, uid, in.GetCode(), s.client(ctx))
+	if err != nil {
+		var blocked *throttle.BlockedError
+		if errors.As(err, &blocked) {
+			return nil, blockedError(blocked)
+		}
+
+		return nil, mfaError(err, "failed to confirm totp")
+	}
+
+	return &ssov1.ConfirmTOTPResponse{RecoveryCodes: recoveryCodes}, nil
+}
+
+func (s *serverAPI) DisableTOTP(
+	ctx context.Context,
+	in *ssov1.DisableTOTPRequest,
+) (*ssov1.DisableTOTPResponse, error) {
+	if in.Code == "" {
+		return nil, status.Error(codes.InvalidArgument, "code is required")
+	}
+
+	uid, err := s.userID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.auth.DisableTOTP(ctx, uid, in.GetCode(), s.client(ctx)); err != nil {
+		var blocked *throttle.BlockedError
+		if errors.As(err, &blocked) {
+			return nil, blockedError(blocked)
+		}
+
+		return nil, mfaError(err, "failed to disable totp")
+	}
+
+	return &ssov1.DisableTOTPResponse{}, nil
+}
+
+func (s *serverAPI) VerifyMFA(
+	ctx context.Context,
+	in *ssov1.VerifyMFARequest,
+) (*ssov1.VerifyMFAResponse, error) {
+	if in.MfaToken == "" {
+		return nil, status.Error(codes.InvalidArgument, "mfa_token is required")
+	}
+
+	if in.Code == "" {
+		return nil, status.Error(codes.InvalidArgument, "code is required")
+	}
+
+	tokens, err := s.auth.VerifyMFA(ctx, in.GetMfaToken(), in.GetCode(), s.client(ctx))
+	if err != nil {
+		var blocked *throttle.BlockedError
+		if errors.As(err, &blocked) {
+			return nil, blockedError(blocked)
+		}
+
+		if errors.Is(err, auth.ErrInvalidMFAToken) {
+			return nil, status.Error(codes.Unauthenticated, "invalid mfa token")
+		}
+
+		return nil, mfaError(err, "failed to verify code")
+	}
+
+	return &ssov1.VerifyMFAResponse{
+		Token:        tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+	}, nil
+}
+
+// userID authenticates call by access token and returns id of the user it was issued to.
+func (s *serverAPI) userID(ctx context.Context) (int64, error) {
+	claims, err := authenticate(ctx, s.auth)
+	if err != nil {
+		return 0, err
+	}
+
+	return claims.UID, nil
+}
+
+// client describes the caller: IP address, user-agent and x-device-name metadata.
+func (s *serverAPI) client(ctx context.Context) models.Client {
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	return models.Client{
+		IP:         clientip.FromGRPC(ctx, s.trustProxy),
+		UserAgent:  firstValue(md.Get("user-agent")),
+		DeviceName: firstValue(md.Get("x-device-name")),
+	}
+}
+
+func firstValue(values []string) string {
+	if len(values) == 0 {
+		return ""
+	}
+
+	return values[0]
+}
+
+// authenticate validates access token passed as "authorization: Bearer <token>" metadata.
+func authenticate(ctx context.Context, a Auth) (jwt.Claims, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	values := md.Get("authorization")
+	if len(values) == 0 {
+		return jwt.Claims{}, status.Error(codes.Unauthenticated, "authorization token is required")
+	}
+
+	token := strings.TrimPrefix(values[0], "Bearer ")
+
+	claims, err := a.ValidateToken(ctx, token)
+	if err != nil {
+		if errors.Is(err, auth.ErrInvalidToken) {
+			return jwt.Claims{}, status.Error(codes.Unauthenticated, "invalid token")
+		}
+
+		return jwt.Claims{}, status.Error(codes.Internal, "failed to validate token")
+	}
+
+	return claims, nil
+}
+
+func (s *serverAPI) UserRoles(
+	ctx context.Context,
+	in *ssov1.UserRolesRequest,
+) (*ssov1.UserRolesResponse, error) {
+	if in.GetUserId() == emptyValue {
+		return nil, status.Error(codes.InvalidArgument, "user_id is required")
+	}
+
+	roles, err := s.auth.UserRoles(ctx, in.GetUserId())
+	if err != nil {
+		return nil, roleError(err, "failed to get roles")
+	}
+
+	return &ssov1.UserRolesResponse{Roles: roles}, nil
+}
+
+func (s *serverAPI) AssignRole(
+	ctx context.Context,
+	in *ssov1.AssignRoleRequest,
+) (*ssov1.AssignRoleResponse, error) {
+	if in.GetUserId() == emptyValue {
+		return nil, status.Error(codes.InvalidArgument, "user_id is required")
+	}
+
+	if in.Role == "" {
+		return nil, status.Error(codes.InvalidArgument, "role is required")
+	}
+
+	if err := s.auth.AssignRole(ctx, in.GetUserId(), in.GetRole()); err != nil {
+		return nil, roleError(err, "failed to assign role")
+	}
+
+	return &ssov1.AssignRoleResponse{}, nil
+}
+
+func (s *serverAPI) RevokeRole(
+	ctx context.Context,
+	in *ssov1.RevokeRoleRequest,
+) (*ssov1.RevokeRoleResponse, error) {
+	if in.GetUserId() == emptyValue {
+		return nil, status.Error(codes.InvalidArgument, "user_id is required")
+	}
+
+	if in.Role == "" {
+		return nil, status.Error(codes.InvalidArgument, "role is required")
+	}
+
+	if err := s.auth.RevokeRole(ctx, in.GetUserId(), in.GetRole()); err != nil {
+		return nil, roleError(err, "failed to revoke role")
+	}
+
+	return &ssov1.RevokeRoleResponse{}, nil
+}
+
+func (s *serverAPI) ListSessions(
+	ctx context.Context,
+	in *ssov1.ListSessionsRequest,
+) (*ssov1.ListSessionsResponse, error) {
+	claims, err := authenticate(ctx, s.auth)
+	if err != nil {
+		return nil, err
+	}
+
+	sessions, err := s.auth.Sessions(ctx, claims.UID, claims.SessionID)
+	if err != nil {
+		return nil, status.Error(codes.Internal, "failed to list sessions")
+	}
+
+	resp := &ssov1.ListSessionsResponse{Sessions: make([]*ssov1.Session, 0, len(sessions))}
+	for _, session := range sessions {
+		resp.Sessions = append(resp.Sessions, &ssov1.Session{
+			Id:         session.ID,
+			AppId:      int64(session.AppID),
+			DeviceName: session.DeviceName,
+			UserAgent:  session.UserAgent,
+			Ip:         session.IP,
+			CreatedAt:  session.CreatedAt.Unix(),
+			LastSeenAt: session.LastSeenAt.Unix(),
+			Current:    session.Current,
+		})
+	}
+
+	return resp, nil
+}
+
+func (s *serverAPI) RevokeSession(
+	ctx context.Context,
+	in *ssov1.RevokeSessionRequest,
+) (*ssov1.RevokeSessionResponse, error) {
+	if in.GetSessionId() == emptyValue {
+		return nil, status.Error(codes.InvalidArgument, "session_id is required")
+	}
+
+	uid, err := s.userID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.auth.RevokeSession(ctx, uid, in.GetSessionId()); err != nil {
+		if errors.Is(err, storage.ErrSessionNotFound) {
+			return nil, status.Error(codes.NotFound, "session not found")
+		}
+
+		return nil, status.Error(codes.Internal, "failed to revoke session")
+	}
+
+	return &ssov1.RevokeSessionResponse{}, nil
+}
+
+func roleError(err error, internalMsg string) error {
+	switch {
+	case errors.Is(err, storage.ErrUserNotFound):
+		return status.Error(codes.NotFound, "user not found")
+	case errors.Is(err, storage.ErrRoleNotFound):
+		return status.Error(codes.InvalidArgument, "unknown role")
+	default:
+		return status.Error(codes.Internal, internalMsg)
+	}
+}
+
+// blockedError reports refused login with RetryInfo detail, so clients know when to retry.
+func blockedError(blocked *throttle.BlockedError) error {
+	code, msg := codes.ResourceExhausted, "too many login attempts"
+	if errors.Is(blocked, throttle.ErrAccountLocked) {
+		code, msg = codes.PermissionDenied, "account is temporarily locked"
+	}
+
+	st, err := status.New(code, msg).WithDetails(&errdetails.RetryInfo{
+		RetryDelay: durationpb.New(blocked.RetryAfter),
+	})
+	if err != nil {
+		return status.Error(code, msg)
+	}
+
+	return st.Err()
+}
+
+func mfaError(err error, internalMsg string) error {
+	switch {
+	case errors.Is(err, auth.ErrInvalidMFACode):
+		return status.Error(codes.InvalidArgument, "invalid code")
+	case errors.Is(err, auth.ErrMFANotEnabled):
+		return status.Error(codes.FailedPrecondition, "two-factor authentication not enabled")
+	case errors.Is(err, auth.ErrMFAAlreadyEnabled):
+		return status.Error(codes.FailedPrecondition, "two-factor authentication already enabled")
+	default:
+		return status.Error(codes.Internal, internalMsg)
+	}
+}
@@ -0,0 +1,78 @@
+package fsblob
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStore_PutDelete(t *testing.T) {
+	dir := t.TempDir()
+	s := New(dir, "http://localhost/media/")
+	ctx := context.Background()
+
+	require.NoError(t, s.Put(ctx, "avatars/1/v1/64.jpg", []byte("first"), "image/jpeg"))
+	require.NoError(t, s.Put(ctx, "avatars/1/v1/64.jpg", []byte("second"), "image/jpeg"))
+
+	data, err := os.ReadFile(filepath.Join(dir, "avatars", "1", "v1", "64.jpg"))
+	require.NoError(t, err)
+	assert.Equal(t, "second", string(data))
+
+	// Temporary files are renamed or removed.
+	entries, err := os.ReadDir(filepath.Join(dir, "avatars", "1", "v1"))
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+
+	assert.Equal(t, "http://localhost/media/avatars/1/v1/64.jpg", s.URL("avatars/1/v1/64.jpg"))
+
+	require.NoError(t, s.Delete(ctx, "avatars/1/v1/64.jpg"))
+	_, err = os.Stat(filepath.Join(dir, "avatars", "1", "v1", "64.jpg"))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	// Deleting missing blob is not an error.
+	require.NoError(t, s.Delete(ctx, "avatars/1/v1/64.jpg"))
+}
+
+func TestStore_InvalidKey(t *testing.T) {
+	s := New(t.TempDir(), "")
+	ctx := context.Background()
+
+	for _, key := range []string{"", "/etc/passwd", "../secret", "a/../../secret", "a//b", "a/./b", "a/"} {
+		assert.ErrorIs(t, s.Put(ctx, key, []byte("x"), "text/plain"), ErrInvalidKey, key)
+		assert.ErrorIs(t, s.Delete(ctx, key), ErrInvalidKey, key)
+	}
+}
+
+func TestStore_ServeHTTP(t *testing.T) {
+	s := New(t.TempDir(), "")
+	require.NoError(t, s.Put(context.Background(), "avatars/1/v1/64.jpg", []byte("image"), "image/jpeg"))
+
+	tests := []struct {
+		path     string
+		wantCode int
+		wantBody string
+	}{
+		{path: "/avatars/1/v1/64.jpg", wantCode: http.StatusOK, wantBody: "image"},
+		{path: "/avatars/1/v1/", wantCode: http.StatusNotFound},
+		{path: "/avatars/1/v1/256.jpg", wantCode: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			s.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			assert.Equal(t, tt.wantCode, rr.Code)
+			if tt.wantBody != "" {
+				assert.Equal(t, tt.wantBody, rr.Body.String())
+				assert.Equal(t, "nosniff", rr.Header().Get("X-Content-Type-Options"))
+			}
+		})
+	}
+}
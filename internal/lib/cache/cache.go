@@ -0,0 +1,104 @@
+package cache
+
+import (
+	"sync"
+	"time"
+)
+
+const sweepInterval = time.Minute
+
+// Cache is a concurrency safe in-memory map with per-entry expiration.
+type Cache[K comparable, V any] struct {
+	mu        sync.Mutex
+	items     map[K]item[V]
+	lastSweep time.Time
+}
+
+type item[V any] struct {
+	value     V
+	expiresAt time.Time
+}
+
+func New[K comparable, V any]() *Cache[K, V] {
+	return &Cache[K, V]{
+		items:     make(map[K]item[V]),
+		lastSweep: time.Now(),
+	}
+}
+
+// Get returns value by key if it's present and not expired.
+func (c *Cache[K, V]) Get(key K) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	it, ok := c.items[key]
+	if !ok {
+		var zero V
+		return zero, false
+	}
+
+	if time.Now().After(it.expiresAt) {
+		delete(c.items, key)
+
+		var zero V
+		return zero, false
+	}
+
+	return it.value, true
+}
+
+// Set stores value for the given ttl.
+// Expired entries are swept at most once per sweepInterval.
+func (c *Cache[K, V]) Set(key K, value V, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+
+	c.items[key] = item[V]{
+		value:     value,
+		expiresAt: now.Add(ttl),
+	}
+
+	if now.Sub(c.lastSweep) < sweepInterval {
+		return
+	}
+
+	for k, it := range c.items {
+		if now.After(it.expiresAt) {
+			delete(c.items, k)
+		}
+	}
+	c.lastSweep = now
+}
+
+// Update atomically replaces value by key with the result of fn and returns it, the entry gets the given ttl.
+// fn receives zero value and false if key is missing or expired.
+func (c *Cache[K, V]) Update(key K, ttl time.Duration, fn func(value V, ok bool) V) V {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+
+	it, ok := c.items[key]
+	if ok && now.After(it.expiresAt) {
+		var zero V
+		it, ok = item[V]{value: zero}, false
+	}
+
+	value := fn(it.value, ok)
+	c.items[key] = item[V]{
+		value:     value,
+		expiresAt: now.Add(ttl),
+	}
+
+	return value
+}
+
+// Delete removes value by key.
+func (c *Cache[K, V]) Delete(key K) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.items, key)
+}
@@ -0,0 +1,63 @@
+package clientip
+
+import (
+	"context"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"net"
+	"net/http"
+	"strings"
+)
+
+const forwardedForHeader = "X-Forwarded-For"
+
+// FromGRPC returns IP of the gRPC client.
+// If trustProxy is set, address added by the reverse proxy to x-forwarded-for metadata is preferred.
+func FromGRPC(ctx context.Context, trustProxy bool) string {
+	if trustProxy {
+		md, _ := metadata.FromIncomingContext(ctx)
+		if ip := lastForwarded(md.Get(forwardedForHeader)); ip != "" {
+			return ip
+		}
+	}
+
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+
+	return host(p.Addr.String())
+}
+
+// FromHTTP returns IP of the HTTP client.
+// If trustProxy is set, address added by the reverse proxy to X-Forwarded-For header is preferred.
+func FromHTTP(r *http.Request, trustProxy bool) string {
+	if trustProxy {
+		if ip := lastForwarded(r.Header.Values(forwardedForHeader)); ip != "" {
+			return ip
+		}
+	}
+
+	return host(r.RemoteAddr)
+}
+
+// lastForwarded returns the rightmost address, the one appended by our proxy.
+// Addresses to the left of it are set by the client and can be spoofed.
+func lastForwarded(values []string) string {
+	if len(values) == 0 {
+		return ""
+	}
+
+	parts := strings.Split(values[len(values)-1], ",")
+
+	return strings.TrimSpace(parts[len(parts)-1])
+}
+
+func host(addr string) string {
+	h, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr
+	}
+
+	return h
+}
@@ -0,0 +1,156 @@
+package diff
+
+import (
+	"slices"
+	"strings"
+)
+
+type Op string
+
+const (
+	Equal  Op = "equal"
+	Insert Op = "insert"
+	Delete Op = "delete"
+)
+
+// maxEdits limits the work spent on a diff. Texts that differ in more lines
+// are diffed as removal of all differing lines followed by insertion of the new ones.
+const maxEdits = 2000
+
+type Line struct {
+	Op   Op
+	Text string
+}
+
+// Lines returns the shortest line-level diff turning text a into text b.
+func Lines(a, b string) []Line {
+	x, y := split(a), split(b)
+
+	// Common beginning and end are cut off, edits are usually somewhere in the middle.
+	prefix := 0
+	for prefix < len(x) && prefix < len(y) && x[prefix] == y[prefix] {
+		prefix++
+	}
+
+	suffix := 0
+	for suffix < len(x)-prefix && suffix < len(y)-prefix && x[len(x)-1-suffix] == y[len(y)-1-suffix] {
+		suffix++
+	}
+
+	lines := make([]Line, 0, len(x)+len(y))
+	for _, text := range x[:prefix] {
+		lines = append(lines, Line{Op: Equal, Text: text})
+	}
+
+	lines = append(lines, edits(x[prefix:len(x)-suffix], y[prefix:len(y)-suffix])...)
+
+	for _, text := range x[len(x)-suffix:] {
+		lines = append(lines, Line{Op: Equal, Text: text})
+	}
+
+	return lines
+}
+
+func split(text string) []string {
+	if text == "" {
+		return nil
+	}
+
+	return strings.Split(text, "\n")
+}
+
+// edits finds the shortest edit script of a and b with Myers' algorithm.
+func edits(a, b []string) []Line {
+	n, m := len(a), len(b)
+	if n == 0 && m == 0 {
+		return nil
+	}
+
+	// v[k+offset] is the furthest x reached on diagonal k = x - y.
+	offset := n + m + 1
+	v := make([]int, 2*offset+1)
+
+	// trace[d] keeps diagonals -d..d of v as they were before step d, to walk the path back.
+	var trace [][]int
+	for d := 0; d <= n+m && d <= maxEdits; d++ {
+		trace = append(trace, slices.Clone(v[offset-d:offset+d+1]))
+
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || k != d && v[offset+k-1] < v[offset+k+1] {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+
+			if x >= n && y >= m {
+				return backtrack(a, b, trace)
+			}
+		}
+	}
+
+	lines := make([]Line, 0, n+m)
+	for _, text := range a {
+		lines = append(lines, Line{Op: Delete, Text: text})
+	}
+	for _, text := range b {
+		lines = append(lines, Line{Op: Insert, Text: text})
+	}
+
+	return lines
+}
+
+// backtrack walks the path found by edits from the end to the start.
+func backtrack(a, b []string, trace [][]int) []Line {
+	var lines []Line
+
+	x, y := len(a), len(b)
+	for d := len(trace) - 1; d >= 0; d-- {
+		// v[k] of step d is at trace[d][k+d].
+		v := func(k int) int {
+			if k < -d || k > d {
+				return 0
+			}
+			return trace[d][k+d]
+		}
+
+		k := x - y
+
+		var prevK int
+		if k == -d || k != d && v(k-1) < v(k+1) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+
+		prevX := v(prevK)
+		prevY := prevX - prevK
+
+		for x > prevX && y > prevY {
+			x--
+			y--
+			lines = append(lines, Line{Op: Equal, Text: a[x]})
+		}
+
+		if d > 0 {
+			if x == prevX {
+				y--
+				lines = append(lines, Line{Op: Insert, Text: b[y]})
+			} else {
+				x--
+				lines = append(lines, Line{Op: Delete, Text: a[x]})
+			}
+		}
+	}
+
+	slices.Reverse(lines)
+
+	return lines
+}
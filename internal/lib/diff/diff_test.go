@@ -0,0 +1,193 @@
+package diff
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// replay rebuilds both texts from the diff and counts changed lines.
+func replay(lines []Line) (a string, b string, changed int) {
+	var x, y []string
+
+	for _, l := range lines {
+		switch l.Op {
+		case Equal:
+			x = append(x, l.Text)
+			y = append(y, l.Text)
+		case Delete:
+			x = append(x, l.Text)
+			changed++
+		case Insert:
+			y = append(y, l.Text)
+			changed++
+		}
+	}
+
+	return strings.Join(x, "\n"), strings.Join(y, "\n"), changed
+}
+
+func numbered(prefix string, n int) []string {
+	lines := make([]string, n)
+	for i := range lines {
+		lines[i] = fmt.Sprintf("%s%d", prefix, i)
+	}
+
+	return lines
+}
+
+func TestLines(t *testing.T) {
+	tests := []struct {
+		name string
+		a    string
+		b    string
+		want []Line
+	}{
+		{
+			name: "empty texts",
+			a:    "",
+			b:    "",
+			want: []Line{},
+		},
+		{
+			name: "equal texts",
+			a:    "one\ntwo",
+			b:    "one\ntwo",
+			want: []Line{{Op: Equal, Text: "one"}, {Op: Equal, Text: "two"}},
+		},
+		{
+			name: "from empty",
+			a:    "",
+			b:    "one\ntwo",
+			want: []Line{{Op: Insert, Text: "one"}, {Op: Insert, Text: "two"}},
+		},
+		{
+			name: "to empty",
+			a:    "one\ntwo",
+			b:    "",
+			want: []Line{{Op: Delete, Text: "one"}, {Op: Delete, Text: "two"}},
+		},
+		{
+			name: "insert only",
+			a:    "one\nthree",
+			b:    "one\ntwo\nthree\nfour",
+			want: []Line{
+				{Op: Equal, Text: "one"},
+				{Op: Insert, Text: "two"},
+				{Op: Equal, Text: "three"},
+				{Op: Insert, Text: "four"},
+			},
+		},
+		{
+			name: "delete only",
+			a:    "one\ntwo\nthree\nfour",
+			b:    "two\nfour",
+			want: []Line{
+				{Op: Delete, Text: "one"},
+				{Op: Equal, Text: "two"},
+				{Op: Delete, Text: "three"},
+				{Op: Equal, Text: "four"},
+			},
+		},
+		{
+			name: "change in the middle",
+			a:    "# Title\nold line\n\nEnd",
+			b:    "# Title\nnew line\nmore\n\nEnd",
+			want: []Line{
+				{Op: Equal, Text: "# Title"},
+				{Op: Delete, Text: "old line"},
+				{Op: Insert, Text: "new line"},
+				{Op: Insert, Text: "more"},
+				{Op: Equal, Text: ""},
+				{Op: Equal, Text: "End"},
+			},
+		},
+		{
+			name: "trailing newline added",
+			a:    "one",
+			b:    "one\n",
+			want: []Line{{Op: Equal, Text: "one"}, {Op: Insert, Text: ""}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lines := Lines(tt.a, tt.b)
+			assert.Equal(t, tt.want, lines)
+
+			a, b, _ := replay(lines)
+			assert.Equal(t, tt.a, a)
+			assert.Equal(t, tt.b, b)
+		})
+	}
+}
+
+func TestLines_MaxEditsFallback(t *testing.T) {
+	// Shortest diff keeps the common line, but it takes more than maxEdits edits to find.
+	x := append(append(numbered("a", maxEdits), "common"), numbered("a", maxEdits)...)
+	y := append(append(numbered("b", maxEdits), "common"), numbered("b", maxEdits)...)
+	a, b := strings.Join(x, "\n"), strings.Join(y, "\n")
+
+	lines := Lines(a, b)
+	require.Len(t, lines, len(x)+len(y))
+
+	for i, l := range lines {
+		if i < len(x) {
+			assert.Equal(t, Delete, l.Op, i)
+		} else {
+			assert.Equal(t, Insert, l.Op, i)
+		}
+	}
+
+	gotA, gotB, _ := replay(lines)
+	assert.Equal(t, a, gotA)
+	assert.Equal(t, b, gotB)
+}
+
+func TestLines_Random(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+
+	text := func() string {
+		lines := make([]string, r.Intn(12))
+		for i := range lines {
+			lines[i] = string(rune('a' + r.Intn(4)))
+		}
+
+		return strings.Join(lines, "\n")
+	}
+
+	for range 2000 {
+		a, b := text(), text()
+
+		gotA, gotB, changed := replay(Lines(a, b))
+		require.Equal(t, a, gotA)
+		require.Equal(t, b, gotB)
+
+		x, y := split(a), split(b)
+		require.Equal(t, len(x)+len(y)-2*lcs(x, y), changed, "diff of %q and %q is not the shortest", a, b)
+	}
+}
+
+// lcs returns length of the longest common subsequence of a and b.
+func lcs(a, b []string) int {
+	dp := make([][]int, len(a)+1)
+	for i := range dp {
+		dp[i] = make([]int, len(b)+1)
+	}
+
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				dp[i][j] = dp[i+1][j+1] + 1
+			} else {
+				dp[i][j] = max(dp[i+1][j], dp[i][j+1])
+			}
+		}
+	}
+
+	return dp[0][0]
+}
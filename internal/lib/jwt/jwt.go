@@ -1,24 +1,224 @@
 package jwt
 
 import (
+	"errors"
+	"fmt"
 	"github.com/golang-jwt/jwt/v5"
 	"sso/internal/domain/models"
+	"sso/internal/lib/opaque"
+	"strconv"
 	"time"
 )
 
-// NewToken creates new JWT token for given user and app.
-func NewToken(user models.User, app models.App, duration time.Duration) (string, error) {
-	token := jwt.New(jwt.SigningMethodHS256)
+var ErrInvalidToken = errors.New("invalid token")
+
+// Token types, stored in the typ claim.
+const (
+	// TypeAccess is empty, access tokens carry no typ claim.
+	TypeAccess = ""
+	// TypeMFA marks challenge tokens issued after password check
+	// and exchanged for access token once second factor is verified.
+	TypeMFA = "mfa"
+)
+
+// Claims holds verified claims of an access token.
+type Claims struct {
+	ID        string
+	UID       int64
+	AppID     int
+	Email     string
+	Roles     []string
+	Type      string
+	IssuedAt  time.Time
+	ExpiresAt time.Time
+	// SessionID is id of the login session access token belongs to, zero for other token types.
+	SessionID int64
+}
+
+// KeyResolver returns verification key by its id (kid header).
+type KeyResolver func(kid string) (Key, error)
+
+// NewToken creates new JWT token for given user and app signed with the given key.
+// The token is bound to the login session, its id is stored in the sid claim.
+func NewToken(user models.User, app models.App, key Key, duration time.Duration, sessionID int64) (string, error) {
+	return newToken(user, app, key, duration, TypeAccess, sessionID)
+}
+
+// NewMFAToken creates MFA challenge token, it is only accepted by second factor verification.
+func NewMFAToken(user models.User, app models.App, key Key, duration time.Duration) (string, error) {
+	return newToken(user, app, key, duration, TypeMFA, 0)
+}
+
+func newToken(
+	user models.User,
+	app models.App,
+	key Key,
+	duration time.Duration,
+	typ string,
+	sessionID int64,
+) (string, error) {
+	method, err := key.signingMethod()
+	if err != nil {
+		return "", err
+	}
+
+	jti, err := opaque.New()
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+
+	token := jwt.New(method)
+	token.Header["kid"] = key.ID
 
 	claims := token.Claims.(jwt.MapClaims)
+	claims["jti"] = jti
 	claims["uid"] = user.ID
 	claims["email"] = user.Email
-	claims["exp"] = time.Now().Add(duration).Unix()
+	claims["app_id"] = app.ID
+	claims["iat"] = now.Unix()
+	claims["exp"] = now.Add(duration).Unix()
+	if typ != TypeAccess {
+		claims["typ"] = typ
+	} else {
+		claims["roles"] = roles(user)
+		claims["sid"] = sessionID
+	}
 
-	tokenString, err := token.SignedString([]byte(app.Secret))
+	tokenString, err := token.SignedString(key.Private)
 	if err != nil {
 		return "", err
 	}
 
 	return tokenString, nil
 }
+
+// ParseToken verifies token signature and expiration and returns its claims.
+// Verification key is looked up by the kid header.
+func ParseToken(tokenString string, resolveKey KeyResolver) (Claims, error) {
+	token, err := jwt.Parse(
+		tokenString,
+		func(token *jwt.Token) (interface{}, error) {
+			kid, ok := token.Header["kid"].(string)
+			if !ok {
+				return nil, errors.New("kid header not found")
+			}
+
+			key, err := resolveKey(kid)
+			if err != nil {
+				return nil, err
+			}
+
+			if token.Method.Alg() != key.Algorithm {
+				return nil, fmt.Errorf("unexpected signing method %s", token.Method.Alg())
+			}
+
+			return key.Public, nil
+		},
+		jwt.WithValidMethods([]string{AlgEdDSA, AlgRS256}),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		return Claims{}, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+
+	mapClaims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return Claims{}, fmt.Errorf("%w: unexpected claims type", ErrInvalidToken)
+	}
+
+	jti, ok := mapClaims["jti"].(string)
+	if !ok || jti == "" {
+		return Claims{}, fmt.Errorf("%w: jti not found", ErrInvalidToken)
+	}
+
+	uid, ok := mapClaims["uid"].(float64)
+	if !ok {
+		return Claims{}, fmt.Errorf("%w: uid not found", ErrInvalidToken)
+	}
+
+	appID, ok := mapClaims["app_id"].(float64)
+	if !ok {
+		return Claims{}, fmt.Errorf("%w: app_id not found", ErrInvalidToken)
+	}
+
+	email, _ := mapClaims["email"].(string)
+	typ, _ := mapClaims["typ"].(string)
+	sid, _ := mapClaims["sid"].(float64)
+
+	var roles []string
+	if rawRoles, ok := mapClaims["roles"].([]any); ok {
+		for _, r := range rawRoles {
+			if role, ok := r.(string); ok {
+				roles = append(roles, role)
+			}
+		}
+	}
+
+	exp, err := mapClaims.GetExpirationTime()
+	if err != nil {
+		return Claims{}, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+
+	claims := Claims{
+		ID:        jti,
+		UID:       int64(uid),
+		AppID:     int(appID),
+		Email:     email,
+		Roles:     roles,
+		SessionID: int64(sid),
+		Type:      typ,
+		ExpiresAt: exp.Time,
+	}
+
+	if iat, err := mapClaims.GetIssuedAt(); err == nil && iat != nil {
+		claims.IssuedAt = iat.Time
+	}
+
+	return claims, nil
+}
+
+// NewIDToken creates OpenID Connect ID token for given user, audience is the app (client) id.
+func NewIDToken(
+	user models.User,
+	app models.App,
+	key Key,
+	issuer string,
+	nonce string,
+	duration time.Duration,
+) (string, error) {
+	method, err := key.signingMethod()
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+
+	token := jwt.New(method)
+	token.Header["kid"] = key.ID
+
+	claims := token.Claims.(jwt.MapClaims)
+	claims["iss"] = issuer
+	claims["sub"] = strconv.FormatInt(user.ID, 10)
+	claims["aud"] = strconv.Itoa(app.ID)
+	claims["email"] = user.Email
+	claims["name"] = user.Name
+	claims["iat"] = now.Unix()
+	claims["auth_time"] = now.Unix()
+	claims["exp"] = now.Add(duration).Unix()
+	if nonce != "" {
+		claims["nonce"] = nonce
+	}
+
+	return token.SignedString(key.Private)
+}
+
+// roles returns user roles as array claim, even if user has no roles.
+func roles(user models.User) []string {
+	if user.Roles == nil {
+		return []string{}
+	}
+
+	return user.Roles
+}
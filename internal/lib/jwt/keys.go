@@ -0,0 +1,165 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"github.com/golang-jwt/jwt/v5"
+	"math/big"
+	"sso/internal/domain/models"
+	"time"
+)
+
+const (
+	AlgEdDSA = "EdDSA"
+	AlgRS256 = "RS256"
+
+	rsaKeyBits = 2048
+)
+
+var ErrUnsupportedAlgorithm = errors.New("unsupported signing algorithm")
+
+// Key is a parsed signing key.
+type Key struct {
+	ID        string
+	Algorithm string
+	Private   crypto.Signer
+	Public    crypto.PublicKey
+	CreatedAt time.Time
+	RetiredAt time.Time
+}
+
+// JWK is a public key in JSON Web Key format (RFC 7517).
+type JWK struct {
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+}
+
+// JWKS is a JSON Web Key Set.
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// GenerateKey generates new key pair for the given algorithm.
+// Key id is derived from the public key thumbprint.
+func GenerateKey(algorithm string) (models.SigningKey, error) {
+	const op = "jwt.GenerateKey"
+
+	var (
+		private any
+		public  any
+	)
+
+	switch algorithm {
+	case AlgEdDSA:
+		pub, priv, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return models.SigningKey{}, fmt.Errorf("%s: %w", op, err)
+		}
+		private, public = priv, pub
+	case AlgRS256:
+		priv, err := rsa.GenerateKey(rand.Reader, rsaKeyBits)
+		if err != nil {
+			return models.SigningKey{}, fmt.Errorf("%s: %w", op, err)
+		}
+		private, public = priv, &priv.PublicKey
+	default:
+		return models.SigningKey{}, fmt.Errorf("%s: %w: %s", op, ErrUnsupportedAlgorithm, algorithm)
+	}
+
+	privateDER, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		return models.SigningKey{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	publicDER, err := x509.MarshalPKIXPublicKey(public)
+	if err != nil {
+		return models.SigningKey{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	thumbprint := sha256.Sum256(publicDER)
+
+	return models.SigningKey{
+		ID:         base64.RawURLEncoding.EncodeToString(thumbprint[:16]),
+		Algorithm:  algorithm,
+		PrivateKey: privateDER,
+		PublicKey:  publicDER,
+		CreatedAt:  time.Now(),
+	}, nil
+}
+
+// ParseKey decodes stored key pair.
+func ParseKey(key models.SigningKey) (Key, error) {
+	const op = "jwt.ParseKey"
+
+	private, err := x509.ParsePKCS8PrivateKey(key.PrivateKey)
+	if err != nil {
+		return Key{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	signer, ok := private.(crypto.Signer)
+	if !ok {
+		return Key{}, fmt.Errorf("%s: private key is not a signer", op)
+	}
+
+	public, err := x509.ParsePKIXPublicKey(key.PublicKey)
+	if err != nil {
+		return Key{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return Key{
+		ID:        key.ID,
+		Algorithm: key.Algorithm,
+		Private:   signer,
+		Public:    public,
+		CreatedAt: key.CreatedAt,
+		RetiredAt: key.RetiredAt,
+	}, nil
+}
+
+// JWK returns public part of the key in JWK format.
+func (k Key) JWK() (JWK, error) {
+	jwk := JWK{
+		Use: "sig",
+		Alg: k.Algorithm,
+		Kid: k.ID,
+	}
+
+	switch pub := k.Public.(type) {
+	case ed25519.PublicKey:
+		jwk.Kty = "OKP"
+		jwk.Crv = "Ed25519"
+		jwk.X = base64.RawURLEncoding.EncodeToString(pub)
+	case *rsa.PublicKey:
+		jwk.Kty = "RSA"
+		jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+		jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+	default:
+		return JWK{}, fmt.Errorf("%w: %T", ErrUnsupportedAlgorithm, k.Public)
+	}
+
+	return jwk, nil
+}
+
+func (k Key) signingMethod() (jwt.SigningMethod, error) {
+	switch k.Algorithm {
+	case AlgEdDSA:
+		return jwt.SigningMethodEdDSA, nil
+	case AlgRS256:
+		return jwt.SigningMethodRS256, nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedAlgorithm, k.Algorithm)
+	}
+}
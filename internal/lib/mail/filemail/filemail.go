@@ -0,0 +1,73 @@
+package filemail
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sso/internal/lib/mail"
+	"strings"
+	"time"
+)
+
+// Sender writes messages to a directory instead of delivering them,
+// it is meant for local development and tests.
+type Sender struct {
+	log  *slog.Logger
+	dir  string
+	from string
+}
+
+// New creates Sender writing .eml files into dir.
+// If dir is empty, messages are dropped. Body is never logged, it carries links and codes granting account access.
+func New(log *slog.Logger, dir string, from string) *Sender {
+	return &Sender{
+		log:  log,
+		dir:  dir,
+		from: from,
+	}
+}
+
+func (s *Sender) Send(_ context.Context, msg mail.Message) error {
+	const op = "mail.filemail.Send"
+
+	log := s.log.With(
+		slog.String("op", op),
+		slog.String("to", msg.To),
+		slog.String("subject", msg.Subject),
+	)
+
+	if s.dir == "" {
+		log.Info("mail message dropped, no mail directory configured")
+
+		return nil
+	}
+
+	if err := os.MkdirAll(s.dir, 0o755); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	name := fmt.Sprintf("%d-%s.eml", time.Now().UnixNano(), sanitize(msg.To))
+	path := filepath.Join(s.dir, name)
+
+	if err := os.WriteFile(path, msg.Format(s.from), 0o600); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("mail message written", slog.String("path", path))
+
+	return nil
+}
+
+// sanitize keeps recipient address usable as part of file name.
+func sanitize(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '-', r == '_', r == '@':
+			return r
+		default:
+			return '_'
+		}
+	}, s)
+}
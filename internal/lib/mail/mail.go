@@ -0,0 +1,46 @@
+package mail
+
+import (
+	"bytes"
+	"mime"
+	"mime/quotedprintable"
+	netmail "net/mail"
+	"time"
+)
+
+type Message struct {
+	To      string
+	Subject string
+	// Body is plain text.
+	Body string
+}
+
+// Format renders message as RFC 5322 text with UTF-8 quoted-printable body.
+func (m Message) Format(from string) []byte {
+	var buf bytes.Buffer
+
+	buf.WriteString("From: " + from + "\r\n")
+	buf.WriteString("To: " + m.To + "\r\n")
+	buf.WriteString("Subject: " + mime.QEncoding.Encode("utf-8", m.Subject) + "\r\n")
+	buf.WriteString("Date: " + time.Now().Format(time.RFC1123Z) + "\r\n")
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	buf.WriteString("Content-Transfer-Encoding: quoted-printable\r\n")
+	buf.WriteString("\r\n")
+
+	qp := quotedprintable.NewWriter(&buf)
+	_, _ = qp.Write([]byte(m.Body))
+	_ = qp.Close()
+
+	return buf.Bytes()
+}
+
+// Address extracts bare email address from "Name <address>" form.
+func Address(s string) (string, error) {
+	addr, err := netmail.ParseAddress(s)
+	if err != nil {
+		return "", err
+	}
+
+	return addr.Address, nil
+}
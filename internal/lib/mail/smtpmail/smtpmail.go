@@ -0,0 +1,112 @@
+package smtpmail
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/smtp"
+	"sso/internal/lib/mail"
+	"strconv"
+)
+
+// implicitTLSPort is the submission port where TLS starts before SMTP greeting.
+const implicitTLSPort = 465
+
+type Sender struct {
+	host     string
+	port     int
+	username string
+	password string
+	from     string
+}
+
+func New(host string, port int, username string, password string, from string) *Sender {
+	return &Sender{
+		host:     host,
+		port:     port,
+		username: username,
+		password: password,
+		from:     from,
+	}
+}
+
+// Send delivers message through SMTP server.
+// STARTTLS is used when server supports it, authentication only when username is set.
+func (s *Sender) Send(ctx context.Context, msg mail.Message) error {
+	const op = "mail.smtpmail.Send"
+
+	conn, err := s.dial(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer conn.Close()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	c, err := smtp.NewClient(conn, s.host)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer c.Close()
+
+	if ok, _ := c.Extension("STARTTLS"); ok && s.port != implicitTLSPort {
+		if err := c.StartTLS(&tls.Config{ServerName: s.host}); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	if s.username != "" {
+		if err := c.Auth(smtp.PlainAuth("", s.username, s.password, s.host)); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	if err := s.send(c, msg); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return c.Quit()
+}
+
+func (s *Sender) dial(ctx context.Context) (net.Conn, error) {
+	addr := net.JoinHostPort(s.host, strconv.Itoa(s.port))
+
+	if s.port == implicitTLSPort {
+		d := tls.Dialer{Config: &tls.Config{ServerName: s.host}}
+
+		return d.DialContext(ctx, "tcp", addr)
+	}
+
+	var d net.Dialer
+
+	return d.DialContext(ctx, "tcp", addr)
+}
+
+func (s *Sender) send(c *smtp.Client, msg mail.Message) error {
+	from, err := mail.Address(s.from)
+	if err != nil {
+		return err
+	}
+
+	if err := c.Mail(from); err != nil {
+		return err
+	}
+
+	if err := c.Rcpt(msg.To); err != nil {
+		return err
+	}
+
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+
+	if _, err := w.Write(msg.Format(s.from)); err != nil {
+		return err
+	}
+
+	return w.Close()
+}
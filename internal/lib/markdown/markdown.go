@@ -0,0 +1,172 @@
+package markdown
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+)
+
+// wordsPerMinute is the reading speed used to estimate reading time.
+const wordsPerMinute = 200
+
+type Heading struct {
+	Level int
+	Title string
+	// ID is the id attribute of the heading in rendered HTML, usable as a link anchor.
+	ID string
+}
+
+type Document struct {
+	// HTML is sanitized and safe to insert into a page as is.
+	HTML     string
+	Headings []Heading
+	Words    int
+}
+
+var (
+	md = goldmark.New(
+		goldmark.WithExtensions(extension.GFM),
+		goldmark.WithParserOptions(parser.WithAutoHeadingID()),
+	)
+
+	policy = newPolicy()
+)
+
+func newPolicy() *bluemonday.Policy {
+	p := bluemonday.UGCPolicy()
+	p.AllowAttrs("id").Matching(regexp.MustCompile(`^[\p{L}\p{N}_-]+$`)).OnElements("h1", "h2", "h3", "h4", "h5", "h6")
+
+	return p
+}
+
+// Render renders Markdown source to HTML. Raw HTML in the source is dropped, the result is also sanitized,
+// so links like javascript: never reach the reader.
+func Render(source string) (Document, error) {
+	src := []byte(source)
+
+	ctx := parser.NewContext(parser.WithIDs(newHeadingIDs()))
+	doc := md.Parser().Parse(text.NewReader(src), parser.WithContext(ctx))
+
+	var (
+		headings []Heading
+		words    int
+	)
+
+	err := ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+
+		switch n := n.(type) {
+		case *ast.Heading:
+			id, _ := n.AttributeString("id")
+			idBytes, _ := id.([]byte)
+
+			headings = append(headings, Heading{
+				Level: n.Level,
+				Title: nodeText(n, src),
+				ID:    string(idBytes),
+			})
+		case *ast.Text:
+			words += len(strings.Fields(string(n.Segment.Value(src))))
+		}
+
+		return ast.WalkContinue, nil
+	})
+	if err != nil {
+		return Document{}, fmt.Errorf("markdown.Render: %w", err)
+	}
+
+	var buf bytes.Buffer
+	if err := md.Renderer().Render(&buf, src, doc); err != nil {
+		return Document{}, fmt.Errorf("markdown.Render: %w", err)
+	}
+
+	if headings == nil {
+		headings = []Heading{}
+	}
+
+	return Document{
+		HTML:     policy.Sanitize(buf.String()),
+		Headings: headings,
+		Words:    words,
+	}, nil
+}
+
+// ReadingMinutes estimates reading time of text with the given number of words, rounded up.
+func ReadingMinutes(words int) int {
+	return (words + wordsPerMinute - 1) / wordsPerMinute
+}
+
+// nodeText returns plain text of inline content of n.
+func nodeText(n ast.Node, src []byte) string {
+	var sb strings.Builder
+
+	for c := n.FirstChild(); c != nil; c = c.NextSibling() {
+		switch c := c.(type) {
+		case *ast.Text:
+			sb.Write(c.Segment.Value(src))
+			if c.SoftLineBreak() || c.HardLineBreak() {
+				sb.WriteByte(' ')
+			}
+		case *ast.String:
+			sb.Write(c.Value)
+		default:
+			sb.WriteString(nodeText(c, src))
+		}
+	}
+
+	return sb.String()
+}
+
+// headingIDs generates heading ids keeping letters of any script, goldmark's default ones
+// drop everything but ASCII, so Russian headings would all become "heading-N".
+type headingIDs struct {
+	used map[string]bool
+}
+
+func newHeadingIDs() *headingIDs {
+	return &headingIDs{used: map[string]bool{}}
+}
+
+func (h *headingIDs) Generate(value []byte, _ ast.NodeKind) []byte {
+	var sb strings.Builder
+
+	dash := false
+	for _, r := range strings.ToLower(string(value)) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsNumber(r) || r == '_':
+			sb.WriteRune(r)
+			dash = false
+		case sb.Len() > 0 && !dash:
+			sb.WriteByte('-')
+			dash = true
+		}
+	}
+
+	id := strings.TrimSuffix(sb.String(), "-")
+	if id == "" {
+		id = "heading"
+	}
+
+	unique := id
+	for i := 1; h.used[unique]; i++ {
+		unique = fmt.Sprintf("%s-%d", id, i)
+	}
+	h.used[unique] = true
+
+	return []byte(unique)
+}
+
+func (h *headingIDs) Put(value []byte) {
+	h.used[string(value)] = true
+}
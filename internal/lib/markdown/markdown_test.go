@@ -0,0 +1,129 @@
+package markdown
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var headingIDRe = regexp.MustCompile(`<h[1-6] id="([^"]*)"`)
+
+func TestRender_Sanitized(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		banned []string
+	}{
+		{
+			// Inline tags are dropped, text between them is left as plain text.
+			name:   "raw script",
+			source: "Text <script>alert(1)</script> after",
+			banned: []string{"<script"},
+		},
+		{
+			name:   "script block",
+			source: "<script>\nalert(1)\n</script>\n\nText",
+			banned: []string{"<script", "alert(1)"},
+		},
+		{
+			name:   "javascript link",
+			source: "[click](javascript:alert(1))",
+			banned: []string{"javascript:"},
+		},
+		{
+			name:   "javascript autolink",
+			source: "<javascript:alert(1)>",
+			banned: []string{"href"},
+		},
+		{
+			name:   "event handler",
+			source: `<img src="x.png" onerror="alert(1)">`,
+			banned: []string{"onerror"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := Render(tt.source)
+			require.NoError(t, err)
+
+			for _, banned := range tt.banned {
+				assert.NotContains(t, doc.HTML, banned)
+			}
+		})
+	}
+}
+
+func TestRender_Headings(t *testing.T) {
+	source := "# Первые шаги\n\n" +
+		"## Установка Go 1.24\n\n" +
+		"## Установка Go 1.24\n\n" +
+		"### `fmt.Println` и **вывод**\n\n" +
+		"## !!!\n"
+
+	doc, err := Render(source)
+	require.NoError(t, err)
+
+	want := []Heading{
+		{Level: 1, Title: "Первые шаги", ID: "первые-шаги"},
+		{Level: 2, Title: "Установка Go 1.24", ID: "установка-go-1-24"},
+		{Level: 2, Title: "Установка Go 1.24", ID: "установка-go-1-24-1"},
+		{Level: 3, Title: "fmt.Println и вывод", ID: "fmt-println-и-вывод"},
+		{Level: 2, Title: "!!!", ID: "heading"},
+	}
+	assert.Equal(t, want, doc.Headings)
+
+	// Table of contents links to ids kept by the sanitizer.
+	var rendered []string
+	for _, m := range headingIDRe.FindAllStringSubmatch(doc.HTML, -1) {
+		rendered = append(rendered, m[1])
+	}
+
+	var toc []string
+	for _, h := range doc.Headings {
+		toc = append(toc, h.ID)
+	}
+
+	assert.Equal(t, toc, rendered)
+
+	// Same source always gets the same ids.
+	again, err := Render(source)
+	require.NoError(t, err)
+	assert.Equal(t, doc.Headings, again.Headings)
+}
+
+func TestRender_Empty(t *testing.T) {
+	doc, err := Render("")
+	require.NoError(t, err)
+
+	assert.Empty(t, doc.HTML)
+	assert.Equal(t, []Heading{}, doc.Headings)
+	assert.Zero(t, doc.Words)
+}
+
+func TestRender_Words(t *testing.T) {
+	doc, err := Render("# Заголовок\n\nРаз два *три* `code`\n\n- четыре\n- пять")
+	require.NoError(t, err)
+
+	assert.Equal(t, 7, doc.Words)
+}
+
+func TestReadingMinutes(t *testing.T) {
+	tests := []struct {
+		words int
+		want  int
+	}{
+		{words: 0, want: 0},
+		{words: 1, want: 1},
+		{words: 199, want: 1},
+		{words: 200, want: 1},
+		{words: 201, want: 2},
+		{words: 1000, want: 5},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, ReadingMinutes(tt.words), tt.words)
+	}
+}
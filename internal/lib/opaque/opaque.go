@@ -0,0 +1,29 @@
+package opaque
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+)
+
+const defaultSize = 32
+
+// New generates random URL-safe token.
+// Only hash of the token (see Hash) should be persisted.
+func New() (string, error) {
+	b := make([]byte, defaultSize)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("opaque.New: %w", err)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// Hash returns hex encoded SHA-256 hash of the token.
+func Hash(token string) string {
+	sum := sha256.Sum256([]byte(token))
+
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,123 @@
+package thumbnail
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func encodePNG(t *testing.T, img image.Image) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, img))
+
+	return buf.Bytes()
+}
+
+// pngHeader returns a tiny PNG whose header declares width x height, like a decompression bomb.
+func pngHeader(t *testing.T, width uint32, height uint32) []byte {
+	t.Helper()
+
+	data := encodePNG(t, image.NewGray(image.Rect(0, 0, 1, 1)))
+
+	// Signature is followed by IHDR: length, type, width, height, ..., CRC of type and data.
+	binary.BigEndian.PutUint32(data[16:20], width)
+	binary.BigEndian.PutUint32(data[20:24], height)
+	binary.BigEndian.PutUint32(data[29:33], crc32.ChecksumIEEE(data[12:29]))
+
+	return data
+}
+
+func TestDecode(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    []byte
+		wantErr error
+	}{
+		{
+			name: "png",
+			data: encodePNG(t, image.NewRGBA(image.Rect(0, 0, 30, 20))),
+		},
+		{
+			name:    "pixel bomb",
+			data:    pngHeader(t, 100_000, 100_000),
+			wantErr: ErrTooLarge,
+		},
+		{
+			name:    "wide image",
+			data:    pngHeader(t, 1_000_001, 1),
+			wantErr: ErrTooLarge,
+		},
+		{
+			name:    "not an image",
+			data:    []byte("<svg xmlns=\"http://www.w3.org/2000/svg\"></svg>"),
+			wantErr: ErrUnsupportedFormat,
+		},
+		{
+			name:    "truncated png",
+			data:    encodePNG(t, image.NewRGBA(image.Rect(0, 0, 30, 20)))[:40],
+			wantErr: ErrUnsupportedFormat,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			img, err := Decode(tt.data, 1_000_000)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, image.Rect(0, 0, 30, 20), img.Bounds())
+		})
+	}
+}
+
+func TestSquare(t *testing.T) {
+	// Left half is red and right half is blue, the central square keeps both.
+	img := image.NewRGBA(image.Rect(0, 0, 300, 200))
+	for y := 0; y < 200; y++ {
+		for x := 0; x < 300; x++ {
+			c := color.RGBA{R: 0xff, A: 0xff}
+			if x >= 150 {
+				c = color.RGBA{B: 0xff, A: 0xff}
+			}
+			img.Set(x, y, c)
+		}
+	}
+
+	for _, size := range []int{256, 128, 64, 1} {
+		thumb := Square(img, size)
+		assert.Equal(t, image.Rect(0, 0, size, size), thumb.Bounds(), size)
+	}
+
+	thumb := Square(img, 64)
+	assert.Equal(t, color.RGBA{R: 0xff, A: 0xff}, thumb.RGBAAt(0, 32))
+	assert.Equal(t, color.RGBA{B: 0xff, A: 0xff}, thumb.RGBAAt(63, 32))
+}
+
+func TestSquare_TransparentBecomesWhite(t *testing.T) {
+	thumb := Square(image.NewNRGBA(image.Rect(0, 0, 10, 10)), 4)
+
+	assert.Equal(t, color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}, thumb.RGBAAt(2, 2))
+}
+
+func TestEncodeJPEG(t *testing.T) {
+	data, err := EncodeJPEG(Square(image.NewRGBA(image.Rect(0, 0, 50, 80)), 32))
+	require.NoError(t, err)
+
+	cfg, err := jpeg.DecodeConfig(bytes.NewReader(data))
+	require.NoError(t, err)
+	assert.Equal(t, 32, cfg.Width)
+	assert.Equal(t, 32, cfg.Height)
+}
@@ -6,111 +6,730 @@ import (
 	"fmt"
 	"golang.org/x/crypto/bcrypt"
 	"log/slog"
+	netmail "net/mail"
+	"net/url"
 	"sso/internal/domain/models"
+	"sso/internal/lib/cache"
 	"sso/internal/lib/jwt"
 	"sso/internal/lib/logger/sl"
+	"sso/internal/lib/mail"
+	"sso/internal/lib/opaque"
 	"sso/internal/storage"
 	"time"
 )
 
 type Auth struct {
-	log         *slog.Logger
-	usrSaver    UserSaver
-	usrProvider UserProvider
-	appProvider AppProvider
-	tokenTTL    time.Duration
+	log               *slog.Logger
+	usrSaver          UserSaver
+	usrProvider       UserProvider
+	appProvider       AppProvider
+	refreshStorage    RefreshTokenStorage
+	tokenStorage      TokenStorage
+	emailTokenStorage EmailTokenStorage
+	mfaStorage        MFAStorage
+	roleStorage       RoleStorage
+	sessionStorage    SessionStorage
+	keyProvider       KeyProvider
+	mailer            Mailer
+	loginLimiter      LoginLimiter
+	cfg               Config
+	// mfaAttempts counts failed codes per MFA challenge jti.
+	mfaAttempts *cache.Cache[string, int]
+	// permissions caches permissions of roles for RevocationCacheTTL.
+	permissions *cache.Cache[string, []string]
+	// revokedSessions remembers sessions revoked by this instance,
+	// so their access tokens are rejected even if validation verdict is cached.
+	revokedSessions *cache.Cache[int64, bool]
+	// tokensRevokedAt remembers when this instance revoked all access tokens of a user,
+	// so cached verdicts of tokens issued before are ignored.
+	tokensRevokedAt *cache.Cache[int64, time.Time]
+	apps            *cache.Cache[int, models.App]
+	// verdicts caches result of token validation by jti,
+	// so revocation and user existence checks don't hit storage on every request.
+	verdicts *cache.Cache[string, bool]
+}
+
+// Config holds token lifetimes and client links used by Auth.
+type Config struct {
+	TokenTTL        time.Duration
+	RefreshTokenTTL time.Duration
+	// RevocationCacheTTL is how long token validation verdicts, apps and role permissions are cached.
+	RevocationCacheTTL time.Duration
+	Verification       VerificationConfig
+	PasswordReset      PasswordResetConfig
+	MFA                MFAConfig
+}
+
+type VerificationConfig struct {
+	// Required makes Authenticate refuse users who haven't confirmed their email.
+	Required bool
+	TokenTTL time.Duration
+	// URL is the page confirming email, token is passed to it as query parameter.
+	URL string
+	// ChangeURL is the page confirming new email, token is passed to it as query parameter.
+	ChangeURL string
+}
+
+type PasswordResetConfig struct {
+	TokenTTL time.Duration
+	// URL is the page where user sets a new password, token is passed to it as query parameter.
+	URL string
+}
+
+type MFAConfig struct {
+	// Issuer is shown as account issuer in authenticator apps.
+	Issuer       string
+	ChallengeTTL time.Duration
 }
 
 var (
-	ErrInvalidCredentials = errors.New("invalid credentials")
+	ErrInvalidCredentials  = errors.New("invalid credentials")
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+	ErrRefreshTokenReused  = errors.New("refresh token reused")
+	ErrInvalidToken        = errors.New("invalid token")
+	ErrInvalidAppID        = errors.New("invalid app id")
+	ErrInvalidEmail        = errors.New("invalid email")
+	ErrEmailNotVerified    = errors.New("email is not verified")
+	ErrInvalidEmailToken   = errors.New("invalid email token")
+	ErrInvalidPassword     = errors.New("invalid password")
+	ErrMFAAlreadyEnabled   = errors.New("two-factor authentication already enabled")
+	ErrMFANotEnabled       = errors.New("two-factor authentication not enabled")
+	ErrInvalidMFACode      = errors.New("invalid two-factor code")
+	ErrInvalidMFAToken     = errors.New("invalid mfa token")
 )
 
+// Storage groups everything Auth keeps in the database.
+type Storage interface {
+	UserSaver
+	UserProvider
+	AppProvider
+	RefreshTokenStorage
+	TokenStorage
+	EmailTokenStorage
+	MFAStorage
+	RoleStorage
+	SessionStorage
+}
+
 type UserSaver interface {
 	SaveUser(
 		ctx context.Context,
 		email string,
 		passHash []byte,
 	) (uid int64, err error)
+	VerifyUser(ctx context.Context, userID int64) error
+	UpdatePassword(ctx context.Context, userID int64, passHash []byte) error
+	// ResetPassword replaces password and revokes all tokens and sessions of user atomically.
+	ResetPassword(ctx context.Context, userID int64, passHash []byte, revokedAt time.Time) error
+	UpdateEmail(ctx context.Context, userID int64, email string) error
 }
 
 type UserProvider interface {
 	User(ctx context.Context, email string) (models.User, error)
+	UserByID(ctx context.Context, userID int64) (models.User, error)
 }
 
 type AppProvider interface {
-	App(ctx context.Context) (models.App, error)
+	App(ctx context.Context, appID int) (models.App, error)
+}
+
+type RefreshTokenStorage interface {
+	SaveRefreshToken(ctx context.Context, token models.RefreshToken) error
+	RefreshToken(ctx context.Context, tokenHash string) (models.RefreshToken, error)
+	UseRefreshToken(ctx context.Context, id int64) error
+	RevokeRefreshTokenFamily(ctx context.Context, familyID string) error
+}
+
+type TokenStorage interface {
+	RevokeToken(ctx context.Context, jti string, expiresAt time.Time) error
+	IsTokenRevoked(ctx context.Context, jti string) (bool, error)
+}
+
+type EmailTokenStorage interface {
+	SaveEmailToken(ctx context.Context, token models.EmailToken) error
+	UseEmailToken(ctx context.Context, tokenHash string, purpose string) (models.EmailToken, error)
+	InvalidateEmailTokens(ctx context.Context, userID int64, purpose string) error
+}
+
+type MFAStorage interface {
+	SaveTOTPSecret(ctx context.Context, userID int64, secret string) error
+	TOTP(ctx context.Context, userID int64) (models.TOTP, error)
+	ConfirmTOTP(ctx context.Context, userID int64, recoveryCodeHashes []string) error
+	UseTOTPStep(ctx context.Context, userID int64, step int64) error
+	UseRecoveryCode(ctx context.Context, userID int64, codeHash string) error
+	DeleteTOTP(ctx context.Context, userID int64) error
+}
+
+// LoginLimiter protects password and second factor checks from brute force.
+// Allow reserves an attempt before the check, it stays counted as failed unless it's refunded.
+type LoginLimiter interface {
+	Allow(ctx context.Context, email string, ip string) error
+	Refund(ctx context.Context, email string, ip string) error
+	Succeed(ctx context.Context, email string, ip string) error
+}
+
+type RoleStorage interface {
+	UserRoles(ctx context.Context, userID int64) ([]string, error)
+	RolePermissions(ctx context.Context, role string) ([]string, error)
+	AssignRole(ctx context.Context, userID int64, role string) error
+	RevokeRole(ctx context.Context, userID int64, role string) error
+	InvalidateAccessTokens(ctx context.Context, userID int64, revokedAt time.Time) error
+}
+
+type SessionStorage interface {
+	SaveSession(ctx context.Context, session models.Session) (int64, error)
+	SessionByFamily(ctx context.Context, familyID string) (models.Session, error)
+	Sessions(ctx context.Context, userID int64, seenSince time.Time) ([]models.Session, error)
+	TouchSession(ctx context.Context, sessionID int64, lastSeenAt time.Time) error
+	RevokeSession(ctx context.Context, userID int64, sessionID int64) error
+	RevokeOtherSessions(ctx context.Context, userID int64, keepSessionID int64) ([]int64, error)
+}
+
+type Mailer interface {
+	Send(ctx context.Context, msg mail.Message) error
+}
+
+type KeyProvider interface {
+	SigningKey(ctx context.Context) (jwt.Key, error)
+	VerificationKey(ctx context.Context, kid string) (jwt.Key, error)
 }
 
 func New(
 	log *slog.Logger,
-	userSaver UserSaver,
-	userProvider UserProvider,
-	appProvider AppProvider,
-	tokenTTL time.Duration,
+	storage Storage,
+	keyProvider KeyProvider,
+	mailer Mailer,
+	loginLimiter LoginLimiter,
+	cfg Config,
 ) *Auth {
 	return &Auth{
-		usrSaver:    userSaver,
-		usrProvider: userProvider,
-		log:         log,
-		appProvider: appProvider,
-		tokenTTL:    tokenTTL,
+		log:               log,
+		usrSaver:          storage,
+		usrProvider:       storage,
+		appProvider:       storage,
+		refreshStorage:    storage,
+		tokenStorage:      storage,
+		emailTokenStorage: storage,
+		mfaStorage:        storage,
+		roleStorage:       storage,
+		sessionStorage:    storage,
+		keyProvider:       keyProvider,
+		mailer:            mailer,
+		loginLimiter:      loginLimiter,
+		cfg:               cfg,
+		mfaAttempts:       cache.New[string, int](),
+		permissions:       cache.New[string, []string](),
+		revokedSessions:   cache.New[int64, bool](),
+		tokensRevokedAt:   cache.New[int64, time.Time](),
+		apps:              cache.New[int, models.App](),
+		verdicts:          cache.New[string, bool](),
 	}
 }
 
-// Login checks if user with given credentials exists in the system and returns access token.
+// Login checks if user with given credentials exists in the system and returns access and refresh tokens
+// for the given app. Login session is recorded for the client.
+// If user has two-factor authentication enabled, only MFA challenge token is returned,
+// it is exchanged for tokens by VerifyMFA.
 // If user exists, but password is incorrect, returns error.
 // If user doesn't exist, returns error.
 func (a *Auth) Login(
 	ctx context.Context,
 	email string,
 	password string,
-) (string, error) {
+	appID int,
+	client models.Client,
+) (models.TokenPair, error) {
 	const op = "Auth.Login"
 
+	user, err := a.Authenticate(ctx, email, password, client)
+	if err != nil {
+		return models.TokenPair{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if user.MFAEnabled {
+		mfaToken, err := a.newMFAChallenge(ctx, user, appID)
+		if err != nil {
+			return models.TokenPair{}, fmt.Errorf("%s: %w", op, err)
+		}
+
+		return models.TokenPair{MFAToken: mfaToken}, nil
+	}
+
+	tokens, err := a.IssueTokens(ctx, user, appID, client)
+	if err != nil {
+		return models.TokenPair{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return tokens, nil
+}
+
+// Authenticate checks if user with given credentials exists in the system and returns the user.
+// Repeated failures for the same email or client IP block further attempts for a while,
+// in that case *throttle.BlockedError is returned.
+func (a *Auth) Authenticate(
+	ctx context.Context,
+	email string,
+	password string,
+	client models.Client,
+) (models.User, error) {
+	const op = "Auth.Authenticate"
+
 	log := a.log.With(
 		slog.String("op", op),
 		slog.String("username", email),
+		slog.String("ip", client.IP),
 	)
 
 	log.Info("attempting to login user")
 
+	if err := a.loginLimiter.Allow(ctx, email, client.IP); err != nil {
+		log.Warn("login attempt refused", sl.Err(err))
+
+		return models.User{}, fmt.Errorf("%s: %w", op, err)
+	}
+
 	user, err := a.usrProvider.User(ctx, email)
 	if err != nil {
 		if errors.Is(err, storage.ErrUserNotFound) {
 			a.log.Warn("user not found", sl.Err(err))
 
-			return "", fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+			return models.User{}, fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
 		}
 
 		a.log.Error("failed to get user", sl.Err(err))
 
-		return "", fmt.Errorf("%s: %w", op, err)
+		return models.User{}, fmt.Errorf("%s: %w", op, err)
 	}
 
 	if err := bcrypt.CompareHashAndPassword(user.PassHash, []byte(password)); err != nil {
 		a.log.Info("invalid credentials", sl.Err(err))
 
-		return "", fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+		return models.User{}, fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
 	}
 
-	app, err := a.appProvider.App(ctx)
-	if err != nil {
-		return "", fmt.Errorf("%s: %w", op, err)
+	// Failures of users with two-factor authentication are reset only after the second factor
+	// is verified, otherwise the password alone would let guessing the code go on forever.
+	if user.MFAEnabled {
+		a.refundLoginAttempt(ctx, log, email, client)
+	} else {
+		a.loginSucceeded(ctx, log, email, client)
+	}
+
+	if a.cfg.Verification.Required && !user.Verified {
+		log.Info("email is not verified")
+
+		return models.User{}, fmt.Errorf("%s: %w", op, ErrEmailNotVerified)
 	}
 
 	log.Info("user logged in successfully")
 
-	token, err := jwt.NewToken(user, app, a.tokenTTL)
+	return user, nil
+}
+
+// refundLoginAttempt returns attempt reserved by the limiter, limiter errors don't change the result of login.
+func (a *Auth) refundLoginAttempt(ctx context.Context, log *slog.Logger, email string, client models.Client) {
+	if err := a.loginLimiter.Refund(ctx, email, client.IP); err != nil {
+		log.Error("failed to refund login attempt", sl.Err(err))
+	}
+}
+
+// loginSucceeded resets failed attempts of the account, limiter errors don't change the result of login.
+func (a *Auth) loginSucceeded(ctx context.Context, log *slog.Logger, email string, client models.Client) {
+	if err := a.loginLimiter.Succeed(ctx, email, client.IP); err != nil {
+		log.Error("failed to reset login failures", sl.Err(err))
+	}
+}
+
+// IssueTokens starts a new session with its own refresh token family for authenticated user
+// and returns the first token pair.
+func (a *Auth) IssueTokens(
+	ctx context.Context,
+	user models.User,
+	appID int,
+	client models.Client,
+) (models.TokenPair, error) {
+	const op = "Auth.IssueTokens"
+
+	log := a.log.With(
+		slog.String("op", op),
+		slog.Int64("uid", user.ID),
+		slog.Int("app_id", appID),
+	)
+
+	app, err := a.app(ctx, appID)
+	if err != nil {
+		if errors.Is(err, storage.ErrAppNotFound) {
+			log.Warn("app not found")
+
+			return models.TokenPair{}, fmt.Errorf("%s: %w", op, ErrInvalidAppID)
+		}
+
+		return models.TokenPair{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	familyID, err := opaque.New()
+	if err != nil {
+		return models.TokenPair{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	now := time.Now()
+
+	sessionID, err := a.sessionStorage.SaveSession(ctx, models.Session{
+		UserID:     user.ID,
+		AppID:      app.ID,
+		FamilyID:   familyID,
+		DeviceName: client.DeviceName,
+		UserAgent:  client.UserAgent,
+		IP:         client.IP,
+		CreatedAt:  now,
+		LastSeenAt: now,
+	})
+	if err != nil {
+		log.Error("failed to save session", sl.Err(err))
+
+		return models.TokenPair{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	tokens, err := a.issueTokens(ctx, user, app, familyID, sessionID)
+	if err != nil {
+		log.Error("failed to generate tokens", sl.Err(err))
+
+		return models.TokenPair{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return tokens, nil
+}
+
+// Refresh exchanges refresh token for a new token pair and rotates refresh token.
+// Each refresh token can be used only once. If already used token is presented,
+// the whole token family is revoked, because either the client or an attacker holds a stolen copy.
+func (a *Auth) Refresh(ctx context.Context, refreshToken string) (models.TokenPair, error) {
+	const op = "Auth.Refresh"
+
+	return a.refresh(ctx, op, refreshToken, 0)
+}
+
+// RefreshForApp works like Refresh, but accepts only refresh tokens issued to the given app.
+// Token of another app is rejected without being used, so its owner can still refresh it.
+func (a *Auth) RefreshForApp(ctx context.Context, refreshToken string, appID int) (models.TokenPair, error) {
+	const op = "Auth.RefreshForApp"
+
+	return a.refresh(ctx, op, refreshToken, appID)
+}
+
+// refresh rotates refresh token, appID 0 accepts token of any app.
+func (a *Auth) refresh(ctx context.Context, op string, refreshToken string, appID int) (models.TokenPair, error) {
+	log := a.log.With(slog.String("op", op))
+
+	stored, err := a.refreshStorage.RefreshToken(ctx, opaque.Hash(refreshToken))
+	if err != nil {
+		if errors.Is(err, storage.ErrRefreshTokenNotFound) {
+			log.Warn("refresh token not found")
+
+			return models.TokenPair{}, fmt.Errorf("%s: %w", op, ErrInvalidRefreshToken)
+		}
+
+		log.Error("failed to get refresh token", sl.Err(err))
+
+		return models.TokenPair{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log = log.With(slog.Int64("uid", stored.UserID))
+
+	if stored.Revoked || time.Now().After(stored.ExpiresAt) {
+		log.Info("refresh token is revoked or expired")
+
+		return models.TokenPair{}, fmt.Errorf("%s: %w", op, ErrInvalidRefreshToken)
+	}
+
+	if appID != 0 && stored.AppID != appID {
+		log.Warn("refresh token issued to another app", slog.Int("app_id", appID))
+
+		return models.TokenPair{}, fmt.Errorf("%s: %w", op, ErrInvalidRefreshToken)
+	}
+
+	if stored.Used {
+		return models.TokenPair{}, a.revokeReusedFamily(ctx, log, op, stored.FamilyID)
+	}
+
+	if err := a.refreshStorage.UseRefreshToken(ctx, stored.ID); err != nil {
+		if errors.Is(err, storage.ErrRefreshTokenUsed) {
+			// Lost the race with concurrent refresh of the same token.
+			return models.TokenPair{}, a.revokeReusedFamily(ctx, log, op, stored.FamilyID)
+		}
+
+		log.Error("failed to use refresh token", sl.Err(err))
+
+		return models.TokenPair{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	session, err := a.sessionStorage.SessionByFamily(ctx, stored.FamilyID)
+	if err != nil {
+		if errors.Is(err, storage.ErrSessionNotFound) {
+			log.Warn("session not found")
+
+			return models.TokenPair{}, fmt.Errorf("%s: %w", op, ErrInvalidRefreshToken)
+		}
+
+		log.Error("failed to get session", sl.Err(err))
+
+		return models.TokenPair{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := a.sessionStorage.TouchSession(ctx, session.ID, time.Now()); err != nil {
+		if errors.Is(err, storage.ErrSessionNotFound) {
+			log.Info("session is revoked")
+
+			return models.TokenPair{}, fmt.Errorf("%s: %w", op, ErrInvalidRefreshToken)
+		}
+
+		log.Error("failed to update session", sl.Err(err))
+
+		return models.TokenPair{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	user, err := a.usrProvider.UserByID(ctx, stored.UserID)
+	if err != nil {
+		if errors.Is(err, storage.ErrUserNotFound) {
+			log.Warn("user not found", sl.Err(err))
+
+			return models.TokenPair{}, fmt.Errorf("%s: %w", op, ErrInvalidRefreshToken)
+		}
+
+		return models.TokenPair{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	app, err := a.app(ctx, stored.AppID)
+	if err != nil {
+		if errors.Is(err, storage.ErrAppNotFound) {
+			return models.TokenPair{}, fmt.Errorf("%s: %w", op, ErrInvalidRefreshToken)
+		}
+
+		return models.TokenPair{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	tokens, err := a.issueTokens(ctx, user, app, stored.FamilyID, session.ID)
+	if err != nil {
+		log.Error("failed to generate tokens", sl.Err(err))
+
+		return models.TokenPair{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("tokens refreshed")
+
+	return tokens, nil
+}
+
+// Logout revokes access token and ends its session.
+// Refresh token, if given, is revoked as well for tokens issued before sessions were recorded.
+func (a *Auth) Logout(ctx context.Context, token string, refreshToken string) error {
+	const op = "Auth.Logout"
+
+	log := a.log.With(slog.String("op", op))
+
+	claims, err := a.parseToken(ctx, token)
+	if err != nil {
+		log.Info("failed to parse token", sl.Err(err))
+
+		return fmt.Errorf("%s: %w", op, ErrInvalidToken)
+	}
+
+	log = log.With(slog.Int64("uid", claims.UID))
+
+	if err := a.tokenStorage.RevokeToken(ctx, claims.ID, claims.ExpiresAt); err != nil {
+		log.Error("failed to revoke token", sl.Err(err))
+
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	a.verdicts.Set(claims.ID, false, time.Until(claims.ExpiresAt))
+
+	if claims.SessionID != 0 {
+		err := a.sessionStorage.RevokeSession(ctx, claims.UID, claims.SessionID)
+		if err != nil && !errors.Is(err, storage.ErrSessionNotFound) {
+			log.Error("failed to revoke session", sl.Err(err))
+
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		a.revokedSessions.Set(claims.SessionID, true, a.cfg.TokenTTL)
+	}
+
+	if refreshToken != "" {
+		stored, err := a.refreshStorage.RefreshToken(ctx, opaque.Hash(refreshToken))
+		if err != nil && !errors.Is(err, storage.ErrRefreshTokenNotFound) {
+			log.Error("failed to get refresh token", sl.Err(err))
+
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		if err == nil && stored.UserID == claims.UID {
+			if err := a.refreshStorage.RevokeRefreshTokenFamily(ctx, stored.FamilyID); err != nil {
+				log.Error("failed to revoke refresh tokens", sl.Err(err))
+
+				return fmt.Errorf("%s: %w", op, err)
+			}
+		}
+	}
+
+	log.Info("user logged out")
+
+	return nil
+}
+
+// ValidateToken checks access token signature, expiration and revocation,
+// and makes sure the user it was issued to still exists and its session is active.
+// Results are cached: rejected tokens until they expire, accepted ones for RevocationCacheTTL.
+// Last seen time of the session is updated whenever the result is not cached.
+func (a *Auth) ValidateToken(ctx context.Context, token string) (jwt.Claims, error) {
+	const op = "Auth.ValidateToken"
+
+	claims, err := a.parseToken(ctx, token)
+	if err != nil || claims.Type != jwt.TypeAccess {
+		return jwt.Claims{}, fmt.Errorf("%s: %w", op, ErrInvalidToken)
+	}
+
+	if _, revoked := a.revokedSessions.Get(claims.SessionID); revoked {
+		return jwt.Claims{}, fmt.Errorf("%s: %w", op, ErrInvalidToken)
+	}
+
+	if revokedAt, ok := a.tokensRevokedAt.Get(claims.UID); ok && !claims.IssuedAt.After(revokedAt) {
+		return jwt.Claims{}, fmt.Errorf("%s: %w", op, ErrInvalidToken)
+	}
+
+	if valid, ok := a.verdicts.Get(claims.ID); ok {
+		if !valid {
+			return jwt.Claims{}, fmt.Errorf("%s: %w", op, ErrInvalidToken)
+		}
+
+		return claims, nil
+	}
+
+	revoked, err := a.tokenStorage.IsTokenRevoked(ctx, claims.ID)
+	if err != nil {
+		return jwt.Claims{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if revoked {
+		a.verdicts.Set(claims.ID, false, time.Until(claims.ExpiresAt))
+
+		return jwt.Claims{}, fmt.Errorf("%s: %w", op, ErrInvalidToken)
+	}
+
+	user, err := a.usrProvider.UserByID(ctx, claims.UID)
 	if err != nil {
-		a.log.Error("failed to generate token", sl.Err(err))
+		if errors.Is(err, storage.ErrUserNotFound) {
+			a.verdicts.Set(claims.ID, false, time.Until(claims.ExpiresAt))
+
+			return jwt.Claims{}, fmt.Errorf("%s: %w", op, ErrInvalidToken)
+		}
+
+		return jwt.Claims{}, fmt.Errorf("%s: %w", op, err)
+	}
 
-		return "", fmt.Errorf("%s: %w", op, err)
+	// Issue time has second precision, tokens issued in the second of revocation are rejected too.
+	if !claims.IssuedAt.After(user.TokensRevokedAt) {
+		a.verdicts.Set(claims.ID, false, time.Until(claims.ExpiresAt))
+
+		return jwt.Claims{}, fmt.Errorf("%s: %w", op, ErrInvalidToken)
 	}
 
-	return token, nil
+	if claims.SessionID != 0 {
+		if err := a.sessionStorage.TouchSession(ctx, claims.SessionID, time.Now()); err != nil {
+			if errors.Is(err, storage.ErrSessionNotFound) {
+				a.verdicts.Set(claims.ID, false, time.Until(claims.ExpiresAt))
+
+				return jwt.Claims{}, fmt.Errorf("%s: %w", op, ErrInvalidToken)
+			}
+
+			return jwt.Claims{}, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	a.verdicts.Set(claims.ID, true, a.cfg.RevocationCacheTTL)
+
+	return claims, nil
+}
+
+// parseToken verifies token with the public key named in its kid header.
+func (a *Auth) parseToken(ctx context.Context, token string) (jwt.Claims, error) {
+	return jwt.ParseToken(token, func(kid string) (jwt.Key, error) {
+		return a.keyProvider.VerificationKey(ctx, kid)
+	})
+}
+
+// app returns app by id, cached for RevocationCacheTTL.
+func (a *Auth) app(ctx context.Context, appID int) (models.App, error) {
+	if app, ok := a.apps.Get(appID); ok {
+		return app, nil
+	}
+
+	app, err := a.appProvider.App(ctx, appID)
+	if err != nil {
+		return models.App{}, err
+	}
+
+	a.apps.Set(appID, app, a.cfg.RevocationCacheTTL)
+
+	return app, nil
+}
+
+func (a *Auth) revokeReusedFamily(ctx context.Context, log *slog.Logger, op string, familyID string) error {
+	log.Warn("refresh token reuse detected, revoking token family")
+
+	if err := a.refreshStorage.RevokeRefreshTokenFamily(ctx, familyID); err != nil {
+		log.Error("failed to revoke token family", sl.Err(err))
+
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return fmt.Errorf("%s: %w", op, ErrRefreshTokenReused)
 }
 
-// RegisterNewUser registers new user in the system and returns user ID.
+// issueTokens creates access token for the session and stores a new refresh token in the given family.
+func (a *Auth) issueTokens(
+	ctx context.Context,
+	user models.User,
+	app models.App,
+	familyID string,
+	sessionID int64,
+) (models.TokenPair, error) {
+	key, err := a.keyProvider.SigningKey(ctx)
+	if err != nil {
+		return models.TokenPair{}, err
+	}
+
+	user.Roles, err = a.roleStorage.UserRoles(ctx, user.ID)
+	if err != nil {
+		return models.TokenPair{}, err
+	}
+
+	accessToken, err := jwt.NewToken(user, app, key, a.cfg.TokenTTL, sessionID)
+	if err != nil {
+		return models.TokenPair{}, err
+	}
+
+	refreshToken, err := opaque.New()
+	if err != nil {
+		return models.TokenPair{}, err
+	}
+
+	err = a.refreshStorage.SaveRefreshToken(ctx, models.RefreshToken{
+		UserID:    user.ID,
+		AppID:     app.ID,
+		FamilyID:  familyID,
+		TokenHash: opaque.Hash(refreshToken),
+		ExpiresAt: time.Now().Add(a.cfg.RefreshTokenTTL),
+	})
+	if err != nil {
+		return models.TokenPair{}, err
+	}
+
+	return models.TokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+	}, nil
+}
+
+// RegisterNewUser registers new user in the system, sends email verification link and returns user ID.
 // If user with given username already exists, returns error.
 func (a *Auth) RegisterNewUser(ctx context.Context, email string, pass string) (int64, error) {
 	const op = "Auth.RegisterNewUser"
@@ -122,6 +741,12 @@ func (a *Auth) RegisterNewUser(ctx context.Context, email string, pass string) (
 
 	log.Info("registering user")
 
+	if !validEmail(email) {
+		log.Info("invalid email")
+
+		return 0, fmt.Errorf("%s: %w", op, ErrInvalidEmail)
+	}
+
 	passHash, err := bcrypt.GenerateFromPassword([]byte(pass), bcrypt.DefaultCost)
 	if err != nil {
 		log.Error("failed to generate password hash", sl.Err(err))
@@ -136,5 +761,171 @@ func (a *Auth) RegisterNewUser(ctx context.Context, email string, pass string) (
 		return 0, fmt.Errorf("%s: %w", op, err)
 	}
 
+	// User is already registered at this point and can ask for another link,
+	// so failed delivery doesn't fail registration.
+	if err := a.sendVerification(ctx, id, email); err != nil {
+		log.Error("failed to send verification email", sl.Err(err))
+	}
+
 	return id, nil
 }
+
+// ConfirmEmail marks user email as verified by token sent to it.
+// Token is single-use and is only valid for the address it was sent to.
+func (a *Auth) ConfirmEmail(ctx context.Context, token string) error {
+	const op = "Auth.ConfirmEmail"
+
+	log := a.log.With(slog.String("op", op))
+
+	stored, err := a.emailTokenStorage.UseEmailToken(ctx, opaque.Hash(token), models.EmailTokenVerify)
+	if err != nil {
+		if errors.Is(err, storage.ErrEmailTokenNotFound) {
+			log.Info("email token not found")
+
+			return fmt.Errorf("%s: %w", op, ErrInvalidEmailToken)
+		}
+
+		log.Error("failed to use email token", sl.Err(err))
+
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log = log.With(slog.Int64("uid", stored.UserID))
+
+	if time.Now().After(stored.ExpiresAt) {
+		log.Info("email token expired")
+
+		return fmt.Errorf("%s: %w", op, ErrInvalidEmailToken)
+	}
+
+	user, err := a.usrProvider.UserByID(ctx, stored.UserID)
+	if err != nil {
+		if errors.Is(err, storage.ErrUserNotFound) {
+			return fmt.Errorf("%s: %w", op, ErrInvalidEmailToken)
+		}
+
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if user.Email != stored.Email {
+		log.Info("email changed since token was sent")
+
+		return fmt.Errorf("%s: %w", op, ErrInvalidEmailToken)
+	}
+
+	if err := a.usrSaver.VerifyUser(ctx, user.ID); err != nil {
+		log.Error("failed to verify user", sl.Err(err))
+
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("email confirmed")
+
+	return nil
+}
+
+// ResendVerification sends a new verification link, previously sent links stop working.
+// To not disclose which emails are registered, unknown and already verified emails are silently ignored.
+func (a *Auth) ResendVerification(ctx context.Context, email string) error {
+	const op = "Auth.ResendVerification"
+
+	log := a.log.With(
+		slog.String("op", op),
+		slog.String("email", email),
+	)
+
+	user, err := a.usrProvider.User(ctx, email)
+	if err != nil {
+		if errors.Is(err, storage.ErrUserNotFound) {
+			log.Info("user not found")
+
+			return nil
+		}
+
+		log.Error("failed to get user", sl.Err(err))
+
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if user.Verified {
+		log.Info("email is already verified")
+
+		return nil
+	}
+
+	if err := a.emailTokenStorage.InvalidateEmailTokens(ctx, user.ID, models.EmailTokenVerify); err != nil {
+		log.Error("failed to invalidate email tokens", sl.Err(err))
+
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := a.sendVerification(ctx, user.ID, user.Email); err != nil {
+		log.Error("failed to send verification email", sl.Err(err))
+
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// sendVerification stores a new verification token and mails the link with it.
+func (a *Auth) sendVerification(ctx context.Context, userID int64, email string) error {
+	link, err := a.newEmailLink(ctx, userID, email, models.EmailTokenVerify, a.cfg.Verification.TokenTTL, a.cfg.Verification.URL)
+	if err != nil {
+		return err
+	}
+
+	return a.mailer.Send(ctx, mail.Message{
+		To:      email,
+		Subject: "Подтверждение email",
+		Body: fmt.Sprintf(
+			"Здравствуйте!\n\nЧтобы подтвердить email, перейдите по ссылке:\n%s\n\n"+
+				"Если вы не регистрировались, просто проигнорируйте это письмо.\n",
+			link,
+		),
+	})
+}
+
+// newEmailLink stores a new single-use token with given purpose
+// and returns pageURL with the token added as query parameter.
+func (a *Auth) newEmailLink(
+	ctx context.Context,
+	userID int64,
+	email string,
+	purpose string,
+	ttl time.Duration,
+	pageURL string,
+) (string, error) {
+	token, err := opaque.New()
+	if err != nil {
+		return "", err
+	}
+
+	err = a.emailTokenStorage.SaveEmailToken(ctx, models.EmailToken{
+		TokenHash: opaque.Hash(token),
+		UserID:    userID,
+		Purpose:   purpose,
+		Email:     email,
+		ExpiresAt: time.Now().Add(ttl),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	link, err := url.Parse(pageURL)
+	if err != nil {
+		return "", err
+	}
+	q := link.Query()
+	q.Set("token", token)
+	link.RawQuery = q.Encode()
+
+	return link.String(), nil
+}
+
+// validEmail reports whether s is a bare email address, without display name.
+func validEmail(s string) bool {
+	addr, err := netmail.ParseAddress(s)
+
+	return err == nil && addr.Address == s
+}
@@ -0,0 +1,213 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"golang.org/x/crypto/bcrypt"
+	"log/slog"
+	"sso/internal/domain/models"
+	"sso/internal/lib/logger/sl"
+	"sso/internal/lib/mail"
+	"sso/internal/lib/opaque"
+	"sso/internal/storage"
+	"time"
+)
+
+// ChangePassword replaces password of user after checking the current one.
+// All sessions except sessionID, the one the change was made from, are revoked.
+// Wrong current passwords are throttled like failed logins of the account, *throttle.BlockedError is returned then.
+func (a *Auth) ChangePassword(
+	ctx context.Context,
+	userID int64,
+	sessionID int64,
+	currentPassword string,
+	newPassword string,
+	client models.Client,
+) error {
+	const op = "Auth.ChangePassword"
+
+	log := a.log.With(
+		slog.String("op", op),
+		slog.Int64("uid", userID),
+		slog.String("ip", client.IP),
+	)
+
+	if newPassword == "" {
+		return fmt.Errorf("%s: %w", op, ErrInvalidPassword)
+	}
+
+	user, err := a.usrProvider.UserByID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := a.loginLimiter.Allow(ctx, user.Email, client.IP); err != nil {
+		log.Warn("password change refused", sl.Err(err))
+
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := bcrypt.CompareHashAndPassword(user.PassHash, []byte(currentPassword)); err != nil {
+		log.Info("invalid current password")
+
+		return fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+	}
+	a.loginSucceeded(ctx, log, user.Email, client)
+
+	passHash, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
+	if err != nil {
+		log.Error("failed to generate password hash", sl.Err(err))
+
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := a.usrSaver.UpdatePassword(ctx, userID, passHash); err != nil {
+		log.Error("failed to update password", sl.Err(err))
+
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	revoked, err := a.sessionStorage.RevokeOtherSessions(ctx, userID, sessionID)
+	if err != nil {
+		log.Error("failed to revoke other sessions", sl.Err(err))
+
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	for _, id := range revoked {
+		a.revokedSessions.Set(id, true, a.cfg.TokenTTL)
+	}
+
+	log.Info("password changed", slog.Int("revoked_sessions", len(revoked)))
+
+	return nil
+}
+
+// ChangeEmail mails confirmation link to the new address, email is replaced once it is confirmed
+// by ConfirmEmailChange. Previously sent links stop working.
+// If the address is taken by another user, returns storage.ErrUserExists.
+func (a *Auth) ChangeEmail(ctx context.Context, userID int64, newEmail string) error {
+	const op = "Auth.ChangeEmail"
+
+	log := a.log.With(
+		slog.String("op", op),
+		slog.Int64("uid", userID),
+		slog.String("email", newEmail),
+	)
+
+	if !validEmail(newEmail) {
+		log.Info("invalid email")
+
+		return fmt.Errorf("%s: %w", op, ErrInvalidEmail)
+	}
+
+	_, err := a.usrProvider.User(ctx, newEmail)
+	if err == nil {
+		log.Info("email is already taken")
+
+		return fmt.Errorf("%s: %w", op, storage.ErrUserExists)
+	}
+	if !errors.Is(err, storage.ErrUserNotFound) {
+		log.Error("failed to get user", sl.Err(err))
+
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := a.emailTokenStorage.InvalidateEmailTokens(ctx, userID, models.EmailTokenChangeEmail); err != nil {
+		log.Error("failed to invalidate email tokens", sl.Err(err))
+
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	link, err := a.newEmailLink(ctx, userID, newEmail, models.EmailTokenChangeEmail, a.cfg.Verification.TokenTTL, a.cfg.Verification.ChangeURL)
+	if err != nil {
+		log.Error("failed to create email token", sl.Err(err))
+
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	err = a.mailer.Send(ctx, mail.Message{
+		To:      newEmail,
+		Subject: "Смена email",
+		Body: fmt.Sprintf(
+			"Здравствуйте!\n\nЧтобы подтвердить новый email, перейдите по ссылке:\n%s\n\n"+
+				"Если вы не меняли email, просто проигнорируйте это письмо.\n",
+			link,
+		),
+	})
+	if err != nil {
+		log.Error("failed to send confirmation email", sl.Err(err))
+
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("email change requested")
+
+	return nil
+}
+
+// ConfirmEmailChange replaces user email with the address the token was sent to.
+// Password reset links sent to the old address stop working, the old address is notified.
+func (a *Auth) ConfirmEmailChange(ctx context.Context, token string) error {
+	const op = "Auth.ConfirmEmailChange"
+
+	log := a.log.With(slog.String("op", op))
+
+	stored, err := a.emailTokenStorage.UseEmailToken(ctx, opaque.Hash(token), models.EmailTokenChangeEmail)
+	if err != nil {
+		if errors.Is(err, storage.ErrEmailTokenNotFound) {
+			log.Info("email token not found")
+
+			return fmt.Errorf("%s: %w", op, ErrInvalidEmailToken)
+		}
+
+		log.Error("failed to use email token", sl.Err(err))
+
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log = log.With(slog.Int64("uid", stored.UserID))
+
+	if time.Now().After(stored.ExpiresAt) {
+		log.Info("email token expired")
+
+		return fmt.Errorf("%s: %w", op, ErrInvalidEmailToken)
+	}
+
+	user, err := a.usrProvider.UserByID(ctx, stored.UserID)
+	if err != nil {
+		if errors.Is(err, storage.ErrUserNotFound) {
+			return fmt.Errorf("%s: %w", op, ErrInvalidEmailToken)
+		}
+
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := a.usrSaver.UpdateEmail(ctx, user.ID, stored.Email); err != nil {
+		log.Warn("failed to update email", sl.Err(err))
+
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := a.emailTokenStorage.InvalidateEmailTokens(ctx, user.ID, models.EmailTokenPasswordReset); err != nil {
+		log.Error("failed to invalidate reset codes", sl.Err(err))
+	}
+
+	err = a.mailer.Send(ctx, mail.Message{
+		To:      user.Email,
+		Subject: "Email изменён",
+		Body: fmt.Sprintf(
+			"Здравствуйте!\n\nEmail вашего аккаунта изменён на %s.\n\n"+
+				"Если это сделали не вы, срочно восстановите доступ к аккаунту.\n",
+			stored.Email,
+		),
+	})
+	if err != nil {
+		log.Error("failed to notify old email", sl.Err(err))
+	}
+
+	log.Info("email changed")
+
+	return nil
+}
@@ -0,0 +1,377 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sso/internal/domain/models"
+	"sso/internal/lib/jwt"
+	"sso/internal/lib/logger/sl"
+	"sso/internal/lib/opaque"
+	"sso/internal/lib/totp"
+	"sso/internal/storage"
+	"strings"
+	"time"
+)
+
+const (
+	recoveryCodesCount = 10
+	// maxMFAAttempts is how many wrong codes are accepted per MFA challenge,
+	// after that user has to log in with password again.
+	maxMFAAttempts = 5
+)
+
+var recoveryCodeEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// EnrollTOTP generates new TOTP secret for user and returns it with otpauth:// URI for authenticator apps.
+// Two-factor authentication is enabled only after ConfirmTOTP.
+func (a *Auth) EnrollTOTP(ctx context.Context, userID int64) (secret string, uri string, err error) {
+	const op = "Auth.EnrollTOTP"
+
+	log := a.log.With(
+		slog.String("op", op),
+		slog.Int64("uid", userID),
+	)
+
+	user, err := a.usrProvider.UserByID(ctx, userID)
+	if err != nil {
+		log.Error("failed to get user", sl.Err(err))
+
+		return "", "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	if user.MFAEnabled {
+		return "", "", fmt.Errorf("%s: %w", op, ErrMFAAlreadyEnabled)
+	}
+
+	secret, err = totp.GenerateSecret()
+	if err != nil {
+		return "", "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := a.mfaStorage.SaveTOTPSecret(ctx, userID, secret); err != nil {
+		log.Error("failed to save totp secret", sl.Err(err))
+
+		return "", "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("totp enrollment started")
+
+	return secret, totp.URI(a.cfg.MFA.Issuer, user.Email, secret), nil
+}
+
+// ConfirmTOTP enables two-factor authentication once user proves the authenticator app is set up,
+// and returns recovery codes. Recovery codes are shown only once, only their hashes are stored.
+// Wrong codes are throttled like failed logins of the account, *throttle.BlockedError is returned then.
+func (a *Auth) ConfirmTOTP(ctx context.Context, userID int64, code string, client models.Client) ([]string, error) {
+	const op = "Auth.ConfirmTOTP"
+
+	log := a.log.With(
+		slog.String("op", op),
+		slog.Int64("uid", userID),
+		slog.String("ip", client.IP),
+	)
+
+	user, err := a.usrProvider.UserByID(ctx, userID)
+	if err != nil {
+		log.Error("failed to get user", sl.Err(err))
+
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	settings, err := a.mfaStorage.TOTP(ctx, userID)
+	if err != nil {
+		if errors.Is(err, storage.ErrTOTPNotFound) {
+			return nil, fmt.Errorf("%s: %w", op, ErrMFANotEnabled)
+		}
+
+		log.Error("failed to get totp", sl.Err(err))
+
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if settings.Confirmed {
+		return nil, fmt.Errorf("%s: %w", op, ErrMFAAlreadyEnabled)
+	}
+
+	if err := a.loginLimiter.Allow(ctx, user.Email, client.IP); err != nil {
+		log.Warn("totp confirmation refused", sl.Err(err))
+
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := a.useTOTPCode(ctx, settings, code); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	a.loginSucceeded(ctx, log, user.Email, client)
+
+	codes := make([]string, recoveryCodesCount)
+	hashes := make([]string, recoveryCodesCount)
+	for i := range codes {
+		codes[i], err = newRecoveryCode()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		hashes[i] = opaque.Hash(normalizeRecoveryCode(codes[i]))
+	}
+
+	if err := a.mfaStorage.ConfirmTOTP(ctx, userID, hashes); err != nil {
+		log.Error("failed to confirm totp", sl.Err(err))
+
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("two-factor authentication enabled")
+
+	return codes, nil
+}
+
+// DisableTOTP turns two-factor authentication off, it requires a valid TOTP or recovery code.
+// Wrong codes are throttled like failed logins of the account, *throttle.BlockedError is returned then.
+func (a *Auth) DisableTOTP(ctx context.Context, userID int64, code string, client models.Client) error {
+	const op = "Auth.DisableTOTP"
+
+	log := a.log.With(
+		slog.String("op", op),
+		slog.Int64("uid", userID),
+		slog.String("ip", client.IP),
+	)
+
+	user, err := a.usrProvider.UserByID(ctx, userID)
+	if err != nil {
+		log.Error("failed to get user", sl.Err(err))
+
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := a.loginLimiter.Allow(ctx, user.Email, client.IP); err != nil {
+		log.Warn("totp disabling refused", sl.Err(err))
+
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := a.verifySecondFactor(ctx, userID, code); err != nil {
+		if errors.Is(err, ErrInvalidMFACode) {
+			log.Info("invalid second factor code")
+		}
+
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	a.loginSucceeded(ctx, log, user.Email, client)
+
+	if err := a.mfaStorage.DeleteTOTP(ctx, userID); err != nil {
+		log.Error("failed to delete totp", sl.Err(err))
+
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("two-factor authentication disabled")
+
+	return nil
+}
+
+// VerifyMFA exchanges MFA challenge token returned by Login and a TOTP or recovery code for tokens.
+// Challenge is single-use and is invalidated after maxMFAAttempts wrong codes,
+// wrong codes are also recorded as failed logins of the account.
+// Login session is recorded for the given client.
+func (a *Auth) VerifyMFA(
+	ctx context.Context,
+	mfaToken string,
+	code string,
+	client models.Client,
+) (models.TokenPair, error) {
+	const op = "Auth.VerifyMFA"
+
+	log := a.log.With(slog.String("op", op))
+
+	claims, err := a.parseToken(ctx, mfaToken)
+	if err != nil || claims.Type != jwt.TypeMFA {
+		log.Info("invalid mfa token")
+
+		return models.TokenPair{}, fmt.Errorf("%s: %w", op, ErrInvalidMFAToken)
+	}
+
+	log = log.With(slog.Int64("uid", claims.UID))
+
+	revoked, err := a.tokenStorage.IsTokenRevoked(ctx, claims.ID)
+	if err != nil {
+		return models.TokenPair{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if revoked {
+		log.Info("mfa token already used")
+
+		return models.TokenPair{}, fmt.Errorf("%s: %w", op, ErrInvalidMFAToken)
+	}
+
+	if err := a.loginLimiter.Allow(ctx, claims.Email, client.IP); err != nil {
+		log.Warn("second factor attempt refused", sl.Err(err))
+
+		return models.TokenPair{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	// Attempt is counted before the code is checked, so concurrent requests can't exceed the limit.
+	attempts := a.mfaAttempts.Update(claims.ID, time.Until(claims.ExpiresAt), func(attempts int, _ bool) int {
+		return attempts + 1
+	})
+	if attempts > maxMFAAttempts {
+		log.Info("mfa attempts exhausted")
+
+		return models.TokenPair{}, fmt.Errorf("%s: %w", op, ErrInvalidMFAToken)
+	}
+
+	if err := a.verifySecondFactor(ctx, claims.UID, code); err != nil {
+		if errors.Is(err, ErrInvalidMFACode) {
+			log.Info("invalid second factor code")
+		}
+
+		return models.TokenPair{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := a.tokenStorage.RevokeToken(ctx, claims.ID, claims.ExpiresAt); err != nil {
+		log.Error("failed to revoke mfa token", sl.Err(err))
+
+		return models.TokenPair{}, fmt.Errorf("%s: %w", op, err)
+	}
+	a.mfaAttempts.Delete(claims.ID)
+	a.loginSucceeded(ctx, log, claims.Email, client)
+
+	user, err := a.usrProvider.UserByID(ctx, claims.UID)
+	if err != nil {
+		if errors.Is(err, storage.ErrUserNotFound) {
+			return models.TokenPair{}, fmt.Errorf("%s: %w", op, ErrInvalidMFAToken)
+		}
+
+		return models.TokenPair{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	tokens, err := a.IssueTokens(ctx, user, claims.AppID, client)
+	if err != nil {
+		return models.TokenPair{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("second factor verified")
+
+	return tokens, nil
+}
+
+// VerifyTOTP checks TOTP or recovery code of user who passed the password check with Authenticate.
+// Wrong codes are recorded as failed logins of the account, so they are throttled and lock the account
+// like wrong passwords do, *throttle.BlockedError is returned then. Failures are reset only when the code is accepted.
+func (a *Auth) VerifyTOTP(ctx context.Context, user models.User, code string, client models.Client) error {
+	const op = "Auth.VerifyTOTP"
+
+	log := a.log.With(
+		slog.String("op", op),
+		slog.Int64("uid", user.ID),
+		slog.String("ip", client.IP),
+	)
+
+	if err := a.loginLimiter.Allow(ctx, user.Email, client.IP); err != nil {
+		log.Warn("second factor attempt refused", sl.Err(err))
+
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := a.verifySecondFactor(ctx, user.ID, code); err != nil {
+		if errors.Is(err, ErrInvalidMFACode) {
+			log.Info("invalid second factor code")
+		}
+
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	a.loginSucceeded(ctx, log, user.Email, client)
+
+	return nil
+}
+
+// newMFAChallenge returns short-lived token proving that user passed password check for the app.
+func (a *Auth) newMFAChallenge(ctx context.Context, user models.User, appID int) (string, error) {
+	app, err := a.app(ctx, appID)
+	if err != nil {
+		if errors.Is(err, storage.ErrAppNotFound) {
+			return "", ErrInvalidAppID
+		}
+
+		return "", err
+	}
+
+	key, err := a.keyProvider.SigningKey(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	return jwt.NewMFAToken(user, app, key, a.cfg.MFA.ChallengeTTL)
+}
+
+// verifySecondFactor accepts either current TOTP code or one of unused recovery codes.
+func (a *Auth) verifySecondFactor(ctx context.Context, userID int64, code string) error {
+	settings, err := a.mfaStorage.TOTP(ctx, userID)
+	if err != nil {
+		if errors.Is(err, storage.ErrTOTPNotFound) {
+			return ErrMFANotEnabled
+		}
+
+		return err
+	}
+
+	if !settings.Confirmed {
+		return ErrMFANotEnabled
+	}
+
+	if len(code) == totp.Digits {
+		return a.useTOTPCode(ctx, settings, code)
+	}
+
+	err = a.mfaStorage.UseRecoveryCode(ctx, userID, opaque.Hash(normalizeRecoveryCode(code)))
+	if err != nil {
+		if errors.Is(err, storage.ErrRecoveryCodeNotFound) {
+			return ErrInvalidMFACode
+		}
+
+		return err
+	}
+
+	a.log.Info("recovery code used", slog.Int64("uid", userID))
+
+	return nil
+}
+
+// useTOTPCode validates code and records its time step, so the same code can't be replayed.
+func (a *Auth) useTOTPCode(ctx context.Context, settings models.TOTP, code string) error {
+	step, ok := totp.Validate(settings.Secret, code, time.Now())
+	if !ok || step <= settings.LastStep {
+		return ErrInvalidMFACode
+	}
+
+	if err := a.mfaStorage.UseTOTPStep(ctx, settings.UserID, step); err != nil {
+		if errors.Is(err, storage.ErrTOTPStepUsed) {
+			return ErrInvalidMFACode
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+// newRecoveryCode returns random code formatted as xxxxx-xxxxx.
+func newRecoveryCode() (string, error) {
+	b := make([]byte, 10)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	code := strings.ToLower(recoveryCodeEncoding.EncodeToString(b))[:10]
+
+	return code[:5] + "-" + code[5:], nil
+}
+
+// normalizeRecoveryCode makes codes typed with different case or without dash match.
+func normalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+}
@@ -0,0 +1,128 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"golang.org/x/crypto/bcrypt"
+	"log/slog"
+	"sso/internal/domain/models"
+	"sso/internal/lib/logger/sl"
+	"sso/internal/lib/mail"
+	"sso/internal/lib/opaque"
+	"sso/internal/storage"
+	"time"
+)
+
+// RequestPasswordReset mails a single-use password reset link, previously sent links stop working.
+// To not disclose which emails are registered, unknown emails are silently ignored.
+func (a *Auth) RequestPasswordReset(ctx context.Context, email string) error {
+	const op = "Auth.RequestPasswordReset"
+
+	log := a.log.With(
+		slog.String("op", op),
+		slog.String("email", email),
+	)
+
+	user, err := a.usrProvider.User(ctx, email)
+	if err != nil {
+		if errors.Is(err, storage.ErrUserNotFound) {
+			log.Info("user not found")
+
+			return nil
+		}
+
+		log.Error("failed to get user", sl.Err(err))
+
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := a.emailTokenStorage.InvalidateEmailTokens(ctx, user.ID, models.EmailTokenPasswordReset); err != nil {
+		log.Error("failed to invalidate reset codes", sl.Err(err))
+
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	link, err := a.newEmailLink(ctx, user.ID, user.Email, models.EmailTokenPasswordReset, a.cfg.PasswordReset.TokenTTL, a.cfg.PasswordReset.URL)
+	if err != nil {
+		log.Error("failed to create reset code", sl.Err(err))
+
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	err = a.mailer.Send(ctx, mail.Message{
+		To:      user.Email,
+		Subject: "Восстановление пароля",
+		Body: fmt.Sprintf(
+			"Здравствуйте!\n\nЧтобы задать новый пароль, перейдите по ссылке:\n%s\n\n"+
+				"Если вы не запрашивали восстановление пароля, просто проигнорируйте это письмо.\n",
+			link,
+		),
+	})
+	if err != nil {
+		log.Error("failed to send reset email", sl.Err(err))
+
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("password reset requested")
+
+	return nil
+}
+
+// ResetPassword sets a new password by reset code sent to user email.
+// All refresh tokens and access tokens issued before the reset are revoked.
+func (a *Auth) ResetPassword(ctx context.Context, code string, newPassword string) error {
+	const op = "Auth.ResetPassword"
+
+	log := a.log.With(slog.String("op", op))
+
+	if newPassword == "" {
+		return fmt.Errorf("%s: %w", op, ErrInvalidPassword)
+	}
+
+	stored, err := a.emailTokenStorage.UseEmailToken(ctx, opaque.Hash(code), models.EmailTokenPasswordReset)
+	if err != nil {
+		if errors.Is(err, storage.ErrEmailTokenNotFound) {
+			log.Info("reset code not found")
+
+			return fmt.Errorf("%s: %w", op, ErrInvalidEmailToken)
+		}
+
+		log.Error("failed to use reset code", sl.Err(err))
+
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log = log.With(slog.Int64("uid", stored.UserID))
+
+	if time.Now().After(stored.ExpiresAt) {
+		log.Info("reset code expired")
+
+		return fmt.Errorf("%s: %w", op, ErrInvalidEmailToken)
+	}
+
+	passHash, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
+	if err != nil {
+		log.Error("failed to generate password hash", sl.Err(err))
+
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	revokedAt := time.Now()
+
+	if err := a.usrSaver.ResetPassword(ctx, stored.UserID, passHash, revokedAt); err != nil {
+		if errors.Is(err, storage.ErrUserNotFound) {
+			return fmt.Errorf("%s: %w", op, ErrInvalidEmailToken)
+		}
+
+		log.Error("failed to reset password", sl.Err(err))
+
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	a.tokensRevokedAt.Set(stored.UserID, revokedAt, a.cfg.TokenTTL)
+
+	log.Info("password reset")
+
+	return nil
+}
@@ -0,0 +1,101 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sso/internal/lib/logger/sl"
+	"time"
+)
+
+// HasPermission reports whether any of the roles grants permission.
+// Permissions of roles are cached for RevocationCacheTTL.
+func (a *Auth) HasPermission(ctx context.Context, roles []string, permission string) (bool, error) {
+	const op = "Auth.HasPermission"
+
+	for _, role := range roles {
+		permissions, ok := a.permissions.Get(role)
+		if !ok {
+			var err error
+
+			permissions, err = a.roleStorage.RolePermissions(ctx, role)
+			if err != nil {
+				return false, fmt.Errorf("%s: %w", op, err)
+			}
+
+			a.permissions.Set(role, permissions, a.cfg.RevocationCacheTTL)
+		}
+
+		if slices.Contains(permissions, permission) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// UserRoles returns roles assigned to user.
+func (a *Auth) UserRoles(ctx context.Context, userID int64) ([]string, error) {
+	const op = "Auth.UserRoles"
+
+	if _, err := a.usrProvider.UserByID(ctx, userID); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	roles, err := a.roleStorage.UserRoles(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return roles, nil
+}
+
+// AssignRole assigns role to user, it is included in tokens issued from now on.
+func (a *Auth) AssignRole(ctx context.Context, userID int64, role string) error {
+	const op = "Auth.AssignRole"
+
+	log := a.log.With(
+		slog.String("op", op),
+		slog.Int64("uid", userID),
+		slog.String("role", role),
+	)
+
+	if err := a.roleStorage.AssignRole(ctx, userID, role); err != nil {
+		log.Warn("failed to assign role", sl.Err(err))
+
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("role assigned")
+
+	return nil
+}
+
+// RevokeRole removes role from user. Access tokens issued before are invalidated,
+// clients have to refresh them and get tokens without the role.
+func (a *Auth) RevokeRole(ctx context.Context, userID int64, role string) error {
+	const op = "Auth.RevokeRole"
+
+	log := a.log.With(
+		slog.String("op", op),
+		slog.Int64("uid", userID),
+		slog.String("role", role),
+	)
+
+	if err := a.roleStorage.RevokeRole(ctx, userID, role); err != nil {
+		log.Warn("failed to revoke role", sl.Err(err))
+
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := a.roleStorage.InvalidateAccessTokens(ctx, userID, time.Now()); err != nil {
+		log.Error("failed to invalidate access tokens", sl.Err(err))
+
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("role revoked")
+
+	return nil
+}
@@ -0,0 +1,50 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sso/internal/domain/models"
+	"sso/internal/lib/logger/sl"
+	"time"
+)
+
+// Sessions returns active login sessions of user, the one with currentSessionID is marked as current.
+// Sessions not used for longer than refresh token lifetime can't be resumed and are not returned.
+func (a *Auth) Sessions(ctx context.Context, userID int64, currentSessionID int64) ([]models.Session, error) {
+	const op = "Auth.Sessions"
+
+	sessions, err := a.sessionStorage.Sessions(ctx, userID, time.Now().Add(-a.cfg.RefreshTokenTTL))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID == currentSessionID
+	}
+
+	return sessions, nil
+}
+
+// RevokeSession ends session of user. Its refresh tokens are revoked
+// and its access tokens are rejected by ValidateToken right away.
+func (a *Auth) RevokeSession(ctx context.Context, userID int64, sessionID int64) error {
+	const op = "Auth.RevokeSession"
+
+	log := a.log.With(
+		slog.String("op", op),
+		slog.Int64("uid", userID),
+		slog.Int64("session_id", sessionID),
+	)
+
+	if err := a.sessionStorage.RevokeSession(ctx, userID, sessionID); err != nil {
+		log.Warn("failed to revoke session", sl.Err(err))
+
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	a.revokedSessions.Set(sessionID, true, a.cfg.TokenTTL)
+
+	log.Info("session revoked")
+
+	return nil
+}
@@ -0,0 +1,109 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"github.com/gorilla/mux"
+	"log/slog"
+	"net/http"
+	"sso/internal/domain/models"
+	"sso/internal/lib/logger/sl"
+	"sso/internal/lib/markdown"
+	"sso/internal/storage"
+	"strconv"
+)
+
+const relatedArticlesLimit = 3
+
+// GetArticleHandler returns published article by id with rendered body.
+func (c *Core) GetArticleHandler(w http.ResponseWriter, r *http.Request) {
+	const op = "core.GetArticleHandler"
+
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid article id", http.StatusBadRequest)
+		return
+	}
+
+	article, err := c.articleProvider.Article(r.Context(), id)
+	if err != nil {
+		writeArticleError(w, op, err)
+		return
+	}
+
+	c.writeArticleDetails(w, r, op, article)
+}
+
+// GetArticleBySlugHandler returns published article by slug with rendered body.
+func (c *Core) GetArticleBySlugHandler(w http.ResponseWriter, r *http.Request) {
+	const op = "core.GetArticleBySlugHandler"
+
+	article, err := c.articleProvider.ArticleBySlug(r.Context(), mux.Vars(r)["slug"])
+	if err != nil {
+		writeArticleError(w, op, err)
+		return
+	}
+
+	c.writeArticleDetails(w, r, op, article)
+}
+
+// writeArticleDetails renders article body, adds related articles and counts the view.
+func (c *Core) writeArticleDetails(w http.ResponseWriter, r *http.Request, op string, article models.ArticleDetails) {
+	log := c.log.With(
+		slog.String("op", op),
+		slog.Int64("article_id", article.ID),
+	)
+
+	if err := renderArticle(&article); err != nil {
+		http.Error(w, fmt.Sprintf("%s: %v", op, err), http.StatusInternalServerError)
+		return
+	}
+
+	var err error
+	article.Related, err = c.articleProvider.RelatedArticles(r.Context(), article.ID, relatedArticlesLimit)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("%s: %v", op, err), http.StatusInternalServerError)
+		return
+	}
+
+	// Failing to count a view must not prevent reading the article.
+	if err := c.articleProvider.IncrementArticleViews(r.Context(), article.ID); err != nil {
+		log.Warn("failed to count article view", sl.Err(err))
+	}
+
+	writeJSON(w, op, article)
+}
+
+// renderArticle fills HTML, reading time and table of contents of article from its body.
+func renderArticle(article *models.ArticleDetails) error {
+	doc, err := markdown.Render(article.Body)
+	if err != nil {
+		return err
+	}
+
+	article.HTML = doc.HTML
+	article.ReadingTimeMinutes = markdown.ReadingMinutes(doc.Words)
+	article.TableOfContents = make([]models.ArticleHeading, 0, len(doc.Headings))
+	for _, h := range doc.Headings {
+		article.TableOfContents = append(article.TableOfContents, models.ArticleHeading{
+			Level:  h.Level,
+			Title:  h.Title,
+			Anchor: h.ID,
+		})
+	}
+
+	if article.Related == nil {
+		article.Related = []models.Article{}
+	}
+
+	return nil
+}
+
+func writeArticleError(w http.ResponseWriter, op string, err error) {
+	if errors.Is(err, storage.ErrArticleNotFound) {
+		http.Error(w, "Article not found", http.StatusNotFound)
+		return
+	}
+
+	http.Error(w, fmt.Sprintf("%s: %v", op, err), http.StatusInternalServerError)
+}
@@ -0,0 +1,154 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/gorilla/mux"
+	"net/http"
+	"slices"
+	"sso/internal/domain/models"
+	"sso/internal/storage"
+	"strconv"
+	"time"
+)
+
+var bookmarkTypes = []string{models.ContentTypeArticle, models.ContentTypeCourse}
+
+// AddBookmarkHandler bookmarks published article or course for the user, it is idempotent.
+func (c *Core) AddBookmarkHandler(w http.ResponseWriter, r *http.Request) {
+	const op = "core.AddBookmarkHandler"
+
+	uid, ok := r.Context().Value("uid").(int64)
+	if !ok {
+		http.Error(w, "UID not found in context", http.StatusInternalServerError)
+		return
+	}
+
+	typ, id, err := parseBookmarkTarget(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err = c.bookmarkManager.AddBookmark(r.Context(), uid, typ, id, time.Now())
+	if err != nil {
+		if errors.Is(err, storage.ErrArticleNotFound) || errors.Is(err, storage.ErrCourseNotFound) {
+			http.Error(w, "Content not found", http.StatusNotFound)
+			return
+		}
+
+		http.Error(w, fmt.Sprintf("%s: %v", op, err), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RemoveBookmarkHandler removes bookmark of article or course, it is idempotent.
+func (c *Core) RemoveBookmarkHandler(w http.ResponseWriter, r *http.Request) {
+	const op = "core.RemoveBookmarkHandler"
+
+	uid, ok := r.Context().Value("uid").(int64)
+	if !ok {
+		http.Error(w, "UID not found in context", http.StatusInternalServerError)
+		return
+	}
+
+	typ, id, err := parseBookmarkTarget(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := c.bookmarkManager.RemoveBookmark(r.Context(), uid, typ, id); err != nil {
+		http.Error(w, fmt.Sprintf("%s: %v", op, err), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetBookmarksHandler returns a page of content bookmarked by the user, the latest bookmarks first.
+// The type parameter limits bookmarks to articles or courses.
+func (c *Core) GetBookmarksHandler(w http.ResponseWriter, r *http.Request) {
+	const op = "core.GetBookmarksHandler"
+
+	uid, ok := r.Context().Value("uid").(int64)
+	if !ok {
+		http.Error(w, "UID not found in context", http.StatusInternalServerError)
+		return
+	}
+
+	values := r.URL.Query()
+
+	query := models.BookmarkQuery{
+		UserID: uid,
+		Type:   values.Get("type"),
+		Cursor: values.Get("cursor"),
+	}
+
+	if query.Type != "" && !slices.Contains(bookmarkTypes, query.Type) {
+		http.Error(w, "Type must be article or course", http.StatusBadRequest)
+		return
+	}
+
+	limit, err := parseLimit(values.Get("limit"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	query.Limit = limit
+
+	bookmarks, err := c.bookmarkManager.Bookmarks(r.Context(), query)
+	if err != nil {
+		writeContentError(w, op, err)
+		return
+	}
+
+	writeJSON(w, op, bookmarks)
+}
+
+func parseBookmarkTarget(r *http.Request) (string, int64, error) {
+	vars := mux.Vars(r)
+
+	typ := vars["type"]
+	if !slices.Contains(bookmarkTypes, typ) {
+		return "", 0, errors.New("Type must be article or course")
+	}
+
+	id, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		return "", 0, errors.New("Invalid content id")
+	}
+
+	return typ, id, nil
+}
+
+// markBookmarked turns page of content of type into page of list items telling which of them user bookmarked.
+func markBookmarked[T any, I any](
+	ctx context.Context,
+	bookmarks BookmarkManager,
+	userID int64,
+	contentType string,
+	page models.Page[T],
+	id func(T) int64,
+	item func(T, bool) I,
+) (models.Page[I], error) {
+	ids := make([]int64, 0, len(page.Items))
+	for _, content := range page.Items {
+		ids = append(ids, id(content))
+	}
+
+	bookmarked, err := bookmarks.BookmarkedContent(ctx, userID, contentType, ids)
+	if err != nil {
+		return models.Page[I]{}, err
+	}
+
+	items := make([]I, 0, len(page.Items))
+	for _, content := range page.Items {
+		items = append(items, item(content, bookmarked[id(content)]))
+	}
+
+	return models.Page[I]{Items: items, NextCursor: page.NextCursor}, nil
+}
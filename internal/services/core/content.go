@@ -0,0 +1,79 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"sso/internal/domain/models"
+	"sso/internal/storage"
+	"strconv"
+)
+
+const maxContentLimit = 100
+
+var (
+	contentSorts        = []string{models.ContentSortDate, models.ContentSortPopularity}
+	contentDifficulties = []string{models.DifficultyBeginner, models.DifficultyIntermediate, models.DifficultyAdvanced}
+)
+
+// parseContentQuery reads limit, cursor, sort, tag, author and difficulty parameters of content list request.
+// Returned error is meant to be shown to the client.
+func parseContentQuery(r *http.Request) (models.ContentQuery, error) {
+	values := r.URL.Query()
+
+	query := models.ContentQuery{
+		Cursor:     values.Get("cursor"),
+		Sort:       values.Get("sort"),
+		Tag:        values.Get("tag"),
+		Difficulty: values.Get("difficulty"),
+	}
+
+	limit, err := parseLimit(values.Get("limit"))
+	if err != nil {
+		return models.ContentQuery{}, err
+	}
+	query.Limit = limit
+
+	if query.Sort != "" && !slices.Contains(contentSorts, query.Sort) {
+		return models.ContentQuery{}, errors.New("Sort must be date or popularity")
+	}
+
+	if query.Difficulty != "" && !slices.Contains(contentDifficulties, query.Difficulty) {
+		return models.ContentQuery{}, errors.New("Difficulty must be beginner, intermediate or advanced")
+	}
+
+	if v := values.Get("author"); v != "" {
+		authorID, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || authorID < 1 {
+			return models.ContentQuery{}, errors.New("Invalid author")
+		}
+
+		query.AuthorID = authorID
+	}
+
+	return query, nil
+}
+
+// parseLimit parses page size, empty value means default one.
+func parseLimit(v string) (int, error) {
+	if v == "" {
+		return 0, nil
+	}
+
+	limit, err := strconv.Atoi(v)
+	if err != nil || limit < 1 || limit > maxContentLimit {
+		return 0, fmt.Errorf("Limit must be between 1 and %d", maxContentLimit)
+	}
+
+	return limit, nil
+}
+
+func writeContentError(w http.ResponseWriter, op string, err error) {
+	if errors.Is(err, storage.ErrInvalidCursor) {
+		http.Error(w, "Invalid cursor", http.StatusBadRequest)
+		return
+	}
+
+	http.Error(w, fmt.Sprintf("%s: %v", op, err), http.StatusInternalServerError)
+}
@@ -0,0 +1,946 @@
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/gorilla/mux"
+	"net/http"
+	"net/url"
+	"slices"
+	"sso/internal/domain/models"
+	"sso/internal/storage"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	maxSlugLength        = 100
+	maxContentNameLength = 200
+	maxDescriptionLength = 1000
+	maxURLLength         = 2048
+	maxTags              = 10
+	maxTagLength         = 32
+	// maxArticleBodyLength is in bytes, it also bounds the size of the request.
+	maxArticleBodyLength  = 200 << 10
+	maxContentRequestSize = maxArticleBodyLength + 64<<10
+)
+
+var contentStatuses = []string{
+	models.ContentStatusDraft,
+	models.ContentStatusInReview,
+	models.ContentStatusPublished,
+	models.ContentStatusArchived,
+}
+
+// contentTransitions lists statuses content can move to from each status. Authors send drafts for review,
+// reviewers publish them or send them back, published content is archived and can be drafted again.
+var contentTransitions = map[string][]string{
+	models.ContentStatusDraft:     {models.ContentStatusInReview},
+	models.ContentStatusInReview:  {models.ContentStatusDraft, models.ContentStatusPublished},
+	models.ContentStatusPublished: {models.ContentStatusArchived},
+	models.ContentStatusArchived:  {models.ContentStatusDraft},
+}
+
+var (
+	errPreconditionRequired = errors.New("If-Match header is required")
+	errPreconditionFailed   = errors.New("Content was changed, reload it and try again")
+	errStatusTransition     = errors.New("Status transition is not allowed")
+	errPublishTimePassed    = errors.New("Publish time must be in the future")
+	errPublishDenied        = errors.New("Only editors with content:publish permission can publish, schedule and change published content")
+)
+
+// contentRequest holds fields editors set on articles, courses and feed items.
+// Fields missing from PATCH request keep their values, POST and PUT requests reset them to defaults.
+type contentRequest struct {
+	Slug        *string   `json:"slug"`
+	Name        *string   `json:"name"`
+	Description *string   `json:"description"`
+	Image       *string   `json:"image"`
+	Difficulty  *string   `json:"difficulty"`
+	Tags        *[]string `json:"tags"`
+	Status      *string   `json:"status"`
+	// PublishAt schedules content in review to be published, null cancels the schedule.
+	PublishAt nullableTime `json:"publish_at"`
+}
+
+// nullableTime tells JSON null, which clears the time, apart from a missing field.
+type nullableTime struct {
+	Set  bool
+	Time *time.Time
+}
+
+func (t *nullableTime) UnmarshalJSON(data []byte) error {
+	t.Set = true
+
+	return json.Unmarshal(data, &t.Time)
+}
+
+type articleRequest struct {
+	contentRequest
+	Body *string `json:"body"`
+}
+
+type feedRequest struct {
+	contentRequest
+	Link *string `json:"link"`
+}
+
+// contentFields points to fields of content model editors change, so the same code edits every content type.
+type contentFields struct {
+	slug        *string
+	name        *string
+	description *string
+	image       *string
+	difficulty  *string
+	tags        *[]string
+	status      *string
+	publishedAt **time.Time
+	publishAt   **time.Time
+	updatedAt   *time.Time
+}
+
+func articleFields(a *models.ArticleDetails) contentFields {
+	return contentFields{
+		&a.Slug, &a.Name, &a.Description, &a.Image, &a.Difficulty, &a.Tags, &a.Status,
+		&a.PublishedAt, &a.PublishAt, &a.UpdatedAt,
+	}
+}
+
+func courseFields(c *models.Course) contentFields {
+	return contentFields{
+		&c.Slug, &c.Name, &c.Description, &c.Image, &c.Difficulty, &c.Tags, &c.Status,
+		&c.PublishedAt, &c.PublishAt, &c.UpdatedAt,
+	}
+}
+
+func feedFields(f *models.Feed) contentFields {
+	return contentFields{
+		&f.Slug, &f.Name, &f.Description, &f.Image, &f.Difficulty, &f.Tags, &f.Status,
+		&f.PublishedAt, &f.PublishAt, &f.UpdatedAt,
+	}
+}
+
+// apply sets fields present in the request, with replace the missing ones are reset to defaults.
+func (req contentRequest) apply(f contentFields, replace bool) {
+	setString(f.slug, req.Slug, replace, "")
+	setString(f.name, req.Name, replace, "")
+	setString(f.description, req.Description, replace, "")
+	setString(f.image, req.Image, replace, "")
+	setString(f.difficulty, req.Difficulty, replace, "")
+	setString(f.status, req.Status, replace, models.ContentStatusDraft)
+
+	// Content leaving review is no longer scheduled, unless the request asks for it and fails validation.
+	switch {
+	case req.PublishAt.Set:
+		*f.publishAt = req.PublishAt.Time
+	case replace || *f.status != models.ContentStatusInReview:
+		*f.publishAt = nil
+	}
+
+	switch {
+	case req.Tags != nil:
+		*f.tags = normalizeTags(*req.Tags)
+	case replace:
+		*f.tags = []string{}
+	}
+}
+
+func (req articleRequest) apply(a *models.ArticleDetails, replace bool) {
+	req.contentRequest.apply(articleFields(a), replace)
+
+	// Body is Markdown, leading indentation is meaningful there.
+	switch {
+	case req.Body != nil:
+		a.Body = *req.Body
+	case replace:
+		a.Body = ""
+	}
+}
+
+func (req feedRequest) apply(f *models.Feed, replace bool) {
+	req.contentRequest.apply(feedFields(f), replace)
+	setString(&f.Link, req.Link, replace, "")
+}
+
+func setString(dst *string, v *string, replace bool, def string) {
+	switch {
+	case v != nil:
+		*dst = strings.TrimSpace(*v)
+	case replace:
+		*dst = def
+	}
+}
+
+// normalizeTags lowercases tags and drops duplicates, so "Go" and "go" are the same tag.
+func normalizeTags(tags []string) []string {
+	normalized := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if !slices.Contains(normalized, tag) {
+			normalized = append(normalized, tag)
+		}
+	}
+	slices.Sort(normalized)
+
+	return normalized
+}
+
+// touch marks content updated at now, content published for the first time gets its publish date.
+func (f contentFields) touch(now time.Time) {
+	*f.updatedAt = now
+
+	if *f.status == models.ContentStatusPublished && *f.publishedAt == nil {
+		*f.publishedAt = &now
+	}
+}
+
+// contentState is what editorial workflow checks of a content change look at.
+type contentState struct {
+	status    string
+	publishAt *time.Time
+}
+
+// newContentState is the state new content is created from.
+var newContentState = contentState{status: models.ContentStatusDraft}
+
+func (f contentFields) state() contentState {
+	return contentState{status: *f.status, publishAt: *f.publishAt}
+}
+
+// checkWorkflow checks that content may move from state to its new fields at now.
+// Publishing content, scheduling it and changing published content takes content:publish permission,
+// other changes only content:write.
+func (c *Core) checkWorkflow(r *http.Request, from contentState, f contentFields, now time.Time) error {
+	to := f.state()
+
+	if from.status != to.status && !slices.Contains(contentTransitions[from.status], to.status) {
+		return fmt.Errorf("%w from %s to %s", errStatusTransition, from.status, to.status)
+	}
+
+	rescheduled := to.publishAt != nil && (from.publishAt == nil || !from.publishAt.Equal(*to.publishAt))
+	if rescheduled && !to.publishAt.After(now) {
+		return errPublishTimePassed
+	}
+
+	if to.status == models.ContentStatusPublished || rescheduled {
+		return c.checkPublish(r)
+	}
+
+	return c.checkPublishedEdit(r, from.status)
+}
+
+// checkPublishedEdit checks that the user may change content of status, readers see published content at once,
+// so its changes take content:publish permission. Other content is written to with content:write.
+func (c *Core) checkPublishedEdit(r *http.Request, status string) error {
+	if status != models.ContentStatusPublished {
+		return nil
+	}
+
+	return c.checkPublish(r)
+}
+
+func (c *Core) checkPublish(r *http.Request) error {
+	roles, _ := r.Context().Value("roles").([]string)
+
+	allowed, err := c.permissionChecker.HasPermission(r.Context(), roles, models.PermissionContentPublish)
+	if err != nil {
+		return err
+	}
+
+	if !allowed {
+		return errPublishDenied
+	}
+
+	return nil
+}
+
+// validate returns error meant to be shown to the client if content fields are invalid.
+func (f contentFields) validate() error {
+	if *f.slug == "" {
+		return errors.New("Slug is required")
+	}
+
+	if len(*f.slug) > maxSlugLength || !isSlug(*f.slug) {
+		return fmt.Errorf(
+			"Slug must be at most %d lowercase latin letters, digits and single hyphens between them",
+			maxSlugLength,
+		)
+	}
+
+	if err := validateName("Name", *f.name); err != nil {
+		return err
+	}
+
+	if err := validateDescription(*f.description); err != nil {
+		return err
+	}
+
+	if !isContentURL(*f.image) {
+		return errors.New("Image must be an http(s) URL or an absolute path")
+	}
+
+	if *f.difficulty != "" && !slices.Contains(contentDifficulties, *f.difficulty) {
+		return errors.New("Difficulty must be beginner, intermediate or advanced")
+	}
+
+	if len(*f.tags) > maxTags {
+		return fmt.Errorf("At most %d tags are allowed", maxTags)
+	}
+
+	for _, tag := range *f.tags {
+		if tag == "" || utf8.RuneCountInString(tag) > maxTagLength || strings.ContainsFunc(tag, func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '-'
+		}) {
+			return fmt.Errorf("Tags must be 1 to %d letters, digits and hyphens", maxTagLength)
+		}
+	}
+
+	if !slices.Contains(contentStatuses, *f.status) {
+		return errors.New("Status must be draft, in_review, published or archived")
+	}
+
+	if *f.publishAt != nil && *f.status != models.ContentStatusInReview {
+		return errors.New("Only content in review can be scheduled")
+	}
+
+	return nil
+}
+
+// validateName checks a single line field like name of content or title of a lesson.
+func validateName(field string, name string) error {
+	if name == "" {
+		return fmt.Errorf("%s is required", field)
+	}
+
+	if utf8.RuneCountInString(name) > maxContentNameLength {
+		return fmt.Errorf("%s must be at most %d characters", field, maxContentNameLength)
+	}
+
+	if strings.ContainsFunc(name, unicode.IsControl) {
+		return fmt.Errorf("%s must not contain control characters", field)
+	}
+
+	return nil
+}
+
+func validateDescription(description string) error {
+	if utf8.RuneCountInString(description) > maxDescriptionLength {
+		return fmt.Errorf("Description must be at most %d characters", maxDescriptionLength)
+	}
+
+	if strings.ContainsFunc(description, func(r rune) bool { return unicode.IsControl(r) && r != '\n' }) {
+		return errors.New("Description must not contain control characters other than line breaks")
+	}
+
+	return nil
+}
+
+func validateArticle(article *models.ArticleDetails) error {
+	if err := articleFields(article).validate(); err != nil {
+		return err
+	}
+
+	if len(article.Body) > maxArticleBodyLength {
+		return fmt.Errorf("Body must be at most %d KB", maxArticleBodyLength>>10)
+	}
+
+	return nil
+}
+
+func validateFeed(feed *models.Feed) error {
+	if err := feedFields(feed).validate(); err != nil {
+		return err
+	}
+
+	if !isContentURL(feed.Link) {
+		return errors.New("Link must be an http(s) URL or an absolute path")
+	}
+
+	return nil
+}
+
+func isSlug(s string) bool {
+	for _, part := range strings.Split(s, "-") {
+		if part == "" || strings.ContainsFunc(part, func(r rune) bool {
+			return (r < 'a' || r > 'z') && (r < '0' || r > '9')
+		}) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// isContentURL reports whether s is empty, an absolute http(s) URL or a path on this site.
+func isContentURL(s string) bool {
+	if s == "" {
+		return true
+	}
+
+	if len(s) > maxURLLength {
+		return false
+	}
+
+	u, err := url.Parse(s)
+	if err != nil {
+		return false
+	}
+
+	if u.Scheme == "" && u.Host == "" {
+		return strings.HasPrefix(s, "/") && !strings.HasPrefix(s, "//")
+	}
+
+	return (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// decodeContentRequest decodes JSON body of content write request, unknown fields are rejected.
+func decodeContentRequest(w http.ResponseWriter, r *http.Request, req any) error {
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxContentRequestSize))
+	decoder.DisallowUnknownFields()
+
+	return decoder.Decode(req)
+}
+
+// ifMatchVersion returns content version from If-Match header, edits are rejected without it,
+// so a client can't overwrite changes it hasn't seen.
+func ifMatchVersion(r *http.Request) (int64, error) {
+	v := r.Header.Get("If-Match")
+	if v == "" {
+		return 0, errPreconditionRequired
+	}
+
+	unquoted, err := strconv.Unquote(v)
+	if err != nil || !strings.HasPrefix(v, `"`) {
+		return 0, errPreconditionFailed
+	}
+
+	version, err := strconv.ParseInt(unquoted, 10, 64)
+	if err != nil {
+		return 0, errPreconditionFailed
+	}
+
+	return version, nil
+}
+
+func contentETag(version int64) string {
+	return strconv.Quote(strconv.FormatInt(version, 10))
+}
+
+func parseContentID(r *http.Request) (int64, error) {
+	return strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+}
+
+// writeContentVersion writes content with its version as ETag.
+func writeContentVersion(w http.ResponseWriter, op string, status int, version int64, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", contentETag(version))
+	w.WriteHeader(status)
+
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		http.Error(w, fmt.Sprintf("%s: %v", op, err), http.StatusInternalServerError)
+		return
+	}
+}
+
+// writeContentEditError writes error of content write request, notFound is the storage error
+// returned for missing content of the edited type.
+func writeContentEditError(w http.ResponseWriter, op string, err error, notFound error) {
+	switch {
+	case errors.Is(err, notFound):
+		http.Error(w, "Content not found", http.StatusNotFound)
+	case errors.Is(err, errPreconditionRequired):
+		http.Error(w, err.Error(), http.StatusPreconditionRequired)
+	case errors.Is(err, errPreconditionFailed), errors.Is(err, storage.ErrVersionConflict):
+		http.Error(w, errPreconditionFailed.Error(), http.StatusPreconditionFailed)
+	case errors.Is(err, errPublishTimePassed):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, errStatusTransition):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, errPublishDenied):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case errors.Is(err, storage.ErrSlugTaken):
+		http.Error(w, "Slug already taken", http.StatusConflict)
+	default:
+		http.Error(w, fmt.Sprintf("%s: %v", op, err), http.StatusInternalServerError)
+	}
+}
+
+// CreateArticleHandler creates article authored by the user, it is a draft unless status says it is in review.
+func (c *Core) CreateArticleHandler(w http.ResponseWriter, r *http.Request) {
+	const op = "core.CreateArticleHandler"
+
+	uid, ok := r.Context().Value("uid").(int64)
+	if !ok {
+		http.Error(w, "UID not found in context", http.StatusInternalServerError)
+		return
+	}
+
+	var req articleRequest
+	if err := decodeContentRequest(w, r, &req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	var article models.ArticleDetails
+	req.apply(&article, true)
+
+	if err := validateArticle(&article); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	now := time.Now()
+	if err := c.checkWorkflow(r, newContentState, articleFields(&article), now); err != nil {
+		writeContentEditError(w, op, err, storage.ErrArticleNotFound)
+		return
+	}
+
+	article.AuthorID = &uid
+	article.UpdatedBy = &uid
+	article.CreatedAt = now
+	articleFields(&article).touch(now)
+
+	created, err := c.articleEditor.CreateArticle(r.Context(), article)
+	if err != nil {
+		writeContentEditError(w, op, err, storage.ErrArticleNotFound)
+		return
+	}
+
+	if err := renderArticle(&created); err != nil {
+		http.Error(w, fmt.Sprintf("%s: %v", op, err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Location", fmt.Sprintf("/article/%d", created.ID))
+	writeContentVersion(w, op, http.StatusCreated, created.Version, created)
+}
+
+// ReplaceArticleHandler replaces editable fields of article, missing fields are reset.
+func (c *Core) ReplaceArticleHandler(w http.ResponseWriter, r *http.Request) {
+	c.updateArticle(w, r, "core.ReplaceArticleHandler", true)
+}
+
+// PatchArticleHandler changes fields of article present in the request.
+func (c *Core) PatchArticleHandler(w http.ResponseWriter, r *http.Request) {
+	c.updateArticle(w, r, "core.PatchArticleHandler", false)
+}
+
+func (c *Core) updateArticle(w http.ResponseWriter, r *http.Request, op string, replace bool) {
+	uid, ok := r.Context().Value("uid").(int64)
+	if !ok {
+		http.Error(w, "UID not found in context", http.StatusInternalServerError)
+		return
+	}
+
+	id, err := parseContentID(r)
+	if err != nil {
+		http.Error(w, "Invalid article id", http.StatusBadRequest)
+		return
+	}
+
+	version, err := ifMatchVersion(r)
+	if err != nil {
+		writeContentEditError(w, op, err, storage.ErrArticleNotFound)
+		return
+	}
+
+	var req articleRequest
+	if err := decodeContentRequest(w, r, &req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	article, err := c.articleEditor.EditableArticle(r.Context(), id)
+	if err != nil {
+		writeContentEditError(w, op, err, storage.ErrArticleNotFound)
+		return
+	}
+
+	if article.Version != version {
+		w.Header().Set("ETag", contentETag(article.Version))
+		writeContentEditError(w, op, errPreconditionFailed, storage.ErrArticleNotFound)
+		return
+	}
+
+	from := articleFields(&article).state()
+	req.apply(&article, replace)
+
+	if err := validateArticle(&article); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	now := time.Now()
+	if err := c.checkWorkflow(r, from, articleFields(&article), now); err != nil {
+		writeContentEditError(w, op, err, storage.ErrArticleNotFound)
+		return
+	}
+
+	article.UpdatedBy = &uid
+	articleFields(&article).touch(now)
+
+	updated, err := c.articleEditor.UpdateArticle(r.Context(), article)
+	if err != nil {
+		writeContentEditError(w, op, err, storage.ErrArticleNotFound)
+		return
+	}
+
+	if err := renderArticle(&updated); err != nil {
+		http.Error(w, fmt.Sprintf("%s: %v", op, err), http.StatusInternalServerError)
+		return
+	}
+
+	writeContentVersion(w, op, http.StatusOK, updated.Version, updated)
+}
+
+// DeleteArticleHandler deletes article, its row is kept so it can be restored.
+func (c *Core) DeleteArticleHandler(w http.ResponseWriter, r *http.Request) {
+	const op = "core.DeleteArticleHandler"
+
+	id, err := parseContentID(r)
+	if err != nil {
+		http.Error(w, "Invalid article id", http.StatusBadRequest)
+		return
+	}
+
+	version, err := ifMatchVersion(r)
+	if err == nil {
+		err = c.articleEditor.DeleteArticle(r.Context(), id, version, time.Now())
+	}
+	if err != nil {
+		writeContentEditError(w, op, err, storage.ErrArticleNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// CreateCourseHandler creates course authored by the user, it is a draft unless status says it is in review.
+func (c *Core) CreateCourseHandler(w http.ResponseWriter, r *http.Request) {
+	const op = "core.CreateCourseHandler"
+
+	uid, ok := r.Context().Value("uid").(int64)
+	if !ok {
+		http.Error(w, "UID not found in context", http.StatusInternalServerError)
+		return
+	}
+
+	var req contentRequest
+	if err := decodeContentRequest(w, r, &req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	var course models.Course
+	req.apply(courseFields(&course), true)
+
+	if err := courseFields(&course).validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	now := time.Now()
+	if err := c.checkWorkflow(r, newContentState, courseFields(&course), now); err != nil {
+		writeContentEditError(w, op, err, storage.ErrCourseNotFound)
+		return
+	}
+
+	course.AuthorID = &uid
+	course.CreatedAt = now
+	courseFields(&course).touch(now)
+
+	created, err := c.courseEditor.CreateCourse(r.Context(), course)
+	if err != nil {
+		writeContentEditError(w, op, err, storage.ErrCourseNotFound)
+		return
+	}
+
+	w.Header().Set("Location", fmt.Sprintf("/course/%d", created.ID))
+	writeContentVersion(w, op, http.StatusCreated, created.Version, created)
+}
+
+// ReplaceCourseHandler replaces editable fields of course, missing fields are reset.
+func (c *Core) ReplaceCourseHandler(w http.ResponseWriter, r *http.Request) {
+	c.updateCourse(w, r, "core.ReplaceCourseHandler", true)
+}
+
+// PatchCourseHandler changes fields of course present in the request.
+func (c *Core) PatchCourseHandler(w http.ResponseWriter, r *http.Request) {
+	c.updateCourse(w, r, "core.PatchCourseHandler", false)
+}
+
+func (c *Core) updateCourse(w http.ResponseWriter, r *http.Request, op string, replace bool) {
+	id, err := parseContentID(r)
+	if err != nil {
+		http.Error(w, "Invalid course id", http.StatusBadRequest)
+		return
+	}
+
+	version, err := ifMatchVersion(r)
+	if err != nil {
+		writeContentEditError(w, op, err, storage.ErrCourseNotFound)
+		return
+	}
+
+	var req contentRequest
+	if err := decodeContentRequest(w, r, &req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	course, err := c.courseEditor.EditableCourse(r.Context(), id)
+	if err != nil {
+		writeContentEditError(w, op, err, storage.ErrCourseNotFound)
+		return
+	}
+
+	if course.Version != version {
+		w.Header().Set("ETag", contentETag(course.Version))
+		writeContentEditError(w, op, errPreconditionFailed, storage.ErrCourseNotFound)
+		return
+	}
+
+	from := courseFields(&course).state()
+	req.apply(courseFields(&course), replace)
+
+	if err := courseFields(&course).validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	now := time.Now()
+	if err := c.checkWorkflow(r, from, courseFields(&course), now); err != nil {
+		writeContentEditError(w, op, err, storage.ErrCourseNotFound)
+		return
+	}
+
+	courseFields(&course).touch(now)
+
+	updated, err := c.courseEditor.UpdateCourse(r.Context(), course)
+	if err != nil {
+		writeContentEditError(w, op, err, storage.ErrCourseNotFound)
+		return
+	}
+
+	writeContentVersion(w, op, http.StatusOK, updated.Version, updated)
+}
+
+// DeleteCourseHandler deletes course, its row, modules and progress of students are kept so it can be restored.
+func (c *Core) DeleteCourseHandler(w http.ResponseWriter, r *http.Request) {
+	const op = "core.DeleteCourseHandler"
+
+	id, err := parseContentID(r)
+	if err != nil {
+		http.Error(w, "Invalid course id", http.StatusBadRequest)
+		return
+	}
+
+	version, err := ifMatchVersion(r)
+	if err == nil {
+		err = c.courseEditor.DeleteCourse(r.Context(), id, version, time.Now())
+	}
+	if err != nil {
+		writeContentEditError(w, op, err, storage.ErrCourseNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// CreateFeedHandler creates feed item authored by the user, it is a draft unless status says it is in review.
+func (c *Core) CreateFeedHandler(w http.ResponseWriter, r *http.Request) {
+	const op = "core.CreateFeedHandler"
+
+	uid, ok := r.Context().Value("uid").(int64)
+	if !ok {
+		http.Error(w, "UID not found in context", http.StatusInternalServerError)
+		return
+	}
+
+	var req feedRequest
+	if err := decodeContentRequest(w, r, &req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	var feed models.Feed
+	req.apply(&feed, true)
+
+	if err := validateFeed(&feed); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	now := time.Now()
+	if err := c.checkWorkflow(r, newContentState, feedFields(&feed), now); err != nil {
+		writeContentEditError(w, op, err, storage.ErrFeedNotFound)
+		return
+	}
+
+	feed.AuthorID = &uid
+	feed.CreatedAt = now
+	feedFields(&feed).touch(now)
+
+	created, err := c.feedEditor.CreateFeed(r.Context(), feed)
+	if err != nil {
+		writeContentEditError(w, op, err, storage.ErrFeedNotFound)
+		return
+	}
+
+	w.Header().Set("Location", fmt.Sprintf("/feed/%d", created.ID))
+	writeContentVersion(w, op, http.StatusCreated, created.Version, created)
+}
+
+// ReplaceFeedHandler replaces editable fields of feed item, missing fields are reset.
+func (c *Core) ReplaceFeedHandler(w http.ResponseWriter, r *http.Request) {
+	c.updateFeed(w, r, "core.ReplaceFeedHandler", true)
+}
+
+// PatchFeedHandler changes fields of feed item present in the request.
+func (c *Core) PatchFeedHandler(w http.ResponseWriter, r *http.Request) {
+	c.updateFeed(w, r, "core.PatchFeedHandler", false)
+}
+
+func (c *Core) updateFeed(w http.ResponseWriter, r *http.Request, op string, replace bool) {
+	id, err := parseContentID(r)
+	if err != nil {
+		http.Error(w, "Invalid feed item id", http.StatusBadRequest)
+		return
+	}
+
+	version, err := ifMatchVersion(r)
+	if err != nil {
+		writeContentEditError(w, op, err, storage.ErrFeedNotFound)
+		return
+	}
+
+	var req feedRequest
+	if err := decodeContentRequest(w, r, &req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	feed, err := c.feedEditor.EditableFeed(r.Context(), id)
+	if err != nil {
+		writeContentEditError(w, op, err, storage.ErrFeedNotFound)
+		return
+	}
+
+	if feed.Version != version {
+		w.Header().Set("ETag", contentETag(feed.Version))
+		writeContentEditError(w, op, errPreconditionFailed, storage.ErrFeedNotFound)
+		return
+	}
+
+	from := feedFields(&feed).state()
+	req.apply(&feed, replace)
+
+	if err := validateFeed(&feed); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	now := time.Now()
+	if err := c.checkWorkflow(r, from, feedFields(&feed), now); err != nil {
+		writeContentEditError(w, op, err, storage.ErrFeedNotFound)
+		return
+	}
+
+	feedFields(&feed).touch(now)
+
+	updated, err := c.feedEditor.UpdateFeed(r.Context(), feed)
+	if err != nil {
+		writeContentEditError(w, op, err, storage.ErrFeedNotFound)
+		return
+	}
+
+	writeContentVersion(w, op, http.StatusOK, updated.Version, updated)
+}
+
+// DeleteFeedHandler deletes feed item, its row is kept so it can be restored.
+func (c *Core) DeleteFeedHandler(w http.ResponseWriter, r *http.Request) {
+	const op = "core.DeleteFeedHandler"
+
+	id, err := parseContentID(r)
+	if err != nil {
+		http.Error(w, "Invalid feed item id", http.StatusBadRequest)
+		return
+	}
+
+	version, err := ifMatchVersion(r)
+	if err == nil {
+		err = c.feedEditor.DeleteFeed(r.Context(), id, version, time.Now())
+	}
+	if err != nil {
+		writeContentEditError(w, op, err, storage.ErrFeedNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// PreviewArticleHandler returns article of any status rendered as readers will see it once published.
+func (c *Core) PreviewArticleHandler(w http.ResponseWriter, r *http.Request) {
+	const op = "core.PreviewArticleHandler"
+
+	id, err := parseContentID(r)
+	if err != nil {
+		http.Error(w, "Invalid article id", http.StatusBadRequest)
+		return
+	}
+
+	article, err := c.articleEditor.EditableArticle(r.Context(), id)
+	if err != nil {
+		writeContentEditError(w, op, err, storage.ErrArticleNotFound)
+		return
+	}
+
+	if err := renderArticle(&article); err != nil {
+		http.Error(w, fmt.Sprintf("%s: %v", op, err), http.StatusInternalServerError)
+		return
+	}
+
+	writeContentVersion(w, op, http.StatusOK, article.Version, article)
+}
+
+// PreviewCourseHandler returns course of any status with its modules and lessons.
+func (c *Core) PreviewCourseHandler(w http.ResponseWriter, r *http.Request) {
+	const op = "core.PreviewCourseHandler"
+
+	id, err := parseContentID(r)
+	if err != nil {
+		http.Error(w, "Invalid course id", http.StatusBadRequest)
+		return
+	}
+
+	course, err := c.courseEditor.PreviewCourse(r.Context(), id)
+	if err != nil {
+		writeContentEditError(w, op, err, storage.ErrCourseNotFound)
+		return
+	}
+
+	writeContentVersion(w, op, http.StatusOK, course.Version, course)
+}
+
+// PreviewFeedHandler returns feed item of any status.
+func (c *Core) PreviewFeedHandler(w http.ResponseWriter, r *http.Request) {
+	const op = "core.PreviewFeedHandler"
+
+	id, err := parseContentID(r)
+	if err != nil {
+		http.Error(w, "Invalid feed id", http.StatusBadRequest)
+		return
+	}
+
+	feed, err := c.feedEditor.EditableFeed(r.Context(), id)
+	if err != nil {
+		writeContentEditError(w, op, err, storage.ErrFeedNotFound)
+		return
+	}
+
+	writeContentVersion(w, op, http.StatusOK, feed.Version, feed)
+}
@@ -3,63 +3,216 @@ package core
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"github.com/gorilla/mux"
 	"log/slog"
 	"net/http"
 	"sso/internal/domain/models"
+	"sso/internal/lib/clientip"
+	"sso/internal/services/auth"
+	"sso/internal/services/throttle"
+	"sso/internal/storage"
+	"strconv"
 	"time"
 )
 
 type UserProvider interface {
 	DeleteUser(ctx context.Context, userId int64) error
 	GetUser(ctx context.Context, userId int64) (models.UserData, error)
+	UpdateProfile(ctx context.Context, userID int64, profile models.UserData) error
+	UpdateAvatar(ctx context.Context, userID int64, image string, avatarKey string) (oldAvatarKey string, err error)
+	Profile(ctx context.Context, userID int64) (models.Profile, error)
+	UserRoles(ctx context.Context, userID int64) ([]string, error)
 }
 
 type CourseProvider interface {
-	Courses(ctx context.Context) ([]models.Course, error)
+	Courses(ctx context.Context, query models.ContentQuery) (models.Page[models.Course], error)
+	Course(ctx context.Context, id int64) (models.CourseDetails, error)
+	Lesson(ctx context.Context, courseID int64, lessonID int64) (models.Lesson, error)
+	IncrementCourseViews(ctx context.Context, courseID int64) error
 }
 
 type ArticleProvider interface {
-	Articles(ctx context.Context) ([]models.Article, error)
+	Articles(ctx context.Context, query models.ContentQuery) (models.Page[models.Article], error)
+	Article(ctx context.Context, id int64) (models.ArticleDetails, error)
+	ArticleBySlug(ctx context.Context, slug string) (models.ArticleDetails, error)
+	RelatedArticles(ctx context.Context, articleID int64, limit int) ([]models.Article, error)
+	IncrementArticleViews(ctx context.Context, articleID int64) error
 }
 
 type FeedProvider interface {
-	Feeds(ctx context.Context) ([]models.Feed, error)
+	Feeds(ctx context.Context, query models.ContentQuery) (models.Page[models.Feed], error)
+}
+
+// ArticleEditor changes articles of any status, unlike ArticleProvider which only reads published ones.
+type ArticleEditor interface {
+	EditableArticle(ctx context.Context, id int64) (models.ArticleDetails, error)
+	CreateArticle(ctx context.Context, article models.ArticleDetails) (models.ArticleDetails, error)
+	UpdateArticle(ctx context.Context, article models.ArticleDetails) (models.ArticleDetails, error)
+	DeleteArticle(ctx context.Context, id int64, version int64, deletedAt time.Time) error
+}
+
+type CourseEditor interface {
+	EditableCourse(ctx context.Context, id int64) (models.Course, error)
+	CreateCourse(ctx context.Context, course models.Course) (models.Course, error)
+	UpdateCourse(ctx context.Context, course models.Course) (models.Course, error)
+	DeleteCourse(ctx context.Context, id int64, version int64, deletedAt time.Time) error
+	PreviewCourse(ctx context.Context, id int64) (models.CourseDetails, error)
+	// Modules and lessons have no versions of their own, each change bumps version of their course.
+	EditableLesson(ctx context.Context, courseID int64, lessonID int64) (models.Lesson, error)
+	CreateModule(ctx context.Context, courseID int64, version int64, module models.CourseModule, now time.Time) (int64, int64, error)
+	UpdateModule(ctx context.Context, courseID int64, version int64, module models.CourseModule, now time.Time) (int64, error)
+	DeleteModule(ctx context.Context, courseID int64, version int64, moduleID int64, deletedAt time.Time) (int64, error)
+	CreateLesson(ctx context.Context, courseID int64, version int64, lesson models.Lesson, now time.Time) (int64, int64, error)
+	UpdateLesson(ctx context.Context, courseID int64, version int64, lesson models.Lesson, now time.Time) (int64, error)
+	DeleteLesson(ctx context.Context, courseID int64, version int64, lessonID int64, deletedAt time.Time) (int64, error)
+}
+
+type FeedEditor interface {
+	EditableFeed(ctx context.Context, id int64) (models.Feed, error)
+	CreateFeed(ctx context.Context, feed models.Feed) (models.Feed, error)
+	UpdateFeed(ctx context.Context, feed models.Feed) (models.Feed, error)
+	DeleteFeed(ctx context.Context, id int64, version int64, deletedAt time.Time) error
+}
+
+// RevisionProvider reads history of article text, revisions are saved by storage on every change.
+type RevisionProvider interface {
+	ArticleRevisions(ctx context.Context, articleID int64) ([]models.Revision, error)
+	ArticleRevision(ctx context.Context, articleID int64, number int64) (models.RevisionDetails, error)
+}
+
+type ProgressTracker interface {
+	Enroll(ctx context.Context, userID int64, courseID int64, enrolledAt time.Time) (bool, error)
+	CompleteLesson(ctx context.Context, userID int64, lessonID int64, completedAt time.Time) error
+	OpenLesson(ctx context.Context, userID int64, courseID int64, lessonID int64, openedAt time.Time) error
+	Progress(ctx context.Context, userID int64) ([]models.CourseProgress, error)
+	LearningSummary(ctx context.Context, userID int64) (models.LearningSummary, error)
+}
+
+type SearchProvider interface {
+	Search(ctx context.Context, query models.SearchQuery) (models.Page[models.SearchResult], error)
+}
+
+type BookmarkManager interface {
+	AddBookmark(ctx context.Context, userID int64, contentType string, contentID int64, createdAt time.Time) error
+	RemoveBookmark(ctx context.Context, userID int64, contentType string, contentID int64) error
+	Bookmarks(ctx context.Context, query models.BookmarkQuery) (models.Page[models.Bookmark], error)
+	BookmarkedContent(ctx context.Context, userID int64, contentType string, ids []int64) (map[int64]bool, error)
+}
+
+type SessionManager interface {
+	Sessions(ctx context.Context, userID int64, currentSessionID int64) ([]models.Session, error)
+	RevokeSession(ctx context.Context, userID int64, sessionID int64) error
+}
+
+type CredentialsManager interface {
+	ChangePassword(
+		ctx context.Context,
+		userID int64,
+		sessionID int64,
+		currentPassword string,
+		newPassword string,
+		client models.Client,
+	) error
+	ChangeEmail(ctx context.Context, userID int64, newEmail string) error
+	ConfirmEmailChange(ctx context.Context, token string) error
+}
+
+// PermissionChecker resolves permissions granted to roles, e.g. content:publish for editors.
+type PermissionChecker interface {
+	HasPermission(ctx context.Context, roles []string, permission string) (bool, error)
+}
+
+// Storage groups everything Core reads and writes in the database.
+type Storage interface {
+	UserProvider
+	CourseProvider
+	ArticleProvider
+	FeedProvider
+	ArticleEditor
+	CourseEditor
+	FeedEditor
+	RevisionProvider
+	ProgressTracker
+	SearchProvider
+	BookmarkManager
+}
+
+// Accounts groups account features of the auth service exposed through REST.
+type Accounts interface {
+	SessionManager
+	CredentialsManager
+	PermissionChecker
+}
+
+type Config struct {
+	// MaxAvatarSize is the largest accepted avatar file in bytes.
+	MaxAvatarSize int64
+	// TrustProxy makes client IP to be taken from X-Forwarded-For header.
+	TrustProxy bool
 }
 
 type Core struct {
-	log             *slog.Logger
-	userProvider    UserProvider
-	courseProvider  CourseProvider
-	articleProvider ArticleProvider
-	feedProvider    FeedProvider
-	tokenTTL        time.Duration
+	log                *slog.Logger
+	userProvider       UserProvider
+	courseProvider     CourseProvider
+	articleProvider    ArticleProvider
+	feedProvider       FeedProvider
+	articleEditor      ArticleEditor
+	courseEditor       CourseEditor
+	feedEditor         FeedEditor
+	revisionProvider   RevisionProvider
+	progressTracker    ProgressTracker
+	searchProvider     SearchProvider
+	bookmarkManager    BookmarkManager
+	sessionManager     SessionManager
+	credentialsManager CredentialsManager
+	permissionChecker  PermissionChecker
+	blobStore          BlobStore
+	cfg                Config
 }
 
 func New(
 	log *slog.Logger,
-	userProvider UserProvider,
-	courseProvider CourseProvider,
-	articleProvider ArticleProvider,
-	feedProvider FeedProvider,
-	tokenTTL time.Duration,
+	storage Storage,
+	accounts Accounts,
+	blobStore BlobStore,
+	cfg Config,
 ) *Core {
 	return &Core{
-		log:             log,
-		userProvider:    userProvider,
-		courseProvider:  courseProvider,
-		articleProvider: articleProvider,
-		feedProvider:    feedProvider,
-		tokenTTL:        tokenTTL,
+		log:                log,
+		userProvider:       storage,
+		courseProvider:     storage,
+		articleProvider:    storage,
+		feedProvider:       storage,
+		articleEditor:      storage,
+		courseEditor:       storage,
+		feedEditor:         storage,
+		revisionProvider:   storage,
+		progressTracker:    storage,
+		searchProvider:     storage,
+		bookmarkManager:    storage,
+		sessionManager:     accounts,
+		credentialsManager: accounts,
+		permissionChecker:  accounts,
+		blobStore:          blobStore,
+		cfg:                cfg,
 	}
 }
 
 func (c *Core) GetFeedHandler(w http.ResponseWriter, r *http.Request) {
 	const op = "core.GetFeedHandler"
 
-	feeds, err := c.feedProvider.Feeds(r.Context())
+	query, err := parseContentQuery(r)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("%s: %v", op, err), http.StatusInternalServerError)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	feeds, err := c.feedProvider.Feeds(r.Context(), query)
+	if err != nil {
+		writeContentError(w, op, err)
 		return
 	}
 
@@ -73,14 +226,34 @@ func (c *Core) GetFeedHandler(w http.ResponseWriter, r *http.Request) {
 func (c *Core) GetArticlesHandler(w http.ResponseWriter, r *http.Request) {
 	const op = "core.GetArticlesHandler"
 
-	articles, err := c.articleProvider.Articles(r.Context())
+	query, err := parseContentQuery(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	articles, err := c.articleProvider.Articles(r.Context(), query)
+	if err != nil {
+		writeContentError(w, op, err)
+		return
+	}
+
+	uid, _ := r.Context().Value("uid").(int64)
+
+	items, err := markBookmarked(
+		r.Context(), c.bookmarkManager, uid, models.ContentTypeArticle, articles,
+		func(a models.Article) int64 { return a.ID },
+		func(a models.Article, bookmarked bool) models.ArticleListItem {
+			return models.ArticleListItem{Article: a, Bookmarked: bookmarked}
+		},
+	)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("%s: %v", op, err), http.StatusInternalServerError)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(articles); err != nil {
+	if err := json.NewEncoder(w).Encode(items); err != nil {
 		http.Error(w, fmt.Sprintf("%s: %v", op, err), http.StatusInternalServerError)
 		return
 	}
@@ -92,14 +265,34 @@ func (c *Core) GetCoursesHandler(w http.ResponseWriter, r *http.Request) {
 	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
 	defer cancel()
 
-	courses, err := c.courseProvider.Courses(ctx)
+	query, err := parseContentQuery(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	courses, err := c.courseProvider.Courses(ctx, query)
+	if err != nil {
+		writeContentError(w, op, err)
+		return
+	}
+
+	uid, _ := r.Context().Value("uid").(int64)
+
+	items, err := markBookmarked(
+		ctx, c.bookmarkManager, uid, models.ContentTypeCourse, courses,
+		func(course models.Course) int64 { return course.ID },
+		func(course models.Course, bookmarked bool) models.CourseListItem {
+			return models.CourseListItem{Course: course, Bookmarked: bookmarked}
+		},
+	)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("%s: %v", op, err), http.StatusInternalServerError)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(courses); err != nil {
+	if err := json.NewEncoder(w).Encode(items); err != nil {
 		http.Error(w, fmt.Sprintf("%s: %v", op, err), http.StatusInternalServerError)
 		return
 	}
@@ -123,6 +316,7 @@ func (c *Core) DeleteUserHandler(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusNoContent)
 }
 
+// GetUserHandler returns full profile of the user.
 func (c *Core) GetUserHandler(w http.ResponseWriter, r *http.Request) {
 	const op = "core.GetUserHandler"
 
@@ -132,11 +326,211 @@ func (c *Core) GetUserHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	_, err := c.userProvider.GetUser(r.Context(), uid)
+	profile, err := c.userProvider.Profile(r.Context(), uid)
+	if err != nil {
+		writeUserError(w, op, err)
+		return
+	}
+
+	profile.Roles, err = c.userProvider.UserRoles(r.Context(), uid)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("%s: %v", op, err), http.StatusInternalServerError)
+		return
+	}
+
+	profile.Learning, err = c.progressTracker.LearningSummary(r.Context(), uid)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("%s: %v", op, err), http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, op, profile)
+}
+
+// GetPublicUserHandler returns public profile of the user with id from the path.
+func (c *Core) GetPublicUserHandler(w http.ResponseWriter, r *http.Request) {
+	const op = "core.GetPublicUserHandler"
+
+	uid, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid user id", http.StatusBadRequest)
+		return
+	}
+
+	profile, err := c.userProvider.Profile(r.Context(), uid)
+	if err != nil {
+		writeUserError(w, op, err)
+		return
+	}
+
+	writeJSON(w, op, profile.Public())
+}
+
+// GetSessionsHandler lists active login sessions of the user, the one making the request is marked as current.
+func (c *Core) GetSessionsHandler(w http.ResponseWriter, r *http.Request) {
+	const op = "core.GetSessionsHandler"
+
+	uid, ok := r.Context().Value("uid").(int64)
+	if !ok {
+		http.Error(w, "UID not found in context", http.StatusInternalServerError)
+		return
+	}
+
+	sid, _ := r.Context().Value("sid").(int64)
+
+	sessions, err := c.sessionManager.Sessions(r.Context(), uid, sid)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("%s: %v", op, err), http.StatusInternalServerError)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(sessions); err != nil {
+		http.Error(w, fmt.Sprintf("%s: %v", op, err), http.StatusInternalServerError)
+		return
+	}
+}
+
+// DeleteSessionHandler revokes login session of the user, its tokens stop working immediately.
+func (c *Core) DeleteSessionHandler(w http.ResponseWriter, r *http.Request) {
+	const op = "core.DeleteSessionHandler"
+
+	uid, ok := r.Context().Value("uid").(int64)
+	if !ok {
+		http.Error(w, "UID not found in context", http.StatusInternalServerError)
+		return
+	}
+
+	sessionID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid session id", http.StatusBadRequest)
+		return
+	}
+
+	err = c.sessionManager.RevokeSession(r.Context(), uid, sessionID)
+	if err != nil {
+		if errors.Is(err, storage.ErrSessionNotFound) {
+			http.Error(w, "Session not found", http.StatusNotFound)
+			return
+		}
+
+		http.Error(w, fmt.Sprintf("%s: %v", op, err), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+type changePasswordRequest struct {
+	CurrentPassword string `json:"current_password"`
+	NewPassword     string `json:"new_password"`
+}
+
+// ChangePasswordHandler changes password of the user, other sessions of the user are revoked.
+func (c *Core) ChangePasswordHandler(w http.ResponseWriter, r *http.Request) {
+	const op = "core.ChangePasswordHandler"
+
+	uid, ok := r.Context().Value("uid").(int64)
+	if !ok {
+		http.Error(w, "UID not found in context", http.StatusInternalServerError)
+		return
+	}
+
+	sid, _ := r.Context().Value("sid").(int64)
+
+	var req changePasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if req.CurrentPassword == "" || req.NewPassword == "" {
+		http.Error(w, "Current and new passwords are required", http.StatusBadRequest)
+		return
+	}
+
+	client := models.Client{IP: clientip.FromHTTP(r, c.cfg.TrustProxy)}
+
+	err := c.credentialsManager.ChangePassword(r.Context(), uid, sid, req.CurrentPassword, req.NewPassword, client)
+	if err != nil {
+		var blocked *throttle.BlockedError
+		if errors.As(err, &blocked) {
+			w.Header().Set("Retry-After", strconv.Itoa(max(int(blocked.RetryAfter.Round(time.Second).Seconds()), 1)))
+			http.Error(w, "Too many password attempts", http.StatusTooManyRequests)
+			return
+		}
+
+		if errors.Is(err, auth.ErrInvalidCredentials) {
+			http.Error(w, "Invalid current password", http.StatusForbidden)
+			return
+		}
+
+		http.Error(w, fmt.Sprintf("%s: %v", op, err), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+type changeEmailRequest struct {
+	Email string `json:"email"`
+}
+
+// ChangeEmailHandler sends confirmation link to the new email of the user.
+func (c *Core) ChangeEmailHandler(w http.ResponseWriter, r *http.Request) {
+	const op = "core.ChangeEmailHandler"
+
+	uid, ok := r.Context().Value("uid").(int64)
+	if !ok {
+		http.Error(w, "UID not found in context", http.StatusInternalServerError)
+		return
+	}
+
+	var req changeEmailRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := c.credentialsManager.ChangeEmail(r.Context(), uid, req.Email); err != nil {
+		writeChangeEmailError(w, op, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+type confirmEmailChangeRequest struct {
+	Token string `json:"token"`
+}
+
+// ConfirmEmailChangeHandler replaces email of the user by token from the confirmation link.
+func (c *Core) ConfirmEmailChangeHandler(w http.ResponseWriter, r *http.Request) {
+	const op = "core.ConfirmEmailChangeHandler"
+
+	var req confirmEmailChangeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Token == "" {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := c.credentialsManager.ConfirmEmailChange(r.Context(), req.Token); err != nil {
+		writeChangeEmailError(w, op, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func writeChangeEmailError(w http.ResponseWriter, op string, err error) {
+	switch {
+	case errors.Is(err, storage.ErrUserExists):
+		http.Error(w, "Email already taken", http.StatusConflict)
+	case errors.Is(err, auth.ErrInvalidEmail):
+		http.Error(w, "Invalid email", http.StatusBadRequest)
+	case errors.Is(err, auth.ErrInvalidEmailToken):
+		http.Error(w, "Invalid or expired token", http.StatusBadRequest)
+	default:
+		http.Error(w, fmt.Sprintf("%s: %v", op, err), http.StatusInternalServerError)
+	}
 }
@@ -0,0 +1,276 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sso/internal/domain/models"
+	"sso/internal/services/auth"
+	"sso/internal/services/throttle"
+	"sso/internal/storage"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeProfiles struct {
+	UserProvider
+	ProgressTracker
+
+	profiles map[int64]models.Profile
+	roles    map[int64][]string
+	learning map[int64]models.LearningSummary
+}
+
+func (f *fakeProfiles) Profile(_ context.Context, userID int64) (models.Profile, error) {
+	profile, ok := f.profiles[userID]
+	if !ok {
+		return models.Profile{}, storage.ErrUserNotFound
+	}
+
+	return profile, nil
+}
+
+func (f *fakeProfiles) UserRoles(_ context.Context, userID int64) ([]string, error) {
+	return f.roles[userID], nil
+}
+
+func (f *fakeProfiles) LearningSummary(_ context.Context, userID int64) (models.LearningSummary, error) {
+	return f.learning[userID], nil
+}
+
+func newProfileCore() *Core {
+	createdAt := time.Unix(1700000000, 0).UTC()
+
+	profiles := &fakeProfiles{
+		profiles: map[int64]models.Profile{
+			1: {
+				ID:         1,
+				Email:      "reader@example.com",
+				Name:       "Reader",
+				Image:      "http://localhost/media/avatars/1/v1/256.jpg",
+				Bio:        "Learning Go",
+				CreatedAt:  &createdAt,
+				Verified:   true,
+				MFAEnabled: true,
+			},
+		},
+		roles:    map[int64][]string{1: {"admin"}},
+		learning: map[int64]models.LearningSummary{1: {EnrolledCourses: 2, CompletedCourses: 1, CompletedLessons: 7}},
+	}
+
+	return &Core{
+		log:             slog.New(slog.NewTextHandler(io.Discard, nil)),
+		userProvider:    profiles,
+		progressTracker: profiles,
+	}
+}
+
+func TestGetUserHandler(t *testing.T) {
+	tests := []struct {
+		name     string
+		uid      int64
+		wantCode int
+	}{
+		{name: "own profile", uid: 1, wantCode: http.StatusOK},
+		{name: "deleted user", uid: 2, wantCode: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/user", nil)
+			r = r.WithContext(context.WithValue(r.Context(), "uid", tt.uid))
+
+			rr := httptest.NewRecorder()
+			newProfileCore().GetUserHandler(rr, r)
+
+			require.Equal(t, tt.wantCode, rr.Code, rr.Body.String())
+			if tt.wantCode != http.StatusOK {
+				return
+			}
+
+			var profile models.Profile
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &profile))
+			assert.Equal(t, "reader@example.com", profile.Email)
+			assert.Equal(t, []string{"admin"}, profile.Roles)
+			assert.True(t, profile.MFAEnabled)
+			assert.Equal(t, models.LearningSummary{EnrolledCourses: 2, CompletedCourses: 1, CompletedLessons: 7}, profile.Learning)
+		})
+	}
+}
+
+func TestGetPublicUserHandler(t *testing.T) {
+	tests := []struct {
+		name     string
+		id       string
+		wantCode int
+	}{
+		{name: "existing user", id: "1", wantCode: http.StatusOK},
+		{name: "unknown user", id: "2", wantCode: http.StatusNotFound},
+		{name: "id out of range", id: "99999999999999999999", wantCode: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := mux.SetURLVars(httptest.NewRequest(http.MethodGet, "/users/"+tt.id, nil), map[string]string{"id": tt.id})
+
+			rr := httptest.NewRecorder()
+			newProfileCore().GetPublicUserHandler(rr, r)
+
+			require.Equal(t, tt.wantCode, rr.Code, rr.Body.String())
+			if tt.wantCode != http.StatusOK {
+				return
+			}
+
+			var fields map[string]any
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &fields))
+
+			assert.Equal(t, "Reader", fields["name"])
+			assert.Equal(t, "2023-11-14T22:13:20Z", fields["created_at"])
+			for _, private := range []string{"email", "roles", "verified", "mfa_enabled", "learning"} {
+				assert.NotContains(t, fields, private)
+			}
+		})
+	}
+}
+
+type fakeCredentials struct {
+	CredentialsManager
+
+	err    error
+	client models.Client
+}
+
+func (f *fakeCredentials) ChangePassword(_ context.Context, _, _ int64, _, _ string, client models.Client) error {
+	f.client = client
+
+	return f.err
+}
+
+func TestChangePasswordHandler(t *testing.T) {
+	tests := []struct {
+		name           string
+		err            error
+		wantCode       int
+		wantRetryAfter string
+	}{
+		{name: "changed", wantCode: http.StatusNoContent},
+		{name: "wrong password", err: auth.ErrInvalidCredentials, wantCode: http.StatusForbidden},
+		{
+			name:           "throttled",
+			err:            &throttle.BlockedError{Reason: throttle.ErrAccountLocked, RetryAfter: 90 * time.Second},
+			wantCode:       http.StatusTooManyRequests,
+			wantRetryAfter: "90",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			credentials := &fakeCredentials{err: tt.err}
+			c := &Core{
+				log:                slog.New(slog.NewTextHandler(io.Discard, nil)),
+				credentialsManager: credentials,
+			}
+
+			r := httptest.NewRequest(http.MethodPut, "/user/password",
+				strings.NewReader(`{"current_password":"old","new_password":"new"}`))
+			r.RemoteAddr = "192.0.2.1:1234"
+			r = r.WithContext(context.WithValue(r.Context(), "uid", int64(1)))
+
+			rr := httptest.NewRecorder()
+			c.ChangePasswordHandler(rr, r)
+
+			require.Equal(t, tt.wantCode, rr.Code, rr.Body.String())
+			assert.Equal(t, tt.wantRetryAfter, rr.Header().Get("Retry-After"))
+			assert.Equal(t, "192.0.2.1", credentials.client.IP)
+		})
+	}
+}
+
+type fakeCourses struct {
+	CourseEditor
+
+	course models.Course
+}
+
+func (f *fakeCourses) EditableCourse(_ context.Context, id int64) (models.Course, error) {
+	if id != f.course.ID {
+		return models.Course{}, storage.ErrCourseNotFound
+	}
+
+	return f.course, nil
+}
+
+func (f *fakeCourses) UpdateCourse(_ context.Context, course models.Course) (models.Course, error) {
+	course.Version++
+
+	return course, nil
+}
+
+func (f *fakeCourses) DeleteLesson(_ context.Context, _, version, _ int64, _ time.Time) (int64, error) {
+	return version + 1, nil
+}
+
+// fakePermissions grants content:publish to role "publisher" and content:write to everyone.
+type fakePermissions struct {
+	PermissionChecker
+}
+
+func (fakePermissions) HasPermission(_ context.Context, roles []string, permission string) (bool, error) {
+	return permission != models.PermissionContentPublish || slices.Contains(roles, "publisher"), nil
+}
+
+func TestCourseEdit_PublishedNeedsPublishPermission(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   string
+		roles    []string
+		wantCode int
+	}{
+		{name: "draft", status: models.ContentStatusDraft, roles: []string{"author"}, wantCode: http.StatusOK},
+		{name: "published", status: models.ContentStatusPublished, roles: []string{"author"}, wantCode: http.StatusForbidden},
+		{name: "published by publisher", status: models.ContentStatusPublished, roles: []string{"publisher"}, wantCode: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Core{
+				log: slog.New(slog.NewTextHandler(io.Discard, nil)),
+				courseEditor: &fakeCourses{course: models.Course{
+					ID: 1, Slug: "go", Name: "Go", Tags: []string{}, Status: tt.status, Version: 3,
+				}},
+				permissionChecker: fakePermissions{},
+			}
+
+			request := func(method string, target string, body string, vars map[string]string) *http.Request {
+				r := httptest.NewRequest(method, target, strings.NewReader(body))
+				r.Header.Set("If-Match", `"3"`)
+				ctx := context.WithValue(r.Context(), "uid", int64(1))
+				ctx = context.WithValue(ctx, "roles", tt.roles)
+
+				return mux.SetURLVars(r.WithContext(ctx), vars)
+			}
+
+			rr := httptest.NewRecorder()
+			c.PatchCourseHandler(rr, request(http.MethodPatch, "/course/1", `{"name":"Go in practice"}`, map[string]string{"id": "1"}))
+			assert.Equal(t, tt.wantCode, rr.Code, rr.Body.String())
+
+			wantCode := tt.wantCode
+			if wantCode == http.StatusOK {
+				wantCode = http.StatusNoContent
+			}
+
+			rr = httptest.NewRecorder()
+			c.DeleteLessonHandler(rr, request(http.MethodDelete, "/course/1/lesson/10", "", map[string]string{"id": "1", "lessonId": "10"}))
+			assert.Equal(t, wantCode, rr.Code, rr.Body.String())
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"github.com/gorilla/mux"
+	"log/slog"
+	"net/http"
+	"sso/internal/lib/logger/sl"
+	"sso/internal/lib/markdown"
+	"sso/internal/storage"
+	"strconv"
+	"time"
+)
+
+// GetCourseHandler returns published course with its modules and lessons.
+func (c *Core) GetCourseHandler(w http.ResponseWriter, r *http.Request) {
+	const op = "core.GetCourseHandler"
+
+	log := c.log.With(slog.String("op", op))
+
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid course id", http.StatusBadRequest)
+		return
+	}
+
+	course, err := c.courseProvider.Course(r.Context(), id)
+	if err != nil {
+		writeCourseError(w, op, err)
+		return
+	}
+
+	// Failing to count a view must not prevent opening the course.
+	if err := c.courseProvider.IncrementCourseViews(r.Context(), id); err != nil {
+		log.Warn("failed to count course view", sl.Err(err))
+	}
+
+	writeJSON(w, op, course)
+}
+
+// GetLessonHandler returns lesson of published course with rendered body.
+func (c *Core) GetLessonHandler(w http.ResponseWriter, r *http.Request) {
+	const op = "core.GetLessonHandler"
+
+	log := c.log.With(slog.String("op", op))
+
+	courseID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid course id", http.StatusBadRequest)
+		return
+	}
+
+	lessonID, err := strconv.ParseInt(mux.Vars(r)["lessonId"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid lesson id", http.StatusBadRequest)
+		return
+	}
+
+	lesson, err := c.courseProvider.Lesson(r.Context(), courseID, lessonID)
+	if err != nil {
+		writeCourseError(w, op, err)
+		return
+	}
+
+	// Remembered as the place to continue from, if the user is enrolled in the course.
+	if uid, ok := r.Context().Value("uid").(int64); ok {
+		if err := c.progressTracker.OpenLesson(r.Context(), uid, courseID, lessonID, time.Now()); err != nil {
+			log.Warn("failed to record opened lesson", sl.Err(err))
+		}
+	}
+
+	doc, err := markdown.Render(lesson.Body)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("%s: %v", op, err), http.StatusInternalServerError)
+		return
+	}
+	lesson.HTML = doc.HTML
+
+	writeJSON(w, op, lesson)
+}
+
+func writeCourseError(w http.ResponseWriter, op string, err error) {
+	switch {
+	case errors.Is(err, storage.ErrCourseNotFound):
+		http.Error(w, "Course not found", http.StatusNotFound)
+	case errors.Is(err, storage.ErrLessonNotFound):
+		http.Error(w, "Lesson not found", http.StatusNotFound)
+	default:
+		http.Error(w, fmt.Sprintf("%s: %v", op, err), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,577 @@
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/gorilla/mux"
+	"net/http"
+	"slices"
+	"sso/internal/domain/models"
+	"sso/internal/lib/markdown"
+	"sso/internal/storage"
+	"strconv"
+	"time"
+)
+
+// maxLessonDuration is in minutes.
+const maxLessonDuration = 24 * 60
+
+var lessonTypes = []string{
+	models.LessonTypeText,
+	models.LessonTypeVideo,
+	models.LessonTypeQuiz,
+	models.LessonTypeExternal,
+}
+
+// moduleRequest holds fields editors set on course modules.
+// Fields missing from PATCH request keep their values, POST and PUT requests reset them to defaults.
+type moduleRequest struct {
+	Title       *string `json:"title"`
+	Description *string `json:"description"`
+	// Position moves module among modules of the course, the first one is 1.
+	// Module stays where it is, or is added after the last one, unless position is set.
+	Position *int `json:"position"`
+}
+
+// lessonRequest holds fields editors set on lessons, like moduleRequest does for modules.
+type lessonRequest struct {
+	Title           *string     `json:"title"`
+	Type            *string     `json:"type"`
+	Body            *string     `json:"body"`
+	URL             *string     `json:"url"`
+	DurationMinutes nullableInt `json:"duration_minutes"`
+	// Position moves lesson among lessons of the module, the first one is 1.
+	Position *int `json:"position"`
+}
+
+// nullableInt tells JSON null, which clears the number, apart from a missing field.
+type nullableInt struct {
+	Set bool
+	Int *int
+}
+
+func (i *nullableInt) UnmarshalJSON(data []byte) error {
+	i.Set = true
+
+	return json.Unmarshal(data, &i.Int)
+}
+
+// apply sets fields present in the request, with replace the missing ones are reset to defaults.
+// Position is set only if the request has it, 0 keeps the module where it is.
+func (req moduleRequest) apply(m *models.CourseModule, replace bool) {
+	setString(&m.Title, req.Title, replace, "")
+	setString(&m.Description, req.Description, replace, "")
+
+	m.Position = 0
+	if req.Position != nil {
+		m.Position = *req.Position
+	}
+}
+
+func (req lessonRequest) apply(l *models.Lesson, replace bool) {
+	setString(&l.Title, req.Title, replace, "")
+	setString(&l.Type, req.Type, replace, models.LessonTypeText)
+	setString(&l.URL, req.URL, replace, "")
+
+	// Body is Markdown, leading indentation is meaningful there.
+	switch {
+	case req.Body != nil:
+		l.Body = *req.Body
+	case replace:
+		l.Body = ""
+	}
+
+	switch {
+	case req.DurationMinutes.Set:
+		l.DurationMinutes = req.DurationMinutes.Int
+	case replace:
+		l.DurationMinutes = nil
+	}
+
+	l.Position = 0
+	if req.Position != nil {
+		l.Position = *req.Position
+	}
+}
+
+// validate returns error meant to be shown to the client if the request is invalid.
+// Only the position is checked here, the rest is checked on the module it is applied to.
+func (req moduleRequest) validate() error {
+	return validatePosition(req.Position)
+}
+
+func (req lessonRequest) validate() error {
+	return validatePosition(req.Position)
+}
+
+func validatePosition(position *int) error {
+	if position != nil && *position < 1 {
+		return errors.New("Position must be 1 or greater")
+	}
+
+	return nil
+}
+
+func validateModule(module *models.CourseModule) error {
+	if err := validateName("Title", module.Title); err != nil {
+		return err
+	}
+
+	return validateDescription(module.Description)
+}
+
+func validateLesson(lesson *models.Lesson) error {
+	if err := validateName("Title", lesson.Title); err != nil {
+		return err
+	}
+
+	if !slices.Contains(lessonTypes, lesson.Type) {
+		return errors.New("Type must be text, video, quiz or external")
+	}
+
+	if len(lesson.Body) > maxArticleBodyLength {
+		return fmt.Errorf("Body must be at most %d KB", maxArticleBodyLength>>10)
+	}
+
+	if !isContentURL(lesson.URL) {
+		return errors.New("URL must be an http(s) URL or an absolute path")
+	}
+
+	if lesson.URL == "" && (lesson.Type == models.LessonTypeVideo || lesson.Type == models.LessonTypeExternal) {
+		return errors.New("URL is required for video and external lessons")
+	}
+
+	if d := lesson.DurationMinutes; d != nil && (*d < 1 || *d > maxLessonDuration) {
+		return fmt.Errorf("Duration must be 1 to %d minutes", maxLessonDuration)
+	}
+
+	return nil
+}
+
+// writeCourseEditError writes error of module or lesson write request.
+func writeCourseEditError(w http.ResponseWriter, op string, err error) {
+	switch {
+	case errors.Is(err, storage.ErrModuleNotFound):
+		http.Error(w, "Module not found", http.StatusNotFound)
+	case errors.Is(err, storage.ErrLessonNotFound):
+		http.Error(w, "Lesson not found", http.StatusNotFound)
+	default:
+		writeContentEditError(w, op, err, storage.ErrCourseNotFound)
+	}
+}
+
+func parseRouteID(r *http.Request, name string) (int64, error) {
+	return strconv.ParseInt(mux.Vars(r)[name], 10, 64)
+}
+
+// checkCourseEdit checks that modules and lessons of course may be changed at version.
+// Students see changes of published courses at once, so they take content:publish permission.
+func (c *Core) checkCourseEdit(w http.ResponseWriter, r *http.Request, course models.Course, version int64) error {
+	if course.Version != version {
+		w.Header().Set("ETag", contentETag(course.Version))
+		return errPreconditionFailed
+	}
+
+	return c.checkPublishedEdit(r, course.Status)
+}
+
+// editableCourse loads course whose modules and lessons are changed at version and checks the change is allowed.
+func (c *Core) editableCourse(w http.ResponseWriter, r *http.Request, courseID int64, version int64) error {
+	course, err := c.courseEditor.EditableCourse(r.Context(), courseID)
+	if err != nil {
+		return err
+	}
+
+	return c.checkCourseEdit(w, r, course, version)
+}
+
+// writeCourseTree writes course with its modules and lessons after a change of its modules.
+func (c *Core) writeCourseTree(w http.ResponseWriter, r *http.Request, op string, status int, courseID int64) {
+	course, err := c.courseEditor.PreviewCourse(r.Context(), courseID)
+	if err != nil {
+		writeCourseEditError(w, op, err)
+		return
+	}
+
+	writeContentVersion(w, op, status, course.Version, course)
+}
+
+// writeLesson writes lesson of course with rendered body and version of the course as ETag.
+func (c *Core) writeLesson(
+	w http.ResponseWriter,
+	r *http.Request,
+	op string,
+	status int,
+	courseID int64,
+	lessonID int64,
+	version int64,
+) {
+	lesson, err := c.courseEditor.EditableLesson(r.Context(), courseID, lessonID)
+	if err != nil {
+		writeCourseEditError(w, op, err)
+		return
+	}
+
+	doc, err := markdown.Render(lesson.Body)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("%s: %v", op, err), http.StatusInternalServerError)
+		return
+	}
+	lesson.HTML = doc.HTML
+
+	writeContentVersion(w, op, status, version, lesson)
+}
+
+// CreateModuleHandler adds module to the course and returns the course with its modules.
+// Modules and lessons are versioned with their course, so If-Match takes ETag of the course.
+func (c *Core) CreateModuleHandler(w http.ResponseWriter, r *http.Request) {
+	const op = "core.CreateModuleHandler"
+
+	courseID, err := parseContentID(r)
+	if err != nil {
+		http.Error(w, "Invalid course id", http.StatusBadRequest)
+		return
+	}
+
+	version, err := ifMatchVersion(r)
+	if err != nil {
+		writeCourseEditError(w, op, err)
+		return
+	}
+
+	var req moduleRequest
+	if err := decodeContentRequest(w, r, &req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := c.editableCourse(w, r, courseID, version); err != nil {
+		writeCourseEditError(w, op, err)
+		return
+	}
+
+	var module models.CourseModule
+	req.apply(&module, true)
+
+	if err := req.validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := validateModule(&module); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if _, _, err := c.courseEditor.CreateModule(r.Context(), courseID, version, module, time.Now()); err != nil {
+		writeCourseEditError(w, op, err)
+		return
+	}
+
+	c.writeCourseTree(w, r, op, http.StatusCreated, courseID)
+}
+
+// ReplaceModuleHandler replaces fields of module, missing fields are reset.
+func (c *Core) ReplaceModuleHandler(w http.ResponseWriter, r *http.Request) {
+	c.updateModule(w, r, "core.ReplaceModuleHandler", true)
+}
+
+// PatchModuleHandler changes fields of module present in the request.
+func (c *Core) PatchModuleHandler(w http.ResponseWriter, r *http.Request) {
+	c.updateModule(w, r, "core.PatchModuleHandler", false)
+}
+
+func (c *Core) updateModule(w http.ResponseWriter, r *http.Request, op string, replace bool) {
+	courseID, err := parseContentID(r)
+	if err != nil {
+		http.Error(w, "Invalid course id", http.StatusBadRequest)
+		return
+	}
+
+	moduleID, err := parseRouteID(r, "moduleId")
+	if err != nil {
+		http.Error(w, "Invalid module id", http.StatusBadRequest)
+		return
+	}
+
+	version, err := ifMatchVersion(r)
+	if err != nil {
+		writeCourseEditError(w, op, err)
+		return
+	}
+
+	var req moduleRequest
+	if err := decodeContentRequest(w, r, &req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	course, err := c.courseEditor.PreviewCourse(r.Context(), courseID)
+	if err != nil {
+		writeCourseEditError(w, op, err)
+		return
+	}
+
+	if err := c.checkCourseEdit(w, r, course.Course, version); err != nil {
+		writeCourseEditError(w, op, err)
+		return
+	}
+
+	i := slices.IndexFunc(course.Modules, func(m models.CourseModule) bool { return m.ID == moduleID })
+	if i < 0 {
+		writeCourseEditError(w, op, storage.ErrModuleNotFound)
+		return
+	}
+
+	module := course.Modules[i]
+	req.apply(&module, replace)
+
+	if err := req.validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := validateModule(&module); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if _, err := c.courseEditor.UpdateModule(r.Context(), courseID, version, module, time.Now()); err != nil {
+		writeCourseEditError(w, op, err)
+		return
+	}
+
+	c.writeCourseTree(w, r, op, http.StatusOK, courseID)
+}
+
+// DeleteModuleHandler deletes module with its lessons, their rows are kept so they can be restored.
+// ETag of the course after the change is returned.
+func (c *Core) DeleteModuleHandler(w http.ResponseWriter, r *http.Request) {
+	const op = "core.DeleteModuleHandler"
+
+	courseID, err := parseContentID(r)
+	if err != nil {
+		http.Error(w, "Invalid course id", http.StatusBadRequest)
+		return
+	}
+
+	moduleID, err := parseRouteID(r, "moduleId")
+	if err != nil {
+		http.Error(w, "Invalid module id", http.StatusBadRequest)
+		return
+	}
+
+	version, err := ifMatchVersion(r)
+	if err == nil {
+		err = c.editableCourse(w, r, courseID, version)
+	}
+	if err == nil {
+		version, err = c.courseEditor.DeleteModule(r.Context(), courseID, version, moduleID, time.Now())
+	}
+	if err != nil {
+		writeCourseEditError(w, op, err)
+		return
+	}
+
+	w.Header().Set("ETag", contentETag(version))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// CreateLessonHandler adds lesson authored by the user to the module, it is a text lesson unless type says otherwise.
+func (c *Core) CreateLessonHandler(w http.ResponseWriter, r *http.Request) {
+	const op = "core.CreateLessonHandler"
+
+	uid, ok := r.Context().Value("uid").(int64)
+	if !ok {
+		http.Error(w, "UID not found in context", http.StatusInternalServerError)
+		return
+	}
+
+	courseID, err := parseContentID(r)
+	if err != nil {
+		http.Error(w, "Invalid course id", http.StatusBadRequest)
+		return
+	}
+
+	moduleID, err := parseRouteID(r, "moduleId")
+	if err != nil {
+		http.Error(w, "Invalid module id", http.StatusBadRequest)
+		return
+	}
+
+	version, err := ifMatchVersion(r)
+	if err != nil {
+		writeCourseEditError(w, op, err)
+		return
+	}
+
+	var req lessonRequest
+	if err := decodeContentRequest(w, r, &req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := c.editableCourse(w, r, courseID, version); err != nil {
+		writeCourseEditError(w, op, err)
+		return
+	}
+
+	lesson := models.Lesson{ModuleID: moduleID}
+	req.apply(&lesson, true)
+
+	if err := req.validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := validateLesson(&lesson); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	lesson.UpdatedBy = &uid
+
+	id, version, err := c.courseEditor.CreateLesson(r.Context(), courseID, version, lesson, time.Now())
+	if err != nil {
+		writeCourseEditError(w, op, err)
+		return
+	}
+
+	w.Header().Set("Location", fmt.Sprintf("/course/%d/lesson/%d/preview", courseID, id))
+	c.writeLesson(w, r, op, http.StatusCreated, courseID, id, version)
+}
+
+// ReplaceLessonHandler replaces fields of lesson, missing fields are reset.
+func (c *Core) ReplaceLessonHandler(w http.ResponseWriter, r *http.Request) {
+	c.updateLesson(w, r, "core.ReplaceLessonHandler", true)
+}
+
+// PatchLessonHandler changes fields of lesson present in the request.
+func (c *Core) PatchLessonHandler(w http.ResponseWriter, r *http.Request) {
+	c.updateLesson(w, r, "core.PatchLessonHandler", false)
+}
+
+func (c *Core) updateLesson(w http.ResponseWriter, r *http.Request, op string, replace bool) {
+	uid, ok := r.Context().Value("uid").(int64)
+	if !ok {
+		http.Error(w, "UID not found in context", http.StatusInternalServerError)
+		return
+	}
+
+	courseID, err := parseContentID(r)
+	if err != nil {
+		http.Error(w, "Invalid course id", http.StatusBadRequest)
+		return
+	}
+
+	lessonID, err := parseRouteID(r, "lessonId")
+	if err != nil {
+		http.Error(w, "Invalid lesson id", http.StatusBadRequest)
+		return
+	}
+
+	version, err := ifMatchVersion(r)
+	if err != nil {
+		writeCourseEditError(w, op, err)
+		return
+	}
+
+	var req lessonRequest
+	if err := decodeContentRequest(w, r, &req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := c.editableCourse(w, r, courseID, version); err != nil {
+		writeCourseEditError(w, op, err)
+		return
+	}
+
+	lesson, err := c.courseEditor.EditableLesson(r.Context(), courseID, lessonID)
+	if err != nil {
+		writeCourseEditError(w, op, err)
+		return
+	}
+
+	req.apply(&lesson, replace)
+
+	if err := req.validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := validateLesson(&lesson); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	lesson.UpdatedBy = &uid
+
+	version, err = c.courseEditor.UpdateLesson(r.Context(), courseID, version, lesson, time.Now())
+	if err != nil {
+		writeCourseEditError(w, op, err)
+		return
+	}
+
+	c.writeLesson(w, r, op, http.StatusOK, courseID, lessonID, version)
+}
+
+// DeleteLessonHandler deletes lesson, its row and revisions are kept so it can be restored.
+// ETag of the course after the change is returned.
+func (c *Core) DeleteLessonHandler(w http.ResponseWriter, r *http.Request) {
+	const op = "core.DeleteLessonHandler"
+
+	courseID, err := parseContentID(r)
+	if err != nil {
+		http.Error(w, "Invalid course id", http.StatusBadRequest)
+		return
+	}
+
+	lessonID, err := parseRouteID(r, "lessonId")
+	if err != nil {
+		http.Error(w, "Invalid lesson id", http.StatusBadRequest)
+		return
+	}
+
+	version, err := ifMatchVersion(r)
+	if err == nil {
+		err = c.editableCourse(w, r, courseID, version)
+	}
+	if err == nil {
+		version, err = c.courseEditor.DeleteLesson(r.Context(), courseID, version, lessonID, time.Now())
+	}
+	if err != nil {
+		writeCourseEditError(w, op, err)
+		return
+	}
+
+	w.Header().Set("ETag", contentETag(version))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// PreviewLessonHandler returns lesson of course of any status with rendered body and ETag of the course.
+func (c *Core) PreviewLessonHandler(w http.ResponseWriter, r *http.Request) {
+	const op = "core.PreviewLessonHandler"
+
+	courseID, err := parseContentID(r)
+	if err != nil {
+		http.Error(w, "Invalid course id", http.StatusBadRequest)
+		return
+	}
+
+	lessonID, err := parseRouteID(r, "lessonId")
+	if err != nil {
+		http.Error(w, "Invalid lesson id", http.StatusBadRequest)
+		return
+	}
+
+	// Version is read before the lesson, so a change in between makes the ETag stale rather than too new.
+	course, err := c.courseEditor.EditableCourse(r.Context(), courseID)
+	if err != nil {
+		writeCourseEditError(w, op, err)
+		return
+	}
+
+	c.writeLesson(w, r, op, http.StatusOK, courseID, lessonID, course.Version)
+}
@@ -0,0 +1,250 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"slices"
+	"sso/internal/domain/models"
+	"sso/internal/lib/logger/sl"
+	"sso/internal/lib/opaque"
+	"sso/internal/lib/thumbnail"
+	"sso/internal/storage"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	maxNameLength = 64
+	maxBioLength  = 500
+	// maxAvatarPixels bounds memory used to decode uploaded image.
+	maxAvatarPixels = 4096 * 4096
+	// multipartOverhead is allowed on top of the file size for multipart headers and boundaries.
+	multipartOverhead = 64 << 10
+	avatarFormField   = "avatar"
+)
+
+// avatarSizes are sides of square avatar thumbnails, the first one is used as UserData.Image.
+var avatarSizes = []int{256, 128, 64}
+
+var avatarContentTypes = []string{"image/jpeg", "image/png", "image/gif"}
+
+type BlobStore interface {
+	Put(ctx context.Context, key string, data []byte, contentType string) error
+	Delete(ctx context.Context, key string) error
+	URL(key string) string
+}
+
+type updateProfileRequest struct {
+	Name *string `json:"name"`
+	Bio  *string `json:"bio"`
+}
+
+// UpdateUserHandler updates profile fields present in the request and returns the updated profile.
+func (c *Core) UpdateUserHandler(w http.ResponseWriter, r *http.Request) {
+	const op = "core.UpdateUserHandler"
+
+	uid, ok := r.Context().Value("uid").(int64)
+	if !ok {
+		http.Error(w, "UID not found in context", http.StatusInternalServerError)
+		return
+	}
+
+	var req updateProfileRequest
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	profile, err := c.userProvider.GetUser(r.Context(), uid)
+	if err != nil {
+		writeUserError(w, op, err)
+		return
+	}
+
+	if req.Name != nil {
+		profile.Name = strings.TrimSpace(*req.Name)
+	}
+
+	if req.Bio != nil {
+		profile.Bio = strings.TrimSpace(*req.Bio)
+	}
+
+	if err := validateProfile(profile); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := c.userProvider.UpdateProfile(r.Context(), uid, profile); err != nil {
+		writeUserError(w, op, err)
+		return
+	}
+
+	writeJSON(w, op, profile)
+}
+
+// UpdateAvatarHandler accepts JPEG, PNG or GIF image in the "avatar" multipart field,
+// stores square thumbnails of it and sets the largest one as user image.
+func (c *Core) UpdateAvatarHandler(w http.ResponseWriter, r *http.Request) {
+	const op = "core.UpdateAvatarHandler"
+
+	log := c.log.With(slog.String("op", op))
+
+	uid, ok := r.Context().Value("uid").(int64)
+	if !ok {
+		http.Error(w, "UID not found in context", http.StatusInternalServerError)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, c.cfg.MaxAvatarSize+multipartOverhead)
+
+	file, header, err := r.FormFile(avatarFormField)
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			http.Error(w, "Image is too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+
+		http.Error(w, "Avatar file is required", http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, c.cfg.MaxAvatarSize+1))
+	if err != nil {
+		http.Error(w, "Failed to read image", http.StatusBadRequest)
+		return
+	}
+
+	if int64(len(data)) > c.cfg.MaxAvatarSize {
+		http.Error(w, "Image is too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	declared := strings.TrimSpace(strings.Split(header.Header.Get("Content-Type"), ";")[0])
+	if !slices.Contains(avatarContentTypes, declared) ||
+		!slices.Contains(avatarContentTypes, http.DetectContentType(data)) {
+		http.Error(w, "Image must be JPEG, PNG or GIF", http.StatusUnsupportedMediaType)
+		return
+	}
+
+	img, err := thumbnail.Decode(data, maxAvatarPixels)
+	if err != nil {
+		if errors.Is(err, thumbnail.ErrTooLarge) {
+			http.Error(w, "Image dimensions are too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+
+		http.Error(w, "Invalid image", http.StatusUnsupportedMediaType)
+		return
+	}
+
+	version, err := opaque.New()
+	if err != nil {
+		http.Error(w, fmt.Sprintf("%s: %v", op, err), http.StatusInternalServerError)
+		return
+	}
+
+	// New upload gets new keys, so cached old thumbnails are never served instead of it.
+	avatarKey := fmt.Sprintf("avatars/%d/%s", uid, version)
+
+	source := img
+	for _, size := range avatarSizes {
+		thumb := thumbnail.Square(source, size)
+		source = thumb
+
+		encoded, err := thumbnail.EncodeJPEG(thumb)
+		if err == nil {
+			err = c.blobStore.Put(r.Context(), avatarBlobKey(avatarKey, size), encoded, "image/jpeg")
+		}
+		if err != nil {
+			log.Error("failed to store avatar", sl.Err(err))
+			c.deleteAvatar(r.Context(), log, avatarKey)
+			http.Error(w, fmt.Sprintf("%s: %v", op, err), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	image := c.blobStore.URL(avatarBlobKey(avatarKey, avatarSizes[0]))
+
+	oldKey, err := c.userProvider.UpdateAvatar(r.Context(), uid, image, avatarKey)
+	if err != nil {
+		c.deleteAvatar(r.Context(), log, avatarKey)
+		writeUserError(w, op, err)
+		return
+	}
+
+	if oldKey != "" {
+		c.deleteAvatar(r.Context(), log, oldKey)
+	}
+
+	profile, err := c.userProvider.GetUser(r.Context(), uid)
+	if err != nil {
+		writeUserError(w, op, err)
+		return
+	}
+
+	writeJSON(w, op, profile)
+}
+
+// deleteAvatar removes all thumbnails of avatar, failures only leave unused files behind.
+func (c *Core) deleteAvatar(ctx context.Context, log *slog.Logger, avatarKey string) {
+	for _, size := range avatarSizes {
+		if err := c.blobStore.Delete(ctx, avatarBlobKey(avatarKey, size)); err != nil {
+			log.Warn("failed to delete avatar", sl.Err(err))
+		}
+	}
+}
+
+func avatarBlobKey(avatarKey string, size int) string {
+	return fmt.Sprintf("%s/%d.jpg", avatarKey, size)
+}
+
+func validateProfile(profile models.UserData) error {
+	if profile.Name == "" {
+		return errors.New("Name is required")
+	}
+
+	if utf8.RuneCountInString(profile.Name) > maxNameLength {
+		return fmt.Errorf("Name must be at most %d characters", maxNameLength)
+	}
+
+	if strings.ContainsFunc(profile.Name, unicode.IsControl) {
+		return errors.New("Name must not contain control characters")
+	}
+
+	if utf8.RuneCountInString(profile.Bio) > maxBioLength {
+		return fmt.Errorf("Bio must be at most %d characters", maxBioLength)
+	}
+
+	if strings.ContainsFunc(profile.Bio, func(r rune) bool { return unicode.IsControl(r) && r != '\n' }) {
+		return errors.New("Bio must not contain control characters other than line breaks")
+	}
+
+	return nil
+}
+
+func writeUserError(w http.ResponseWriter, op string, err error) {
+	if errors.Is(err, storage.ErrUserNotFound) {
+		http.Error(w, "User not found", http.StatusNotFound)
+		return
+	}
+
+	http.Error(w, fmt.Sprintf("%s: %v", op, err), http.StatusInternalServerError)
+}
+
+func writeJSON(w http.ResponseWriter, op string, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		http.Error(w, fmt.Sprintf("%s: %v", op, err), http.StatusInternalServerError)
+		return
+	}
+}
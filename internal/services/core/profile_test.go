@@ -0,0 +1,302 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"log/slog"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"sort"
+	"sso/internal/domain/models"
+	"sso/internal/storage"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testMaxAvatarSize = 64 << 10
+
+// fakeUsers keeps users in memory, methods not needed by tests panic through the nil interface.
+type fakeUsers struct {
+	UserProvider
+
+	users      map[int64]models.UserData
+	avatarKeys map[int64]string
+	updateErr  error
+}
+
+func (f *fakeUsers) GetUser(_ context.Context, userID int64) (models.UserData, error) {
+	user, ok := f.users[userID]
+	if !ok {
+		return models.UserData{}, storage.ErrUserNotFound
+	}
+
+	return user, nil
+}
+
+func (f *fakeUsers) UpdateAvatar(_ context.Context, userID int64, image string, avatarKey string) (string, error) {
+	if f.updateErr != nil {
+		return "", f.updateErr
+	}
+
+	user, ok := f.users[userID]
+	if !ok {
+		return "", storage.ErrUserNotFound
+	}
+
+	oldKey := f.avatarKeys[userID]
+	user.Image = image
+	f.users[userID] = user
+	f.avatarKeys[userID] = avatarKey
+
+	return oldKey, nil
+}
+
+type memBlobs struct {
+	mu    sync.Mutex
+	blobs map[string][]byte
+}
+
+func (m *memBlobs) Put(_ context.Context, key string, data []byte, _ string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.blobs[key] = data
+
+	return nil
+}
+
+func (m *memBlobs) Delete(_ context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.blobs, key)
+
+	return nil
+}
+
+func (m *memBlobs) URL(key string) string {
+	return "http://localhost/media/" + key
+}
+
+func (m *memBlobs) keys() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	keys := make([]string, 0, len(m.blobs))
+	for key := range m.blobs {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+func newAvatarCore() (*Core, *fakeUsers, *memBlobs) {
+	users := &fakeUsers{
+		users:      map[int64]models.UserData{1: {Name: "Reader"}},
+		avatarKeys: map[int64]string{},
+	}
+	blobs := &memBlobs{blobs: map[string][]byte{}}
+
+	c := &Core{
+		log:          slog.New(slog.NewTextHandler(io.Discard, nil)),
+		userProvider: users,
+		blobStore:    blobs,
+		cfg:          Config{MaxAvatarSize: testMaxAvatarSize},
+	}
+
+	return c, users, blobs
+}
+
+func encodePNG(t *testing.T, width int, height int) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, width, height))))
+
+	return buf.Bytes()
+}
+
+// pixelBomb returns a tiny PNG whose header declares a huge bitmap.
+func pixelBomb(t *testing.T) []byte {
+	t.Helper()
+
+	data := encodePNG(t, 1, 1)
+	binary.BigEndian.PutUint32(data[16:20], 50_000)
+	binary.BigEndian.PutUint32(data[20:24], 50_000)
+	binary.BigEndian.PutUint32(data[29:33], crc32.ChecksumIEEE(data[12:29]))
+
+	return data
+}
+
+func uploadAvatar(t *testing.T, c *Core, contentType string, data []byte) *httptest.ResponseRecorder {
+	t.Helper()
+
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+
+	header := textproto.MIMEHeader{}
+	header.Set("Content-Disposition", `form-data; name="avatar"; filename="avatar"`)
+	header.Set("Content-Type", contentType)
+	part, err := mw.CreatePart(header)
+	require.NoError(t, err)
+	_, err = part.Write(data)
+	require.NoError(t, err)
+	require.NoError(t, mw.Close())
+
+	r := httptest.NewRequest(http.MethodPut, "/user/avatar", &body)
+	r.Header.Set("Content-Type", mw.FormDataContentType())
+	r = r.WithContext(context.WithValue(r.Context(), "uid", int64(1)))
+
+	rr := httptest.NewRecorder()
+	c.UpdateAvatarHandler(rr, r)
+
+	return rr
+}
+
+func TestUpdateAvatarHandler_Rejected(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		data        func(t *testing.T) []byte
+		wantCode    int
+	}{
+		{
+			name:        "declared type not allowed",
+			contentType: "image/svg+xml",
+			data:        func(t *testing.T) []byte { return encodePNG(t, 10, 10) },
+			wantCode:    http.StatusUnsupportedMediaType,
+		},
+		{
+			name:        "sniffed type not allowed",
+			contentType: "image/png",
+			data: func(*testing.T) []byte {
+				return []byte("<html><script>alert(1)</script></html>")
+			},
+			wantCode: http.StatusUnsupportedMediaType,
+		},
+		{
+			name:        "broken image",
+			contentType: "image/png",
+			data:        func(t *testing.T) []byte { return encodePNG(t, 10, 10)[:40] },
+			wantCode:    http.StatusUnsupportedMediaType,
+		},
+		{
+			name:        "file too large",
+			contentType: "image/png",
+			data: func(t *testing.T) []byte {
+				return append(encodePNG(t, 10, 10), make([]byte, testMaxAvatarSize)...)
+			},
+			wantCode: http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:        "body too large",
+			contentType: "image/png",
+			data: func(t *testing.T) []byte {
+				return append(encodePNG(t, 10, 10), make([]byte, testMaxAvatarSize+multipartOverhead)...)
+			},
+			wantCode: http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:        "pixel bomb",
+			contentType: "image/png",
+			data:        pixelBomb,
+			wantCode:    http.StatusRequestEntityTooLarge,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, users, blobs := newAvatarCore()
+
+			rr := uploadAvatar(t, c, tt.contentType, tt.data(t))
+
+			assert.Equal(t, tt.wantCode, rr.Code, rr.Body.String())
+			assert.Empty(t, blobs.keys())
+			assert.Empty(t, users.users[1].Image)
+		})
+	}
+}
+
+func TestUpdateAvatarHandler_Thumbnails(t *testing.T) {
+	c, users, blobs := newAvatarCore()
+
+	rr := uploadAvatar(t, c, "image/png; name=avatar.png", encodePNG(t, 300, 200))
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+	avatarKey := users.avatarKeys[1]
+	require.True(t, strings.HasPrefix(avatarKey, "avatars/1/"), avatarKey)
+
+	keys := blobs.keys()
+	require.Len(t, keys, len(avatarSizes))
+
+	for _, size := range avatarSizes {
+		data, ok := blobs.blobs[avatarBlobKey(avatarKey, size)]
+		require.True(t, ok, size)
+
+		cfg, err := jpeg.DecodeConfig(bytes.NewReader(data))
+		require.NoError(t, err)
+		assert.Equal(t, size, cfg.Width)
+		assert.Equal(t, size, cfg.Height)
+	}
+
+	var profile models.UserData
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &profile))
+	assert.Equal(t, blobs.URL(avatarBlobKey(avatarKey, avatarSizes[0])), profile.Image)
+}
+
+func TestUpdateAvatarHandler_DeletesOldAvatar(t *testing.T) {
+	c, users, blobs := newAvatarCore()
+
+	require.Equal(t, http.StatusOK, uploadAvatar(t, c, "image/png", encodePNG(t, 20, 20)).Code)
+	oldKey := users.avatarKeys[1]
+
+	require.Equal(t, http.StatusOK, uploadAvatar(t, c, "image/png", encodePNG(t, 20, 20)).Code)
+	newKey := users.avatarKeys[1]
+	require.NotEqual(t, oldKey, newKey)
+
+	var want []string
+	for _, size := range avatarSizes {
+		want = append(want, avatarBlobKey(newKey, size))
+	}
+	sort.Strings(want)
+
+	assert.Equal(t, want, blobs.keys())
+}
+
+func TestUpdateAvatarHandler_UpdateFails(t *testing.T) {
+	tests := []struct {
+		name      string
+		updateErr error
+		wantCode  int
+	}{
+		{name: "user deleted", updateErr: storage.ErrUserNotFound, wantCode: http.StatusNotFound},
+		{name: "storage error", updateErr: errors.New("database is locked"), wantCode: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, users, blobs := newAvatarCore()
+			users.updateErr = tt.updateErr
+
+			rr := uploadAvatar(t, c, "image/png", encodePNG(t, 20, 20))
+
+			assert.Equal(t, tt.wantCode, rr.Code)
+			assert.Empty(t, blobs.keys(), "uploaded thumbnails are removed")
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"github.com/gorilla/mux"
+	"net/http"
+	"sso/internal/storage"
+	"strconv"
+	"time"
+)
+
+// EnrollHandler enrolls the user in the course. Responds 201 on enrollment and 204 if already enrolled.
+func (c *Core) EnrollHandler(w http.ResponseWriter, r *http.Request) {
+	const op = "core.EnrollHandler"
+
+	uid, ok := r.Context().Value("uid").(int64)
+	if !ok {
+		http.Error(w, "UID not found in context", http.StatusInternalServerError)
+		return
+	}
+
+	courseID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid course id", http.StatusBadRequest)
+		return
+	}
+
+	enrolled, err := c.progressTracker.Enroll(r.Context(), uid, courseID, time.Now())
+	if err != nil {
+		writeCourseError(w, op, err)
+		return
+	}
+
+	if !enrolled {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+}
+
+// CompleteLessonHandler marks the lesson completed, the user must be enrolled in its course.
+func (c *Core) CompleteLessonHandler(w http.ResponseWriter, r *http.Request) {
+	const op = "core.CompleteLessonHandler"
+
+	uid, ok := r.Context().Value("uid").(int64)
+	if !ok {
+		http.Error(w, "UID not found in context", http.StatusInternalServerError)
+		return
+	}
+
+	lessonID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid lesson id", http.StatusBadRequest)
+		return
+	}
+
+	err = c.progressTracker.CompleteLesson(r.Context(), uid, lessonID, time.Now())
+	if err != nil {
+		if errors.Is(err, storage.ErrNotEnrolled) {
+			http.Error(w, "Not enrolled in the course", http.StatusForbidden)
+			return
+		}
+
+		writeCourseError(w, op, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetProgressHandler returns completion of every course the user is enrolled in and the lesson opened last.
+func (c *Core) GetProgressHandler(w http.ResponseWriter, r *http.Request) {
+	const op = "core.GetProgressHandler"
+
+	uid, ok := r.Context().Value("uid").(int64)
+	if !ok {
+		http.Error(w, "UID not found in context", http.StatusInternalServerError)
+		return
+	}
+
+	progress, err := c.progressTracker.Progress(r.Context(), uid)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("%s: %v", op, err), http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, op, progress)
+}
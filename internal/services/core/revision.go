@@ -0,0 +1,201 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"github.com/gorilla/mux"
+	"net/http"
+	"sso/internal/domain/models"
+	"sso/internal/lib/diff"
+	"sso/internal/storage"
+	"strconv"
+	"time"
+)
+
+// GetArticleRevisionsHandler returns the history of article text, the latest revision first.
+func (c *Core) GetArticleRevisionsHandler(w http.ResponseWriter, r *http.Request) {
+	const op = "core.GetArticleRevisionsHandler"
+
+	id, err := parseContentID(r)
+	if err != nil {
+		http.Error(w, "Invalid article id", http.StatusBadRequest)
+		return
+	}
+
+	revisions, err := c.revisionProvider.ArticleRevisions(r.Context(), id)
+	if err != nil {
+		writeRevisionError(w, op, err)
+		return
+	}
+
+	writeJSON(w, op, revisions)
+}
+
+// GetArticleRevisionHandler returns revision of article with its Markdown body.
+func (c *Core) GetArticleRevisionHandler(w http.ResponseWriter, r *http.Request) {
+	const op = "core.GetArticleRevisionHandler"
+
+	id, err := parseContentID(r)
+	if err != nil {
+		http.Error(w, "Invalid article id", http.StatusBadRequest)
+		return
+	}
+
+	number, err := strconv.ParseInt(mux.Vars(r)["rev"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid revision", http.StatusBadRequest)
+		return
+	}
+
+	revision, err := c.revisionProvider.ArticleRevision(r.Context(), id, number)
+	if err != nil {
+		writeRevisionError(w, op, err)
+		return
+	}
+
+	writeJSON(w, op, revision)
+}
+
+// DiffArticleRevisionsHandler compares revisions from and to of article line by line.
+// Any two revisions can be compared, from doesn't have to be the older one.
+func (c *Core) DiffArticleRevisionsHandler(w http.ResponseWriter, r *http.Request) {
+	const op = "core.DiffArticleRevisionsHandler"
+
+	id, err := parseContentID(r)
+	if err != nil {
+		http.Error(w, "Invalid article id", http.StatusBadRequest)
+		return
+	}
+
+	values := r.URL.Query()
+
+	fromNumber, err := strconv.ParseInt(values.Get("from"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid from revision", http.StatusBadRequest)
+		return
+	}
+
+	toNumber, err := strconv.ParseInt(values.Get("to"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid to revision", http.StatusBadRequest)
+		return
+	}
+
+	from, err := c.revisionProvider.ArticleRevision(r.Context(), id, fromNumber)
+	if err != nil {
+		writeRevisionError(w, op, err)
+		return
+	}
+
+	to, err := c.revisionProvider.ArticleRevision(r.Context(), id, toNumber)
+	if err != nil {
+		writeRevisionError(w, op, err)
+		return
+	}
+
+	writeJSON(w, op, models.RevisionDiff{
+		From:        from.Number,
+		To:          to.Number,
+		Name:        diffLines(from.Name, to.Name),
+		Description: diffLines(from.Description, to.Description),
+		Body:        diffLines(from.Body, to.Body),
+	})
+}
+
+func diffLines(from string, to string) []models.DiffLine {
+	lines := []models.DiffLine{}
+	for _, line := range diff.Lines(from, to) {
+		lines = append(lines, models.DiffLine{Op: string(line.Op), Text: line.Text})
+	}
+
+	return lines
+}
+
+// RestoreArticleRevisionHandler brings text of revision back to article. The restored text is saved
+// as a new revision, so the history stays complete and the restore itself can be undone.
+func (c *Core) RestoreArticleRevisionHandler(w http.ResponseWriter, r *http.Request) {
+	const op = "core.RestoreArticleRevisionHandler"
+
+	uid, ok := r.Context().Value("uid").(int64)
+	if !ok {
+		http.Error(w, "UID not found in context", http.StatusInternalServerError)
+		return
+	}
+
+	id, err := parseContentID(r)
+	if err != nil {
+		http.Error(w, "Invalid article id", http.StatusBadRequest)
+		return
+	}
+
+	number, err := strconv.ParseInt(mux.Vars(r)["rev"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid revision", http.StatusBadRequest)
+		return
+	}
+
+	version, err := ifMatchVersion(r)
+	if err != nil {
+		writeContentEditError(w, op, err, storage.ErrArticleNotFound)
+		return
+	}
+
+	article, err := c.articleEditor.EditableArticle(r.Context(), id)
+	if err != nil {
+		writeContentEditError(w, op, err, storage.ErrArticleNotFound)
+		return
+	}
+
+	if article.Version != version {
+		w.Header().Set("ETag", contentETag(article.Version))
+		writeContentEditError(w, op, errPreconditionFailed, storage.ErrArticleNotFound)
+		return
+	}
+
+	if err := c.checkPublishedEdit(r, article.Status); err != nil {
+		writeContentEditError(w, op, err, storage.ErrArticleNotFound)
+		return
+	}
+
+	revision, err := c.revisionProvider.ArticleRevision(r.Context(), id, number)
+	if err != nil {
+		writeRevisionError(w, op, err)
+		return
+	}
+
+	article.Name = revision.Name
+	article.Description = revision.Description
+	article.Body = revision.Body
+	article.UpdatedBy = &uid
+
+	if err := validateArticle(&article); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	articleFields(&article).touch(time.Now())
+
+	updated, err := c.articleEditor.UpdateArticle(r.Context(), article)
+	if err != nil {
+		writeContentEditError(w, op, err, storage.ErrArticleNotFound)
+		return
+	}
+
+	if err := renderArticle(&updated); err != nil {
+		http.Error(w, fmt.Sprintf("%s: %v", op, err), http.StatusInternalServerError)
+		return
+	}
+
+	writeContentVersion(w, op, http.StatusOK, updated.Version, updated)
+}
+
+func writeRevisionError(w http.ResponseWriter, op string, err error) {
+	switch {
+	case errors.Is(err, storage.ErrArticleNotFound):
+		http.Error(w, "Article not found", http.StatusNotFound)
+	case errors.Is(err, storage.ErrRevisionNotFound):
+		http.Error(w, "Revision not found", http.StatusNotFound)
+	default:
+		http.Error(w, fmt.Sprintf("%s: %v", op, err), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,65 @@
+package core
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"sso/internal/domain/models"
+	"strings"
+	"unicode/utf8"
+)
+
+const maxSearchQueryLength = 200
+
+var searchTypes = []string{models.ContentTypeArticle, models.ContentTypeCourse, models.ContentTypeFeed}
+
+// SearchHandler searches published articles, courses and feed by the q parameter.
+// Results can be limited to content types listed in the type parameter, separated by commas.
+func (c *Core) SearchHandler(w http.ResponseWriter, r *http.Request) {
+	const op = "core.SearchHandler"
+
+	values := r.URL.Query()
+
+	query := models.SearchQuery{
+		Query:  strings.TrimSpace(values.Get("q")),
+		Cursor: values.Get("cursor"),
+	}
+
+	if query.Query == "" {
+		http.Error(w, "Query is required", http.StatusBadRequest)
+		return
+	}
+
+	if utf8.RuneCountInString(query.Query) > maxSearchQueryLength {
+		http.Error(w, fmt.Sprintf("Query must be at most %d characters", maxSearchQueryLength), http.StatusBadRequest)
+		return
+	}
+
+	for _, v := range values["type"] {
+		for _, typ := range strings.Split(v, ",") {
+			if !slices.Contains(searchTypes, typ) {
+				http.Error(w, "Type must be article, course or feed", http.StatusBadRequest)
+				return
+			}
+
+			if !slices.Contains(query.Types, typ) {
+				query.Types = append(query.Types, typ)
+			}
+		}
+	}
+
+	limit, err := parseLimit(values.Get("limit"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	query.Limit = limit
+
+	results, err := c.searchProvider.Search(r.Context(), query)
+	if err != nil {
+		writeContentError(w, op, err)
+		return
+	}
+
+	writeJSON(w, op, results)
+}
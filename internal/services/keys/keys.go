@@ -0,0 +1,286 @@
+package keys
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sso/internal/domain/models"
+	"sso/internal/lib/jwt"
+	"sync"
+	"time"
+)
+
+const (
+	// refreshInterval limits how often keys are reloaded from storage.
+	// Keys may be rotated by another process sharing the same storage.
+	refreshInterval = 30 * time.Second
+
+	// missRefreshInterval limits reloads forced by tokens signed with unknown key.
+	missRefreshInterval = 5 * time.Second
+
+	// publishDelay is how long new key is published in JWKS before tokens are signed with it.
+	// Other instances see the key within refreshInterval and JWKS consumers cache it for another one.
+	publishDelay = 2 * refreshInterval
+)
+
+var ErrKeyNotFound = errors.New("signing key not found")
+
+type KeyStorage interface {
+	SigningKeys(ctx context.Context) ([]models.SigningKey, error)
+	RotateSigningKey(ctx context.Context, key models.SigningKey) error
+}
+
+type Keys struct {
+	log            *slog.Logger
+	keyStorage     KeyStorage
+	algorithm      string
+	rotationPeriod time.Duration
+	gracePeriod    time.Duration
+
+	mu       sync.RWMutex
+	keys     []jwt.Key // newest first
+	loadedAt time.Time
+}
+
+func New(
+	log *slog.Logger,
+	keyStorage KeyStorage,
+	algorithm string,
+	rotationPeriod time.Duration,
+	gracePeriod time.Duration,
+) *Keys {
+	return &Keys{
+		log:            log,
+		keyStorage:     keyStorage,
+		algorithm:      algorithm,
+		rotationPeriod: rotationPeriod,
+		gracePeriod:    gracePeriod,
+	}
+}
+
+// SigningKey returns the active key.
+// New key is generated when the newest one is older than rotation period, it is published
+// for publishDelay before it signs tokens. The previous key stays valid for verification during grace period.
+func (k *Keys) SigningKey(ctx context.Context) (jwt.Key, error) {
+	const op = "keys.SigningKey"
+
+	if err := k.refresh(ctx, refreshInterval); err != nil {
+		return jwt.Key{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	k.mu.RLock()
+	key, ok := k.active()
+	k.mu.RUnlock()
+
+	if ok {
+		return key, nil
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if err := k.load(ctx); err != nil {
+		return jwt.Key{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if key, ok := k.active(); ok {
+		return key, nil
+	}
+
+	if err := k.rotate(ctx); err != nil {
+		return jwt.Key{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	key, ok = k.active()
+	if !ok {
+		return jwt.Key{}, fmt.Errorf("%s: %w", op, ErrKeyNotFound)
+	}
+
+	return key, nil
+}
+
+// VerificationKey returns key by id if it's active or was retired less than grace period ago.
+func (k *Keys) VerificationKey(ctx context.Context, kid string) (jwt.Key, error) {
+	const op = "keys.VerificationKey"
+
+	if err := k.refresh(ctx, refreshInterval); err != nil {
+		return jwt.Key{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if key, ok := k.find(kid); ok {
+		return key, nil
+	}
+
+	// Key may have been generated by another process after the last reload.
+	if err := k.refresh(ctx, missRefreshInterval); err != nil {
+		return jwt.Key{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if key, ok := k.find(kid); ok {
+		return key, nil
+	}
+
+	return jwt.Key{}, fmt.Errorf("%s: %w", op, ErrKeyNotFound)
+}
+
+func (k *Keys) find(kid string) (jwt.Key, bool) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	for _, key := range k.keys {
+		if key.ID == kid && k.valid(key) {
+			return key, true
+		}
+	}
+
+	return jwt.Key{}, false
+}
+
+// JWKS returns public parts of all keys valid for verification.
+func (k *Keys) JWKS(ctx context.Context) (jwt.JWKS, error) {
+	const op = "keys.JWKS"
+
+	// Make sure there is at least one key to publish.
+	if _, err := k.SigningKey(ctx); err != nil {
+		return jwt.JWKS{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	jwks := jwt.JWKS{Keys: make([]jwt.JWK, 0, len(k.keys))}
+	for _, key := range k.keys {
+		if !k.valid(key) {
+			continue
+		}
+
+		jwk, err := key.JWK()
+		if err != nil {
+			return jwt.JWKS{}, fmt.Errorf("%s: %w", op, err)
+		}
+
+		jwks.Keys = append(jwks.Keys, jwk)
+	}
+
+	return jwks, nil
+}
+
+func (k *Keys) JWKSHandler(w http.ResponseWriter, r *http.Request) {
+	const op = "keys.JWKSHandler"
+
+	jwks, err := k.JWKS(r.Context())
+	if err != nil {
+		http.Error(w, fmt.Sprintf("%s: %v", op, err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(refreshInterval.Seconds())))
+	if err := json.NewEncoder(w).Encode(jwks); err != nil {
+		http.Error(w, fmt.Sprintf("%s: %v", op, err), http.StatusInternalServerError)
+		return
+	}
+}
+
+// refresh reloads keys from storage if they were loaded more than maxAge ago.
+func (k *Keys) refresh(ctx context.Context, maxAge time.Duration) error {
+	k.mu.RLock()
+	fresh := time.Since(k.loadedAt) < maxAge
+	k.mu.RUnlock()
+
+	if fresh {
+		return nil
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	// Keys may have been reloaded while waiting for the lock.
+	if time.Since(k.loadedAt) < maxAge {
+		return nil
+	}
+
+	return k.load(ctx)
+}
+
+// load must be called with write lock held.
+func (k *Keys) load(ctx context.Context) error {
+	stored, err := k.keyStorage.SigningKeys(ctx)
+	if err != nil {
+		return err
+	}
+
+	keys := make([]jwt.Key, 0, len(stored))
+	for _, s := range stored {
+		key, err := jwt.ParseKey(s)
+		if err != nil {
+			return err
+		}
+
+		if k.valid(key) {
+			keys = append(keys, key)
+		}
+	}
+
+	k.keys = keys
+	k.loadedAt = time.Now()
+
+	return nil
+}
+
+// rotate must be called with write lock held.
+func (k *Keys) rotate(ctx context.Context) error {
+	key, err := jwt.GenerateKey(k.algorithm)
+	if err != nil {
+		return err
+	}
+
+	if err := k.keyStorage.RotateSigningKey(ctx, key); err != nil {
+		return err
+	}
+
+	k.log.Info("signing key rotated",
+		slog.String("kid", key.ID),
+		slog.String("algorithm", key.Algorithm),
+	)
+
+	return k.load(ctx)
+}
+
+// active must be called with lock held. It returns false when the newest key has to be rotated.
+// Until the newest key has been published for publishDelay, the previous one keeps signing.
+func (k *Keys) active() (jwt.Key, bool) {
+	if len(k.keys) == 0 {
+		return jwt.Key{}, false
+	}
+
+	newest := k.keys[0]
+	if !newest.RetiredAt.IsZero() ||
+		newest.Algorithm != k.algorithm ||
+		time.Since(newest.CreatedAt) > k.rotationPeriod {
+		return jwt.Key{}, false
+	}
+
+	for _, key := range k.keys {
+		if time.Since(key.CreatedAt) >= publishDelay {
+			return key, true
+		}
+	}
+
+	// There is no published key to sign with yet, e.g. on the first start.
+	return k.keys[len(k.keys)-1], true
+}
+
+func (k *Keys) valid(key jwt.Key) bool {
+	// Retired key keeps signing for publishDelay, tokens signed at the end of it stay valid for grace period.
+	if !key.RetiredAt.IsZero() {
+		return time.Since(key.RetiredAt) < publishDelay+k.gracePeriod
+	}
+
+	// Active key which should have been rotated long ago is kept valid
+	// for grace period as if it was retired on schedule.
+	return time.Since(key.CreatedAt) < k.rotationPeriod+publishDelay+k.gracePeriod
+}
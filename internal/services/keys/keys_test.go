@@ -0,0 +1,143 @@
+package keys
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"sso/internal/domain/models"
+	"sso/internal/lib/jwt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryKeys is a key storage shared by several Keys, like a database shared by several processes.
+type memoryKeys struct {
+	mu    sync.Mutex
+	keys  []models.SigningKey // newest first
+	loads int
+}
+
+func (m *memoryKeys) SigningKeys(context.Context) ([]models.SigningKey, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.loads++
+
+	return append([]models.SigningKey(nil), m.keys...), nil
+}
+
+func (m *memoryKeys) RotateSigningKey(_ context.Context, key models.SigningKey) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i := range m.keys {
+		if m.keys[i].RetiredAt.IsZero() {
+			m.keys[i].RetiredAt = key.CreatedAt
+		}
+	}
+	m.keys = append([]models.SigningKey{key}, m.keys...)
+
+	return nil
+}
+
+func (m *memoryKeys) loadCount() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.loads
+}
+
+func newTestKeys(store *memoryKeys) *Keys {
+	return New(slog.New(slog.NewTextHandler(io.Discard, nil)), store, jwt.AlgEdDSA, time.Hour, time.Hour)
+}
+
+func generateKey(t *testing.T, age time.Duration) models.SigningKey {
+	t.Helper()
+
+	key, err := jwt.GenerateKey(jwt.AlgEdDSA)
+	require.NoError(t, err)
+	key.CreatedAt = time.Now().Add(-age)
+
+	return key
+}
+
+func TestSigningKey_FirstKeySignsAtOnce(t *testing.T) {
+	store := &memoryKeys{}
+
+	key, err := newTestKeys(store).SigningKey(context.Background())
+	require.NoError(t, err)
+
+	require.Len(t, store.keys, 1)
+	assert.Equal(t, store.keys[0].ID, key.ID)
+}
+
+func TestSigningKey_NewKeyPublishedBeforeSigning(t *testing.T) {
+	store := &memoryKeys{}
+	ctx := context.Background()
+
+	old := generateKey(t, 2*time.Hour)
+	require.NoError(t, store.RotateSigningKey(ctx, old))
+
+	k := newTestKeys(store)
+
+	// Old key is past rotation period, new one is generated but old one still signs.
+	key, err := k.SigningKey(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, old.ID, key.ID)
+	require.Len(t, store.keys, 2)
+
+	newest := store.keys[0]
+
+	jwks, err := k.JWKS(ctx)
+	require.NoError(t, err)
+	var kids []string
+	for _, jwk := range jwks.Keys {
+		kids = append(kids, jwk.Kid)
+	}
+	assert.ElementsMatch(t, []string{old.ID, newest.ID}, kids)
+
+	// Once the new key has been published long enough, it signs.
+	store.mu.Lock()
+	store.keys[0].CreatedAt = time.Now().Add(-publishDelay)
+	store.keys[1].RetiredAt = store.keys[0].CreatedAt
+	store.mu.Unlock()
+
+	key, err = newTestKeys(store).SigningKey(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, newest.ID, key.ID)
+}
+
+func TestVerificationKey_ReloadsOnUnknownKid(t *testing.T) {
+	store := &memoryKeys{}
+	ctx := context.Background()
+
+	require.NoError(t, store.RotateSigningKey(ctx, generateKey(t, time.Minute)))
+
+	k := newTestKeys(store)
+	_, err := k.SigningKey(ctx)
+	require.NoError(t, err)
+
+	// Another process rotates the key a bit later.
+	rotated := generateKey(t, 0)
+	require.NoError(t, store.RotateSigningKey(ctx, rotated))
+
+	k.mu.Lock()
+	k.loadedAt = time.Now().Add(-missRefreshInterval)
+	k.mu.Unlock()
+
+	key, err := k.VerificationKey(ctx, rotated.ID)
+	require.NoError(t, err)
+	assert.Equal(t, rotated.ID, key.ID)
+
+	// Unknown kids don't reload keys on every request.
+	loads := store.loadCount()
+	for range 10 {
+		_, err := k.VerificationKey(ctx, "unknown")
+		assert.ErrorIs(t, err, ErrKeyNotFound)
+	}
+	assert.Equal(t, loads, store.loadCount())
+}
@@ -0,0 +1,709 @@
+package oidc
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	_ "embed"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+	"sso/internal/domain/models"
+	"sso/internal/lib/clientip"
+	"sso/internal/lib/jwt"
+	"sso/internal/lib/logger/sl"
+	"sso/internal/lib/opaque"
+	"sso/internal/services/auth"
+	"sso/internal/services/throttle"
+	"sso/internal/storage"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	responseTypeCode    = "code"
+	scopeOpenID         = "openid"
+	codeChallengeMethod = "S256"
+
+	grantAuthorizationCode = "authorization_code"
+	grantRefreshToken      = "refresh_token"
+
+	// RFC 7636: code verifier is 43 to 128 characters long.
+	minVerifierLen = 43
+	maxVerifierLen = 128
+
+	// Login form carries a token which must match the cookie set along with the page.
+	csrfCookie = "oidc_csrf"
+	csrfField  = "csrf_token"
+	csrfTTL    = time.Hour
+)
+
+var ErrInvalidClient = errors.New("invalid client")
+
+//go:embed login.html
+var loginPageHTML string
+
+var loginPage = template.Must(template.New("login").Parse(loginPageHTML))
+
+type Authenticator interface {
+	Authenticate(ctx context.Context, email string, password string, client models.Client) (models.User, error)
+	VerifyTOTP(ctx context.Context, user models.User, code string, client models.Client) error
+	IssueTokens(ctx context.Context, user models.User, appID int, client models.Client) (models.TokenPair, error)
+	RefreshForApp(ctx context.Context, refreshToken string, appID int) (models.TokenPair, error)
+}
+
+type Storage interface {
+	AppProvider
+	UserProvider
+	CodeStorage
+}
+
+type AppProvider interface {
+	App(ctx context.Context, appID int) (models.App, error)
+}
+
+type UserProvider interface {
+	UserByID(ctx context.Context, userID int64) (models.User, error)
+}
+
+type CodeStorage interface {
+	SaveAuthorizationCode(ctx context.Context, code models.AuthorizationCode) error
+	UseAuthorizationCode(ctx context.Context, codeHash string) (models.AuthorizationCode, error)
+}
+
+type KeyProvider interface {
+	SigningKey(ctx context.Context) (jwt.Key, error)
+}
+
+// OIDC is an OpenID Connect provider supporting authorization code flow with PKCE.
+// Apps act as clients: app id is the client_id and app secret is the client_secret.
+type OIDC struct {
+	log              *slog.Logger
+	issuer           string
+	authenticator    Authenticator
+	appProvider      AppProvider
+	usrProvider      UserProvider
+	codeStorage      CodeStorage
+	keyProvider      KeyProvider
+	signingAlgorithm string
+	codeTTL          time.Duration
+	tokenTTL         time.Duration
+	// trustProxy makes client IP to be taken from X-Forwarded-For header.
+	trustProxy bool
+	// origin of the issuer, login form may only be posted from it.
+	origin string
+}
+
+type Config struct {
+	// Issuer is the public URL of the provider, endpoints are served under it.
+	Issuer           string
+	SigningAlgorithm string
+	CodeTTL          time.Duration
+	TokenTTL         time.Duration
+	// TrustProxy makes client IP to be taken from X-Forwarded-For header.
+	TrustProxy bool
+}
+
+func New(
+	log *slog.Logger,
+	authenticator Authenticator,
+	storage Storage,
+	keyProvider KeyProvider,
+	cfg Config,
+) *OIDC {
+	var origin string
+	if u, err := url.Parse(cfg.Issuer); err == nil {
+		origin = u.Scheme + "://" + u.Host
+	}
+
+	return &OIDC{
+		log:              log,
+		issuer:           strings.TrimSuffix(cfg.Issuer, "/"),
+		authenticator:    authenticator,
+		appProvider:      storage,
+		usrProvider:      storage,
+		codeStorage:      storage,
+		keyProvider:      keyProvider,
+		signingAlgorithm: cfg.SigningAlgorithm,
+		codeTTL:          cfg.CodeTTL,
+		tokenTTL:         cfg.TokenTTL,
+		trustProxy:       cfg.TrustProxy,
+		origin:           origin,
+	}
+}
+
+type discoveryDocument struct {
+	Issuer                            string   `json:"issuer"`
+	AuthorizationEndpoint             string   `json:"authorization_endpoint"`
+	TokenEndpoint                     string   `json:"token_endpoint"`
+	UserInfoEndpoint                  string   `json:"userinfo_endpoint"`
+	JWKSURI                           string   `json:"jwks_uri"`
+	ResponseTypesSupported            []string `json:"response_types_supported"`
+	GrantTypesSupported               []string `json:"grant_types_supported"`
+	SubjectTypesSupported             []string `json:"subject_types_supported"`
+	IDTokenSigningAlgValuesSupported  []string `json:"id_token_signing_alg_values_supported"`
+	ScopesSupported                   []string `json:"scopes_supported"`
+	TokenEndpointAuthMethodsSupported []string `json:"token_endpoint_auth_methods_supported"`
+	ClaimsSupported                   []string `json:"claims_supported"`
+	CodeChallengeMethodsSupported     []string `json:"code_challenge_methods_supported"`
+}
+
+func (o *OIDC) DiscoveryHandler(w http.ResponseWriter, r *http.Request) {
+	const op = "oidc.DiscoveryHandler"
+
+	doc := discoveryDocument{
+		Issuer:                            o.issuer,
+		AuthorizationEndpoint:             o.issuer + "/authorize",
+		TokenEndpoint:                     o.issuer + "/token",
+		UserInfoEndpoint:                  o.issuer + "/userinfo",
+		JWKSURI:                           o.issuer + "/.well-known/jwks.json",
+		ResponseTypesSupported:            []string{responseTypeCode},
+		GrantTypesSupported:               []string{grantAuthorizationCode, grantRefreshToken},
+		SubjectTypesSupported:             []string{"public"},
+		IDTokenSigningAlgValuesSupported:  []string{o.signingAlgorithm},
+		ScopesSupported:                   []string{scopeOpenID, "email", "profile"},
+		TokenEndpointAuthMethodsSupported: []string{"none", "client_secret_basic", "client_secret_post"},
+		ClaimsSupported:                   []string{"sub", "iss", "aud", "exp", "iat", "auth_time", "nonce", "email", "name", "picture"},
+		CodeChallengeMethodsSupported:     []string{codeChallengeMethod},
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(doc); err != nil {
+		http.Error(w, fmt.Sprintf("%s: %v", op, err), http.StatusInternalServerError)
+		return
+	}
+}
+
+type authorizeRequest struct {
+	ResponseType        string
+	ClientID            string
+	RedirectURI         string
+	Scope               string
+	State               string
+	Nonce               string
+	CodeChallenge       string
+	CodeChallengeMethod string
+}
+
+func parseAuthorizeRequest(r *http.Request) authorizeRequest {
+	return authorizeRequest{
+		ResponseType:        r.Form.Get("response_type"),
+		ClientID:            r.Form.Get("client_id"),
+		RedirectURI:         r.Form.Get("redirect_uri"),
+		Scope:               r.Form.Get("scope"),
+		State:               r.Form.Get("state"),
+		Nonce:               r.Form.Get("nonce"),
+		CodeChallenge:       r.Form.Get("code_challenge"),
+		CodeChallengeMethod: r.Form.Get("code_challenge_method"),
+	}
+}
+
+// validate returns OAuth error code and description if request is malformed.
+func (req authorizeRequest) validate() (string, string) {
+	if req.ResponseType != responseTypeCode {
+		return "unsupported_response_type", "only response_type=code is supported"
+	}
+
+	if !slices.Contains(strings.Fields(req.Scope), scopeOpenID) {
+		return "invalid_scope", "openid scope is required"
+	}
+
+	if req.CodeChallenge == "" || req.CodeChallengeMethod != codeChallengeMethod {
+		return "invalid_request", "PKCE with S256 code challenge is required"
+	}
+
+	return "", ""
+}
+
+// AuthorizeHandler shows login page on GET and issues authorization code on POST.
+func (o *OIDC) AuthorizeHandler(w http.ResponseWriter, r *http.Request) {
+	const op = "oidc.AuthorizeHandler"
+
+	log := o.log.With(slog.String("op", op))
+
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "invalid request", http.StatusBadRequest)
+		return
+	}
+
+	req := parseAuthorizeRequest(r)
+
+	// Client and redirect URI are checked before anything else:
+	// errors must not be redirected to an unregistered URI.
+	app, err := o.client(r.Context(), req.ClientID, req.RedirectURI)
+	if err != nil {
+		if errors.Is(err, ErrInvalidClient) {
+			http.Error(w, "invalid client_id or redirect_uri", http.StatusBadRequest)
+			return
+		}
+
+		log.Error("failed to get client", sl.Err(err))
+		http.Error(w, fmt.Sprintf("%s: %v", op, err), http.StatusInternalServerError)
+		return
+	}
+
+	if code, description := req.validate(); code != "" {
+		redirectWithParams(w, r, req.RedirectURI, url.Values{
+			"error":             {code},
+			"error_description": {description},
+			"state":             {req.State},
+		})
+		return
+	}
+
+	if r.Method == http.MethodGet {
+		o.renderLogin(w, http.StatusOK, app, req, "", "")
+		return
+	}
+
+	email := r.PostForm.Get("email")
+
+	if !o.validCSRF(r) {
+		log.Warn("login form posted without valid csrf token")
+		o.renderLogin(w, http.StatusForbidden, app, req, email, "Страница входа устарела, попробуйте войти ещё раз")
+		return
+	}
+
+	client := models.Client{IP: clientip.FromHTTP(r, o.trustProxy)}
+
+	user, err := o.authenticator.Authenticate(r.Context(), email, r.PostForm.Get("password"), client)
+	if err != nil {
+		var blocked *throttle.BlockedError
+		if errors.As(err, &blocked) {
+			o.renderBlocked(w, app, req, email, blocked)
+			return
+		}
+
+		if errors.Is(err, auth.ErrInvalidCredentials) {
+			o.renderLogin(w, http.StatusUnauthorized, app, req, email, "Неверный email или пароль")
+			return
+		}
+
+		if errors.Is(err, auth.ErrEmailNotVerified) {
+			o.renderLogin(w, http.StatusForbidden, app, req, email, "Подтвердите email по ссылке из письма, чтобы войти")
+			return
+		}
+
+		log.Error("failed to authenticate user", sl.Err(err))
+		http.Error(w, fmt.Sprintf("%s: %v", op, err), http.StatusInternalServerError)
+		return
+	}
+
+	if user.MFAEnabled {
+		otp := r.PostForm.Get("otp")
+		if otp == "" {
+			o.renderLogin(w, http.StatusUnauthorized, app, req, email, "Введите код двухфакторной аутентификации")
+			return
+		}
+
+		if err := o.authenticator.VerifyTOTP(r.Context(), user, otp, client); err != nil {
+			var blocked *throttle.BlockedError
+			if errors.As(err, &blocked) {
+				o.renderBlocked(w, app, req, email, blocked)
+				return
+			}
+
+			if errors.Is(err, auth.ErrInvalidMFACode) {
+				o.renderLogin(w, http.StatusUnauthorized, app, req, email, "Неверный код двухфакторной аутентификации")
+				return
+			}
+
+			log.Error("failed to verify second factor", sl.Err(err))
+			http.Error(w, fmt.Sprintf("%s: %v", op, err), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	code, err := opaque.New()
+	if err != nil {
+		http.Error(w, fmt.Sprintf("%s: %v", op, err), http.StatusInternalServerError)
+		return
+	}
+
+	err = o.codeStorage.SaveAuthorizationCode(r.Context(), models.AuthorizationCode{
+		CodeHash:            opaque.Hash(code),
+		AppID:               app.ID,
+		UserID:              user.ID,
+		RedirectURI:         req.RedirectURI,
+		Scope:               req.Scope,
+		Nonce:               req.Nonce,
+		CodeChallenge:       req.CodeChallenge,
+		CodeChallengeMethod: req.CodeChallengeMethod,
+		ExpiresAt:           time.Now().Add(o.codeTTL),
+	})
+	if err != nil {
+		log.Error("failed to save authorization code", sl.Err(err))
+		http.Error(w, fmt.Sprintf("%s: %v", op, err), http.StatusInternalServerError)
+		return
+	}
+
+	log.Info("authorization code issued", slog.Int64("uid", user.ID), slog.Int("app_id", app.ID))
+
+	redirectWithParams(w, r, req.RedirectURI, url.Values{
+		"code":  {code},
+		"state": {req.State},
+	})
+}
+
+type tokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int    `json:"expires_in"`
+	RefreshToken string `json:"refresh_token"`
+	IDToken      string `json:"id_token,omitempty"`
+	Scope        string `json:"scope,omitempty"`
+}
+
+// TokenHandler exchanges authorization code or refresh token for tokens.
+func (o *OIDC) TokenHandler(w http.ResponseWriter, r *http.Request) {
+	const op = "oidc.TokenHandler"
+
+	if err := r.ParseForm(); err != nil {
+		writeTokenError(w, http.StatusBadRequest, "invalid_request", "malformed form")
+		return
+	}
+
+	app, err := o.authenticateClient(r)
+	if err != nil {
+		if errors.Is(err, ErrInvalidClient) {
+			writeTokenError(w, http.StatusUnauthorized, "invalid_client", "client authentication failed")
+			return
+		}
+
+		o.log.Error("failed to authenticate client", slog.String("op", op), sl.Err(err))
+		writeTokenError(w, http.StatusInternalServerError, "server_error", "")
+		return
+	}
+
+	switch r.PostForm.Get("grant_type") {
+	case grantAuthorizationCode:
+		o.exchangeCode(w, r, app)
+	case grantRefreshToken:
+		o.refresh(w, r, app)
+	default:
+		writeTokenError(w, http.StatusBadRequest, "unsupported_grant_type", "")
+	}
+}
+
+func (o *OIDC) exchangeCode(w http.ResponseWriter, r *http.Request, app models.App) {
+	const op = "oidc.exchangeCode"
+
+	log := o.log.With(slog.String("op", op), slog.Int("app_id", app.ID))
+
+	code, err := o.codeStorage.UseAuthorizationCode(r.Context(), opaque.Hash(r.PostForm.Get("code")))
+	if err != nil {
+		if errors.Is(err, storage.ErrAuthCodeNotFound) {
+			writeTokenError(w, http.StatusBadRequest, "invalid_grant", "invalid authorization code")
+			return
+		}
+
+		log.Error("failed to use authorization code", sl.Err(err))
+		writeTokenError(w, http.StatusInternalServerError, "server_error", "")
+		return
+	}
+
+	if code.AppID != app.ID ||
+		code.RedirectURI != r.PostForm.Get("redirect_uri") ||
+		time.Now().After(code.ExpiresAt) {
+		writeTokenError(w, http.StatusBadRequest, "invalid_grant", "invalid authorization code")
+		return
+	}
+
+	if !verifyCodeChallenge(r.PostForm.Get("code_verifier"), code.CodeChallenge) {
+		writeTokenError(w, http.StatusBadRequest, "invalid_grant", "invalid code_verifier")
+		return
+	}
+
+	user, err := o.usrProvider.UserByID(r.Context(), code.UserID)
+	if err != nil {
+		if errors.Is(err, storage.ErrUserNotFound) {
+			writeTokenError(w, http.StatusBadRequest, "invalid_grant", "user not found")
+			return
+		}
+
+		log.Error("failed to get user", sl.Err(err))
+		writeTokenError(w, http.StatusInternalServerError, "server_error", "")
+		return
+	}
+
+	// Token request is made by the app backend, not by the user's device,
+	// so the session is only labeled with the app name.
+	tokens, err := o.authenticator.IssueTokens(r.Context(), user, app.ID, models.Client{DeviceName: app.Name})
+	if err != nil {
+		log.Error("failed to issue tokens", sl.Err(err))
+		writeTokenError(w, http.StatusInternalServerError, "server_error", "")
+		return
+	}
+
+	key, err := o.keyProvider.SigningKey(r.Context())
+	if err != nil {
+		log.Error("failed to get signing key", sl.Err(err))
+		writeTokenError(w, http.StatusInternalServerError, "server_error", "")
+		return
+	}
+
+	idToken, err := jwt.NewIDToken(user, app, key, o.issuer, code.Nonce, o.tokenTTL)
+	if err != nil {
+		log.Error("failed to create id token", sl.Err(err))
+		writeTokenError(w, http.StatusInternalServerError, "server_error", "")
+		return
+	}
+
+	writeToken(w, tokenResponse{
+		AccessToken:  tokens.AccessToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int(o.tokenTTL.Seconds()),
+		RefreshToken: tokens.RefreshToken,
+		IDToken:      idToken,
+		Scope:        code.Scope,
+	})
+}
+
+func (o *OIDC) refresh(w http.ResponseWriter, r *http.Request, app models.App) {
+	const op = "oidc.refresh"
+
+	tokens, err := o.authenticator.RefreshForApp(r.Context(), r.PostForm.Get("refresh_token"), app.ID)
+	if err != nil {
+		if errors.Is(err, auth.ErrInvalidRefreshToken) || errors.Is(err, auth.ErrRefreshTokenReused) {
+			writeTokenError(w, http.StatusBadRequest, "invalid_grant", "invalid refresh token")
+			return
+		}
+
+		o.log.Error("failed to refresh tokens", slog.String("op", op), sl.Err(err))
+		writeTokenError(w, http.StatusInternalServerError, "server_error", "")
+		return
+	}
+
+	writeToken(w, tokenResponse{
+		AccessToken:  tokens.AccessToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int(o.tokenTTL.Seconds()),
+		RefreshToken: tokens.RefreshToken,
+	})
+}
+
+type userInfo struct {
+	Sub     string `json:"sub"`
+	Email   string `json:"email"`
+	Name    string `json:"name"`
+	Picture string `json:"picture,omitempty"`
+}
+
+// UserInfoHandler returns claims about the user authenticated by AuthMiddleware.
+func (o *OIDC) UserInfoHandler(w http.ResponseWriter, r *http.Request) {
+	const op = "oidc.UserInfoHandler"
+
+	uid, ok := r.Context().Value("uid").(int64)
+	if !ok {
+		http.Error(w, "UID not found in context", http.StatusInternalServerError)
+		return
+	}
+
+	user, err := o.usrProvider.UserByID(r.Context(), uid)
+	if err != nil {
+		if errors.Is(err, storage.ErrUserNotFound) {
+			http.Error(w, "user not found", http.StatusUnauthorized)
+			return
+		}
+
+		http.Error(w, fmt.Sprintf("%s: %v", op, err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(userInfo{
+		Sub:     strconv.FormatInt(user.ID, 10),
+		Email:   user.Email,
+		Name:    user.Name,
+		Picture: user.Image,
+	}); err != nil {
+		http.Error(w, fmt.Sprintf("%s: %v", op, err), http.StatusInternalServerError)
+		return
+	}
+}
+
+// client returns app registered with the given client_id and redirect URI.
+func (o *OIDC) client(ctx context.Context, clientID string, redirectURI string) (models.App, error) {
+	app, err := o.app(ctx, clientID)
+	if err != nil {
+		return models.App{}, err
+	}
+
+	if !slices.Contains(app.RedirectURIs, redirectURI) {
+		return models.App{}, ErrInvalidClient
+	}
+
+	return app, nil
+}
+
+// authenticateClient checks client credentials passed with HTTP Basic auth or in the form.
+// Public clients have no secret, send only client_id and are protected by PKCE.
+// Confidential clients must always send their secret.
+func (o *OIDC) authenticateClient(r *http.Request) (models.App, error) {
+	clientID, secret, ok := r.BasicAuth()
+	if !ok {
+		clientID = r.PostForm.Get("client_id")
+		secret = r.PostForm.Get("client_secret")
+	}
+
+	app, err := o.app(r.Context(), clientID)
+	if err != nil {
+		return models.App{}, err
+	}
+
+	if subtle.ConstantTimeCompare([]byte(secret), []byte(app.Secret)) != 1 {
+		return models.App{}, ErrInvalidClient
+	}
+
+	return app, nil
+}
+
+func (o *OIDC) app(ctx context.Context, clientID string) (models.App, error) {
+	appID, err := strconv.Atoi(clientID)
+	if err != nil {
+		return models.App{}, ErrInvalidClient
+	}
+
+	app, err := o.appProvider.App(ctx, appID)
+	if err != nil {
+		if errors.Is(err, storage.ErrAppNotFound) {
+			return models.App{}, ErrInvalidClient
+		}
+
+		return models.App{}, err
+	}
+
+	return app, nil
+}
+
+func (o *OIDC) renderLogin(
+	w http.ResponseWriter,
+	status int,
+	app models.App,
+	req authorizeRequest,
+	email string,
+	errMessage string,
+) {
+	csrfToken, err := opaque.New()
+	if err != nil {
+		http.Error(w, fmt.Sprintf("oidc.renderLogin: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     csrfCookie,
+		Value:    csrfToken,
+		Path:     "/authorize",
+		MaxAge:   int(csrfTTL.Seconds()),
+		Secure:   strings.HasPrefix(o.issuer, "https://"),
+		HttpOnly: true,
+		SameSite: http.SameSiteStrictMode,
+	})
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("X-Frame-Options", "DENY")
+	w.Header().Set("Content-Security-Policy", "frame-ancestors 'none'")
+	w.WriteHeader(status)
+
+	err = loginPage.Execute(w, struct {
+		AppName   string
+		Email     string
+		Error     string
+		CSRFToken string
+		Request   authorizeRequest
+	}{
+		AppName:   app.Name,
+		Email:     email,
+		Error:     errMessage,
+		CSRFToken: csrfToken,
+		Request:   req,
+	})
+	if err != nil {
+		o.log.Error("failed to render login page", sl.Err(err))
+	}
+}
+
+// validCSRF reports whether login form was posted from the page rendered by renderLogin,
+// so a third-party page can't sign the browser in to another account.
+func (o *OIDC) validCSRF(r *http.Request) bool {
+	if origin := r.Header.Get("Origin"); origin != "" && origin != o.origin {
+		return false
+	}
+
+	cookie, err := r.Cookie(csrfCookie)
+	if err != nil || cookie.Value == "" {
+		return false
+	}
+
+	return subtle.ConstantTimeCompare([]byte(cookie.Value), []byte(r.PostForm.Get(csrfField))) == 1
+}
+
+// renderBlocked shows login page telling when the next attempt is allowed.
+func (o *OIDC) renderBlocked(
+	w http.ResponseWriter,
+	app models.App,
+	req authorizeRequest,
+	email string,
+	blocked *throttle.BlockedError,
+) {
+	seconds := max(int(blocked.RetryAfter.Round(time.Second).Seconds()), 1)
+	w.Header().Set("Retry-After", strconv.Itoa(seconds))
+	o.renderLogin(w, http.StatusTooManyRequests, app, req, email,
+		fmt.Sprintf("Слишком много попыток входа, попробуйте снова через %d с", seconds))
+}
+
+func verifyCodeChallenge(verifier string, challenge string) bool {
+	if len(verifier) < minVerifierLen || len(verifier) > maxVerifierLen {
+		return false
+	}
+
+	sum := sha256.Sum256([]byte(verifier))
+	expected := base64.RawURLEncoding.EncodeToString(sum[:])
+
+	return subtle.ConstantTimeCompare([]byte(expected), []byte(challenge)) == 1
+}
+
+func redirectWithParams(w http.ResponseWriter, r *http.Request, redirectURI string, params url.Values) {
+	u, err := url.Parse(redirectURI)
+	if err != nil {
+		http.Error(w, "invalid redirect_uri", http.StatusBadRequest)
+		return
+	}
+
+	q := u.Query()
+	for key, values := range params {
+		if len(values) > 0 && values[0] != "" {
+			q.Set(key, values[0])
+		}
+	}
+	u.RawQuery = q.Encode()
+
+	http.Redirect(w, r, u.String(), http.StatusFound)
+}
+
+func writeToken(w http.ResponseWriter, resp tokenResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+func writeTokenError(w http.ResponseWriter, status int, code string, description string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(struct {
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description,omitempty"`
+	}{
+		Error:            code,
+		ErrorDescription: description,
+	})
+}
@@ -0,0 +1,583 @@
+package oidc
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"sso/internal/domain/models"
+	"sso/internal/lib/jwt"
+	"sso/internal/services/auth"
+	"sso/internal/storage"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	gojwt "github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testIssuer   = "https://sso.example"
+	testEmail    = "user@example.com"
+	testPassword = "password"
+
+	confidentialRedirect = "https://app.example/callback"
+	publicRedirect       = "https://spa.example/callback"
+)
+
+var (
+	confidentialApp = models.App{ID: 1, Name: "app", Secret: "app-secret", RedirectURIs: []string{confidentialRedirect}}
+	publicApp       = models.App{ID: 2, Name: "spa", RedirectURIs: []string{publicRedirect}}
+	testUser        = models.User{ID: 42, Email: testEmail, Name: "User"}
+)
+
+type fakeAuthenticator struct {
+	// refreshTokens maps refresh token to the app it was issued to.
+	refreshTokens map[string]int
+}
+
+func (f *fakeAuthenticator) Authenticate(_ context.Context, email string, password string, _ models.Client) (models.User, error) {
+	if email != testEmail || password != testPassword {
+		return models.User{}, auth.ErrInvalidCredentials
+	}
+
+	return testUser, nil
+}
+
+func (f *fakeAuthenticator) VerifyTOTP(context.Context, models.User, string, models.Client) error {
+	return auth.ErrMFANotEnabled
+}
+
+func (f *fakeAuthenticator) IssueTokens(_ context.Context, _ models.User, appID int, _ models.Client) (models.TokenPair, error) {
+	refreshToken := "refresh-" + strconv.Itoa(appID)
+	f.refreshTokens[refreshToken] = appID
+
+	return models.TokenPair{AccessToken: "access", RefreshToken: refreshToken}, nil
+}
+
+func (f *fakeAuthenticator) RefreshForApp(_ context.Context, refreshToken string, appID int) (models.TokenPair, error) {
+	if issuedTo, ok := f.refreshTokens[refreshToken]; !ok || issuedTo != appID {
+		return models.TokenPair{}, auth.ErrInvalidRefreshToken
+	}
+
+	return models.TokenPair{AccessToken: "access", RefreshToken: refreshToken}, nil
+}
+
+type fakeApps map[int]models.App
+
+func (f fakeApps) App(_ context.Context, appID int) (models.App, error) {
+	app, ok := f[appID]
+	if !ok {
+		return models.App{}, storage.ErrAppNotFound
+	}
+
+	return app, nil
+}
+
+type fakeUsers map[int64]models.User
+
+func (f fakeUsers) UserByID(_ context.Context, userID int64) (models.User, error) {
+	user, ok := f[userID]
+	if !ok {
+		return models.User{}, storage.ErrUserNotFound
+	}
+
+	return user, nil
+}
+
+type fakeCodes struct {
+	mu    sync.Mutex
+	codes map[string]models.AuthorizationCode
+}
+
+func (f *fakeCodes) SaveAuthorizationCode(_ context.Context, code models.AuthorizationCode) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.codes[code.CodeHash] = code
+
+	return nil
+}
+
+func (f *fakeCodes) UseAuthorizationCode(_ context.Context, codeHash string) (models.AuthorizationCode, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	code, ok := f.codes[codeHash]
+	if !ok {
+		return models.AuthorizationCode{}, storage.ErrAuthCodeNotFound
+	}
+	delete(f.codes, codeHash)
+
+	return code, nil
+}
+
+// expireAll moves expiration of all stored codes to the past.
+func (f *fakeCodes) expireAll() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for hash, code := range f.codes {
+		code.ExpiresAt = time.Now().Add(-time.Second)
+		f.codes[hash] = code
+	}
+}
+
+type fakeKeys struct {
+	key jwt.Key
+}
+
+func (f fakeKeys) SigningKey(context.Context) (jwt.Key, error) {
+	return f.key, nil
+}
+
+type fakeStorage struct {
+	fakeApps
+	fakeUsers
+	*fakeCodes
+}
+
+type testProvider struct {
+	*OIDC
+	codes *fakeCodes
+	key   jwt.Key
+}
+
+func newTestProvider(t *testing.T) testProvider {
+	t.Helper()
+
+	signingKey, err := jwt.GenerateKey(jwt.AlgEdDSA)
+	require.NoError(t, err)
+	key, err := jwt.ParseKey(signingKey)
+	require.NoError(t, err)
+
+	codes := &fakeCodes{codes: map[string]models.AuthorizationCode{}}
+
+	o := New(
+		slog.New(slog.NewTextHandler(io.Discard, nil)),
+		&fakeAuthenticator{refreshTokens: map[string]int{}},
+		fakeStorage{
+			fakeApps:  fakeApps{confidentialApp.ID: confidentialApp, publicApp.ID: publicApp},
+			fakeUsers: fakeUsers{testUser.ID: testUser},
+			fakeCodes: codes,
+		},
+		fakeKeys{key: key},
+		Config{
+			Issuer:           testIssuer,
+			SigningAlgorithm: jwt.AlgEdDSA,
+			CodeTTL:          time.Minute,
+			TokenTTL:         time.Hour,
+		},
+	)
+
+	return testProvider{OIDC: o, codes: codes, key: key}
+}
+
+func codeChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+var testVerifier = strings.Repeat("v", minVerifierLen)
+
+func authorizeParams(app models.App, redirectURI string) url.Values {
+	return url.Values{
+		"response_type":         {responseTypeCode},
+		"client_id":             {strconv.Itoa(app.ID)},
+		"redirect_uri":          {redirectURI},
+		"scope":                 {"openid email"},
+		"state":                 {"state-1"},
+		"nonce":                 {"nonce-1"},
+		"code_challenge":        {codeChallenge(testVerifier)},
+		"code_challenge_method": {codeChallengeMethod},
+	}
+}
+
+var csrfFieldRe = regexp.MustCompile(`name="csrf_token" value="([^"]+)"`)
+
+// loginPage renders login page like a browser following the authorization link,
+// it returns the csrf cookie and form token, both empty if the page is not shown.
+func (p testProvider) loginPage(params url.Values) (*http.Cookie, string) {
+	w := httptest.NewRecorder()
+	p.AuthorizeHandler(w, httptest.NewRequest(http.MethodGet, "/authorize?"+params.Encode(), nil))
+
+	var cookie *http.Cookie
+	for _, c := range w.Result().Cookies() {
+		if c.Name == csrfCookie {
+			cookie = c
+		}
+	}
+
+	m := csrfFieldRe.FindStringSubmatch(w.Body.String())
+	if cookie == nil || m == nil {
+		return nil, ""
+	}
+
+	return cookie, m[1]
+}
+
+// authorize submits login form of the page rendered for params.
+func (p testProvider) authorize(params url.Values) *httptest.ResponseRecorder {
+	cookie, token := p.loginPage(params)
+
+	return p.postLogin(params, cookie, token, "")
+}
+
+func (p testProvider) postLogin(params url.Values, cookie *http.Cookie, token string, origin string) *httptest.ResponseRecorder {
+	form := url.Values{}
+	for key, values := range params {
+		form[key] = values
+	}
+	form.Set("email", testEmail)
+	form.Set("password", testPassword)
+	form.Set(csrfField, token)
+
+	r := httptest.NewRequest(http.MethodPost, "/authorize", strings.NewReader(form.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if cookie != nil {
+		r.AddCookie(cookie)
+	}
+	if origin != "" {
+		r.Header.Set("Origin", origin)
+	}
+	w := httptest.NewRecorder()
+	p.AuthorizeHandler(w, r)
+
+	return w
+}
+
+// issueCode runs authorization request for the app and returns the code from the redirect.
+func (p testProvider) issueCode(t *testing.T, app models.App, redirectURI string) string {
+	t.Helper()
+
+	w := p.authorize(authorizeParams(app, redirectURI))
+	require.Equal(t, http.StatusFound, w.Code, w.Body.String())
+
+	location, err := url.Parse(w.Header().Get("Location"))
+	require.NoError(t, err)
+	require.Equal(t, "state-1", location.Query().Get("state"))
+
+	code := location.Query().Get("code")
+	require.NotEmpty(t, code)
+
+	return code
+}
+
+func (p testProvider) token(form url.Values) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodPost, "/token", strings.NewReader(form.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	p.TokenHandler(w, r)
+
+	return w
+}
+
+func codeExchange(app models.App, code string, redirectURI string) url.Values {
+	return url.Values{
+		"grant_type":    {grantAuthorizationCode},
+		"code":          {code},
+		"redirect_uri":  {redirectURI},
+		"client_id":     {strconv.Itoa(app.ID)},
+		"client_secret": {app.Secret},
+		"code_verifier": {testVerifier},
+	}
+}
+
+func decodeTokenResponse(t *testing.T, w *httptest.ResponseRecorder) map[string]any {
+	t.Helper()
+
+	var body map[string]any
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+
+	return body
+}
+
+func assertTokenError(t *testing.T, w *httptest.ResponseRecorder, status int, code string) {
+	t.Helper()
+
+	assert.Equal(t, status, w.Code, w.Body.String())
+	assert.Equal(t, code, decodeTokenResponse(t, w)["error"])
+}
+
+func TestAuthorize_InvalidClientNotRedirected(t *testing.T) {
+	p := newTestProvider(t)
+
+	tests := []struct {
+		name        string
+		clientID    string
+		redirectURI string
+	}{
+		{name: "unregistered redirect_uri", clientID: "1", redirectURI: "https://evil.example/callback"},
+		{name: "redirect_uri of another client", clientID: "1", redirectURI: publicRedirect},
+		{name: "redirect_uri with extra path", clientID: "1", redirectURI: confidentialRedirect + "/x"},
+		{name: "empty redirect_uri", clientID: "1", redirectURI: ""},
+		{name: "unknown client", clientID: "99", redirectURI: confidentialRedirect},
+		{name: "malformed client_id", clientID: "app", redirectURI: confidentialRedirect},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params := authorizeParams(confidentialApp, tt.redirectURI)
+			params.Set("client_id", tt.clientID)
+
+			for _, method := range []string{http.MethodGet, http.MethodPost} {
+				var w *httptest.ResponseRecorder
+				if method == http.MethodGet {
+					w = httptest.NewRecorder()
+					p.AuthorizeHandler(w, httptest.NewRequest(http.MethodGet, "/authorize?"+params.Encode(), nil))
+				} else {
+					w = p.authorize(params)
+				}
+
+				assert.Equal(t, http.StatusBadRequest, w.Code, method)
+				assert.Empty(t, w.Header().Get("Location"), method)
+			}
+			assert.Empty(t, p.codes.codes)
+		})
+	}
+}
+
+func TestAuthorize_RequiresS256PKCE(t *testing.T) {
+	p := newTestProvider(t)
+
+	tests := []struct {
+		name   string
+		modify func(url.Values)
+	}{
+		{name: "missing challenge", modify: func(v url.Values) { v.Del("code_challenge") }},
+		{name: "missing method", modify: func(v url.Values) { v.Del("code_challenge_method") }},
+		{name: "plain method", modify: func(v url.Values) {
+			v.Set("code_challenge_method", "plain")
+			v.Set("code_challenge", testVerifier)
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params := authorizeParams(confidentialApp, confidentialRedirect)
+			tt.modify(params)
+
+			w := p.authorize(params)
+			require.Equal(t, http.StatusFound, w.Code)
+
+			location, err := url.Parse(w.Header().Get("Location"))
+			require.NoError(t, err)
+			assert.Equal(t, confidentialRedirect, location.Scheme+"://"+location.Host+location.Path)
+			assert.Equal(t, "invalid_request", location.Query().Get("error"))
+			assert.Equal(t, "state-1", location.Query().Get("state"))
+			assert.Empty(t, location.Query().Get("code"))
+			assert.Empty(t, p.codes.codes)
+		})
+	}
+}
+
+func TestToken_WrongCodeVerifier(t *testing.T) {
+	tests := []struct {
+		name     string
+		verifier string
+	}{
+		{name: "missing", verifier: ""},
+		{name: "other verifier", verifier: strings.Repeat("w", minVerifierLen)},
+		{name: "too short", verifier: testVerifier[:minVerifierLen-1]},
+		{name: "challenge instead of verifier", verifier: codeChallenge(testVerifier)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newTestProvider(t)
+			code := p.issueCode(t, confidentialApp, confidentialRedirect)
+
+			form := codeExchange(confidentialApp, code, confidentialRedirect)
+			form.Set("code_verifier", tt.verifier)
+
+			assertTokenError(t, p.token(form), http.StatusBadRequest, "invalid_grant")
+		})
+	}
+}
+
+func TestToken_CodeIsSingleUse(t *testing.T) {
+	p := newTestProvider(t)
+	code := p.issueCode(t, confidentialApp, confidentialRedirect)
+	form := codeExchange(confidentialApp, code, confidentialRedirect)
+
+	w := p.token(form)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	assertTokenError(t, p.token(form), http.StatusBadRequest, "invalid_grant")
+}
+
+func TestToken_ExpiredCode(t *testing.T) {
+	p := newTestProvider(t)
+	code := p.issueCode(t, confidentialApp, confidentialRedirect)
+	p.codes.expireAll()
+
+	assertTokenError(t, p.token(codeExchange(confidentialApp, code, confidentialRedirect)), http.StatusBadRequest, "invalid_grant")
+}
+
+func TestToken_CodeOfAnotherClient(t *testing.T) {
+	p := newTestProvider(t)
+	code := p.issueCode(t, confidentialApp, confidentialRedirect)
+
+	w := p.token(codeExchange(publicApp, code, confidentialRedirect))
+	assertTokenError(t, w, http.StatusBadRequest, "invalid_grant")
+
+	// The code is consumed by the failed attempt and can't be used by its owner either.
+	w = p.token(codeExchange(confidentialApp, code, confidentialRedirect))
+	assertTokenError(t, w, http.StatusBadRequest, "invalid_grant")
+}
+
+func TestToken_RedirectURIMismatch(t *testing.T) {
+	p := newTestProvider(t)
+	code := p.issueCode(t, confidentialApp, confidentialRedirect)
+
+	w := p.token(codeExchange(confidentialApp, code, "https://evil.example/callback"))
+	assertTokenError(t, w, http.StatusBadRequest, "invalid_grant")
+}
+
+func TestToken_ClientAuthentication(t *testing.T) {
+	tests := []struct {
+		name       string
+		app        models.App
+		redirect   string
+		secret     string
+		basicAuth  bool
+		wantStatus int
+	}{
+		{name: "confidential with secret in form", app: confidentialApp, redirect: confidentialRedirect, secret: confidentialApp.Secret, wantStatus: http.StatusOK},
+		{name: "confidential with basic auth", app: confidentialApp, redirect: confidentialRedirect, secret: confidentialApp.Secret, basicAuth: true, wantStatus: http.StatusOK},
+		{name: "confidential without secret", app: confidentialApp, redirect: confidentialRedirect, wantStatus: http.StatusUnauthorized},
+		{name: "confidential with wrong secret", app: confidentialApp, redirect: confidentialRedirect, secret: "wrong", wantStatus: http.StatusUnauthorized},
+		{name: "public without secret", app: publicApp, redirect: publicRedirect, wantStatus: http.StatusOK},
+		{name: "public with made up secret", app: publicApp, redirect: publicRedirect, secret: "secret", wantStatus: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newTestProvider(t)
+			code := p.issueCode(t, tt.app, tt.redirect)
+
+			form := codeExchange(tt.app, code, tt.redirect)
+			form.Del("client_secret")
+			if !tt.basicAuth && tt.secret != "" {
+				form.Set("client_secret", tt.secret)
+			}
+
+			r := httptest.NewRequest(http.MethodPost, "/token", strings.NewReader(form.Encode()))
+			r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			if tt.basicAuth {
+				r.SetBasicAuth(strconv.Itoa(tt.app.ID), tt.secret)
+			}
+			w := httptest.NewRecorder()
+			p.TokenHandler(w, r)
+
+			assert.Equal(t, tt.wantStatus, w.Code, w.Body.String())
+			if tt.wantStatus == http.StatusUnauthorized {
+				assert.Equal(t, "invalid_client", decodeTokenResponse(t, w)["error"])
+			}
+		})
+	}
+}
+
+func TestToken_RefreshTokenOfAnotherClient(t *testing.T) {
+	p := newTestProvider(t)
+	code := p.issueCode(t, confidentialApp, confidentialRedirect)
+
+	w := p.token(codeExchange(confidentialApp, code, confidentialRedirect))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	refreshToken := decodeTokenResponse(t, w)["refresh_token"].(string)
+
+	w = p.token(url.Values{
+		"grant_type":    {grantRefreshToken},
+		"refresh_token": {refreshToken},
+		"client_id":     {strconv.Itoa(publicApp.ID)},
+	})
+	assertTokenError(t, w, http.StatusBadRequest, "invalid_grant")
+
+	w = p.token(url.Values{
+		"grant_type":    {grantRefreshToken},
+		"refresh_token": {refreshToken},
+		"client_id":     {strconv.Itoa(confidentialApp.ID)},
+		"client_secret": {confidentialApp.Secret},
+	})
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+}
+
+func TestToken_IDTokenClaims(t *testing.T) {
+	p := newTestProvider(t)
+	code := p.issueCode(t, confidentialApp, confidentialRedirect)
+
+	w := p.token(codeExchange(confidentialApp, code, confidentialRedirect))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	body := decodeTokenResponse(t, w)
+	assert.Equal(t, "Bearer", body["token_type"])
+	assert.Equal(t, "openid email", body["scope"])
+
+	idToken, ok := body["id_token"].(string)
+	require.True(t, ok)
+
+	parsed, err := gojwt.Parse(idToken, func(*gojwt.Token) (any, error) {
+		return p.key.Public, nil
+	})
+	require.NoError(t, err)
+
+	claims := parsed.Claims.(gojwt.MapClaims)
+	assert.Equal(t, p.key.ID, parsed.Header["kid"])
+	assert.Equal(t, testIssuer, claims["iss"])
+	assert.Equal(t, "42", claims["sub"])
+	assert.Equal(t, strconv.Itoa(confidentialApp.ID), claims["aud"])
+	assert.Equal(t, "nonce-1", claims["nonce"])
+	assert.Equal(t, testEmail, claims["email"])
+}
+
+func TestAuthorize_RequiresCSRFToken(t *testing.T) {
+	p := newTestProvider(t)
+	params := authorizeParams(confidentialApp, confidentialRedirect)
+
+	cookie, token := p.loginPage(params)
+	require.NotNil(t, cookie)
+	require.NotEmpty(t, token)
+	assert.True(t, cookie.HttpOnly)
+	assert.True(t, cookie.Secure)
+	assert.Equal(t, http.SameSiteStrictMode, cookie.SameSite)
+
+	otherCookie, otherToken := p.loginPage(params)
+	require.NotEqual(t, token, otherToken, "each page gets its own token")
+
+	tests := []struct {
+		name   string
+		cookie *http.Cookie
+		token  string
+		origin string
+	}{
+		{name: "no token", cookie: nil, token: ""},
+		{name: "token without cookie", cookie: nil, token: token},
+		{name: "cookie without token", cookie: cookie, token: ""},
+		{name: "token of another page", cookie: cookie, token: otherToken},
+		{name: "posted from another site", cookie: otherCookie, token: otherToken, origin: "https://evil.example"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := p.postLogin(params, tt.cookie, tt.token, tt.origin)
+
+			assert.Equal(t, http.StatusForbidden, w.Code)
+			assert.Empty(t, w.Header().Get("Location"))
+			assert.Empty(t, p.codes.codes)
+		})
+	}
+
+	w := p.postLogin(params, cookie, token, testIssuer)
+	assert.Equal(t, http.StatusFound, w.Code, w.Body.String())
+}
@@ -0,0 +1,74 @@
+package scheduler
+
+import (
+	"context"
+	"log/slog"
+	"sso/internal/lib/logger/sl"
+	"time"
+)
+
+type Publisher interface {
+	PublishScheduled(ctx context.Context, now time.Time) (int64, error)
+}
+
+// Scheduler publishes content in review once its publish time comes.
+type Scheduler struct {
+	log       *slog.Logger
+	publisher Publisher
+	// interval is how often due content is looked for, content is published at most interval late.
+	interval time.Duration
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+func New(log *slog.Logger, publisher Publisher, interval time.Duration) *Scheduler {
+	return &Scheduler{
+		log:       log,
+		publisher: publisher,
+		interval:  interval,
+		stop:      make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+}
+
+// Run publishes due content every interval until Stop is called.
+func (s *Scheduler) Run() {
+	defer close(s.done)
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	s.log.Info("publishing scheduler is running", slog.Duration("interval", s.interval))
+
+	for {
+		s.publish()
+
+		select {
+		case <-ticker.C:
+		case <-s.stop:
+			return
+		}
+	}
+}
+
+// Stop stops the scheduler and waits for the running publication to finish.
+func (s *Scheduler) Stop() {
+	close(s.stop)
+	<-s.done
+}
+
+func (s *Scheduler) publish() {
+	const op = "scheduler.publish"
+
+	log := s.log.With(slog.String("op", op))
+
+	published, err := s.publisher.PublishScheduled(context.Background(), time.Now())
+	if err != nil {
+		log.Error("failed to publish scheduled content", sl.Err(err))
+		return
+	}
+
+	if published > 0 {
+		log.Info("scheduled content published", slog.Int64("count", published))
+	}
+}
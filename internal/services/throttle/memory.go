@@ -0,0 +1,39 @@
+package throttle
+
+import (
+	"context"
+	"sso/internal/domain/models"
+	"sso/internal/lib/cache"
+	"sso/internal/storage"
+	"time"
+)
+
+// MemoryStore keeps counters in process memory, they are lost on restart.
+type MemoryStore struct {
+	failures *cache.Cache[string, models.LoginFailures]
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{failures: cache.New[string, models.LoginFailures]()}
+}
+
+func (m *MemoryStore) LoginFailures(_ context.Context, key string) (models.LoginFailures, error) {
+	failures, ok := m.failures.Get(key)
+	if !ok {
+		return models.LoginFailures{}, storage.ErrLoginFailuresNotFound
+	}
+
+	return failures, nil
+}
+
+func (m *MemoryStore) SaveLoginFailures(_ context.Context, failures models.LoginFailures, expiresAt time.Time) error {
+	m.failures.Set(failures.Key, failures, time.Until(expiresAt))
+
+	return nil
+}
+
+func (m *MemoryStore) DeleteLoginFailures(_ context.Context, key string) error {
+	m.failures.Delete(key)
+
+	return nil
+}
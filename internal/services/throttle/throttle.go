@@ -0,0 +1,245 @@
+package throttle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sso/internal/domain/models"
+	"sso/internal/storage"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	ErrAccountLocked   = errors.New("account temporarily locked")
+	ErrTooManyAttempts = errors.New("too many login attempts")
+)
+
+// BlockedError is returned when login is refused before checking password.
+// It wraps ErrAccountLocked or ErrTooManyAttempts.
+type BlockedError struct {
+	Reason     error
+	RetryAfter time.Duration
+}
+
+func (e *BlockedError) Error() string {
+	return fmt.Sprintf("%v, retry after %s", e.Reason, e.RetryAfter.Round(time.Second))
+}
+
+func (e *BlockedError) Unwrap() error {
+	return e.Reason
+}
+
+// Policy describes how failed attempts for one kind of key are punished.
+type Policy struct {
+	// FreeAttempts is how many failures are allowed before delays start.
+	FreeAttempts int
+	// BaseDelay is the delay after the first punished failure, it doubles with every next one.
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	// LockoutAfter is the number of failures that blocks key for LockoutDuration, zero disables lockout.
+	LockoutAfter    int
+	LockoutDuration time.Duration
+}
+
+type Store interface {
+	LoginFailures(ctx context.Context, key string) (models.LoginFailures, error)
+	SaveLoginFailures(ctx context.Context, failures models.LoginFailures, expiresAt time.Time) error
+	DeleteLoginFailures(ctx context.Context, key string) error
+}
+
+// Throttle tracks failed logins per account and per client IP
+// and blocks further attempts with exponential backoff.
+type Throttle struct {
+	log     *slog.Logger
+	store   Store
+	account Policy
+	ip      Policy
+	// window is how long failures are remembered after the last one.
+	window time.Duration
+	// mu serializes read-modify-write of counters.
+	mu sync.Mutex
+}
+
+func New(log *slog.Logger, store Store, account Policy, ip Policy, window time.Duration) *Throttle {
+	return &Throttle{
+		log:     log,
+		store:   store,
+		account: account,
+		ip:      ip,
+		window:  window,
+	}
+}
+
+// Allow returns *BlockedError if login for email from ip must be refused right now.
+// Otherwise the attempt is reserved: it's counted as failed until Succeed refunds it,
+// so concurrent attempts can't get past the limits while their passwords are being checked.
+func (t *Throttle) Allow(ctx context.Context, email string, ip string) error {
+	const op = "throttle.Allow"
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+
+	keys := t.keys(email, ip)
+	counters := make([]models.LoginFailures, len(keys))
+
+	for i, k := range keys {
+		failures, err := t.store.LoginFailures(ctx, k.key)
+		if err != nil && !errors.Is(err, storage.ErrLoginFailuresNotFound) {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		if failures.BlockedUntil.After(now) {
+			return &BlockedError{
+				Reason:     k.reason,
+				RetryAfter: failures.BlockedUntil.Sub(now),
+			}
+		}
+
+		counters[i] = failures
+	}
+
+	for i, k := range keys {
+		failures := counters[i]
+		failures.Key = k.key
+		failures.Count++
+		failures.LastFailureAt = now
+
+		if delay := k.policy.delay(failures.Count); delay > 0 {
+			failures.BlockedUntil = now.Add(delay)
+
+			t.log.Warn("login blocked",
+				slog.String("op", op),
+				slog.String("key", k.key),
+				slog.Int("failures", failures.Count),
+				slog.Duration("delay", delay),
+			)
+		}
+
+		if err := t.save(ctx, failures, now); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	return nil
+}
+
+// Refund returns the attempt reserved by Allow when it turned out not to be a failure,
+// while login is not complete yet, e.g. password is correct but second factor is still to be checked.
+func (t *Throttle) Refund(ctx context.Context, email string, ip string) error {
+	const op = "throttle.Refund"
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for _, k := range t.keys(email, ip) {
+		if err := t.refund(ctx, k); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	return nil
+}
+
+// Succeed refunds the attempt reserved by Allow and resets failures of the account after successful login.
+// Other failures from ip are kept, otherwise attacker could reset them by logging into own account.
+func (t *Throttle) Succeed(ctx context.Context, email string, ip string) error {
+	const op = "throttle.Succeed"
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if err := t.store.DeleteLoginFailures(ctx, accountKey(email)); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if ip == "" {
+		return nil
+	}
+
+	if err := t.refund(ctx, throttleKey{key: ipKey(ip), policy: t.ip}); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// refund takes one reserved attempt back from the key counter.
+func (t *Throttle) refund(ctx context.Context, k throttleKey) error {
+	failures, err := t.store.LoginFailures(ctx, k.key)
+	if err != nil {
+		if errors.Is(err, storage.ErrLoginFailuresNotFound) {
+			return nil
+		}
+
+		return err
+	}
+
+	failures.Count--
+	if failures.Count <= 0 {
+		return t.store.DeleteLoginFailures(ctx, k.key)
+	}
+
+	// Block set by the refunded attempt is lifted if the remaining failures don't deserve one.
+	if k.policy.delay(failures.Count) == 0 {
+		failures.BlockedUntil = time.Time{}
+	}
+
+	return t.save(ctx, failures, time.Now())
+}
+
+// save stores failures until the window passes or the block ends, whichever is later.
+func (t *Throttle) save(ctx context.Context, failures models.LoginFailures, now time.Time) error {
+	expiresAt := now.Add(t.window)
+	if failures.BlockedUntil.After(expiresAt) {
+		expiresAt = failures.BlockedUntil
+	}
+
+	return t.store.SaveLoginFailures(ctx, failures, expiresAt)
+}
+
+type throttleKey struct {
+	key    string
+	policy Policy
+	reason error
+}
+
+func (t *Throttle) keys(email string, ip string) []throttleKey {
+	keys := []throttleKey{{key: accountKey(email), policy: t.account, reason: ErrAccountLocked}}
+
+	if ip != "" {
+		keys = append(keys, throttleKey{key: ipKey(ip), policy: t.ip, reason: ErrTooManyAttempts})
+	}
+
+	return keys
+}
+
+func accountKey(email string) string {
+	return "email:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+func ipKey(ip string) string {
+	return "ip:" + ip
+}
+
+// delay returns how long key is blocked after given number of failures.
+func (p Policy) delay(failures int) time.Duration {
+	if p.LockoutAfter > 0 && failures >= p.LockoutAfter {
+		return p.LockoutDuration
+	}
+
+	if failures <= p.FreeAttempts {
+		return 0
+	}
+
+	delay := p.BaseDelay
+	for i := p.FreeAttempts + 1; i < failures && delay < p.MaxDelay; i++ {
+		delay *= 2
+	}
+
+	return min(delay, p.MaxDelay)
+}
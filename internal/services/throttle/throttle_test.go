@@ -0,0 +1,115 @@
+package throttle
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"sso/internal/storage"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestThrottle(account Policy, ip Policy) (*Throttle, *MemoryStore) {
+	store := NewMemoryStore()
+
+	return New(slog.New(slog.NewTextHandler(io.Discard, nil)), store, account, ip, time.Minute), store
+}
+
+func TestAllow_ConcurrentAttemptsReserved(t *testing.T) {
+	th, _ := newTestThrottle(
+		Policy{FreeAttempts: 3, BaseDelay: time.Minute, MaxDelay: time.Minute},
+		Policy{FreeAttempts: 100, BaseDelay: time.Minute, MaxDelay: time.Minute},
+	)
+	ctx := context.Background()
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		allowed int
+	)
+	for range 50 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			err := th.Allow(ctx, "user@example.com", "10.0.0.1")
+			if err == nil {
+				mu.Lock()
+				allowed++
+				mu.Unlock()
+				return
+			}
+
+			assert.ErrorIs(t, err, ErrAccountLocked)
+		}()
+	}
+	wg.Wait()
+
+	// Free attempts and the one that starts the delay, same as for sequential failures.
+	assert.Equal(t, 4, allowed)
+}
+
+func TestSucceed_RefundsReservedAttempt(t *testing.T) {
+	th, store := newTestThrottle(
+		Policy{FreeAttempts: 1, BaseDelay: time.Minute, MaxDelay: time.Minute},
+		Policy{FreeAttempts: 1, BaseDelay: time.Minute, MaxDelay: time.Minute},
+	)
+	ctx := context.Background()
+
+	require.NoError(t, th.Allow(ctx, "attacker@example.com", "10.0.0.1"))
+	require.NoError(t, th.Allow(ctx, "user@example.com", "10.0.0.1"))
+
+	// Second attempt from ip has started the block, but it was successful.
+	require.NoError(t, th.Succeed(ctx, "user@example.com", "10.0.0.1"))
+
+	_, err := store.LoginFailures(ctx, accountKey("user@example.com"))
+	assert.ErrorIs(t, err, storage.ErrLoginFailuresNotFound)
+
+	failures, err := store.LoginFailures(ctx, ipKey("10.0.0.1"))
+	require.NoError(t, err)
+	assert.Equal(t, 1, failures.Count, "failure of another account is kept")
+	assert.True(t, failures.BlockedUntil.IsZero())
+
+	require.NoError(t, th.Allow(ctx, "user@example.com", "10.0.0.1"))
+}
+
+func TestRefund_KeepsAccountFailures(t *testing.T) {
+	th, store := newTestThrottle(
+		Policy{FreeAttempts: 5, BaseDelay: time.Minute, MaxDelay: time.Minute},
+		Policy{FreeAttempts: 5, BaseDelay: time.Minute, MaxDelay: time.Minute},
+	)
+	ctx := context.Background()
+
+	for range 3 {
+		require.NoError(t, th.Allow(ctx, "user@example.com", "10.0.0.1"))
+	}
+	require.NoError(t, th.Refund(ctx, "user@example.com", "10.0.0.1"))
+
+	for _, key := range []string{accountKey("user@example.com"), ipKey("10.0.0.1")} {
+		failures, err := store.LoginFailures(ctx, key)
+		require.NoError(t, err)
+		assert.Equal(t, 2, failures.Count, key)
+	}
+}
+
+func TestAllow_BlockedAfterFailures(t *testing.T) {
+	th, _ := newTestThrottle(
+		Policy{FreeAttempts: 1, BaseDelay: time.Minute, MaxDelay: time.Hour, LockoutAfter: 3, LockoutDuration: time.Hour},
+		Policy{FreeAttempts: 100, BaseDelay: time.Minute, MaxDelay: time.Minute},
+	)
+	ctx := context.Background()
+
+	require.NoError(t, th.Allow(ctx, "user@example.com", ""))
+	require.NoError(t, th.Allow(ctx, "user@example.com", ""))
+
+	err := th.Allow(ctx, "USER@example.com ", "")
+	var blocked *BlockedError
+	require.True(t, errors.As(err, &blocked))
+	assert.ErrorIs(t, err, ErrAccountLocked)
+	assert.InDelta(t, time.Minute.Seconds(), blocked.RetryAfter.Seconds(), 1)
+}
@@ -0,0 +1,110 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sso/internal/domain/models"
+	"sso/internal/storage"
+)
+
+// Article returns published article with its Markdown body.
+func (s *Storage) Article(ctx context.Context, id int64) (models.ArticleDetails, error) {
+	const op = "storage.sqlite.Article"
+
+	article, err := s.articleDetails(ctx, "article.id = ?", id)
+	if err != nil {
+		return models.ArticleDetails{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return article, nil
+}
+
+// ArticleBySlug returns published article with its Markdown body.
+func (s *Storage) ArticleBySlug(ctx context.Context, slug string) (models.ArticleDetails, error) {
+	const op = "storage.sqlite.ArticleBySlug"
+
+	article, err := s.articleDetails(ctx, "slug = ?", slug)
+	if err != nil {
+		return models.ArticleDetails{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return article, nil
+}
+
+func (s *Storage) articleDetails(ctx context.Context, cond string, arg any) (models.ArticleDetails, error) {
+	row := s.db.QueryRowContext(
+		ctx,
+		"SELECT "+articleColumns+", body FROM article WHERE "+cond+" AND status = ? AND deleted_at IS NULL",
+		arg,
+		models.ContentStatusPublished,
+	)
+
+	var details models.ArticleDetails
+	article, err := scanArticle(withExtra(row, &details.Body))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.ArticleDetails{}, storage.ErrArticleNotFound
+		}
+
+		return models.ArticleDetails{}, err
+	}
+	details.Article = article
+
+	return details, nil
+}
+
+// RelatedArticles returns published articles sharing tags with the given one,
+// the ones with more tags in common first.
+func (s *Storage) RelatedArticles(ctx context.Context, articleID int64, limit int) ([]models.Article, error) {
+	const op = "storage.sqlite.RelatedArticles"
+
+	articles, err := queryRows(ctx, s.db, scanArticle, `
+	WITH shared AS (
+		SELECT other.article_id, COUNT(*) AS tags
+		FROM article_tags own
+		JOIN article_tags other ON other.tag = own.tag AND other.article_id != own.article_id
+		WHERE own.article_id = ?
+		GROUP BY other.article_id
+	)
+	SELECT `+articleColumns+`
+	FROM article
+	JOIN shared ON shared.article_id = article.id
+	WHERE status = ? AND deleted_at IS NULL
+	ORDER BY shared.tags DESC, COALESCE(published_at, 0) DESC, article.id DESC
+	LIMIT ?
+`, articleID, models.ContentStatusPublished, limit)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return articles, nil
+}
+
+// IncrementArticleViews counts a view of article, views are used to sort lists by popularity.
+func (s *Storage) IncrementArticleViews(ctx context.Context, articleID int64) error {
+	const op = "storage.sqlite.IncrementArticleViews"
+
+	_, err := s.db.ExecContext(ctx, "UPDATE article SET views = views + 1 WHERE id = ?", articleID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// extraScanner scans the columns a scan function knows about followed by extra ones,
+// so summary scan functions can be reused for queries selecting more columns.
+type extraScanner struct {
+	row   rowScanner
+	extra []any
+}
+
+func withExtra(row rowScanner, extra ...any) rowScanner {
+	return extraScanner{row: row, extra: extra}
+}
+
+func (s extraScanner) Scan(dest ...any) error {
+	return s.row.Scan(append(dest, s.extra...)...)
+}
@@ -0,0 +1,93 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sso/internal/domain/models"
+	"sso/internal/storage"
+	"time"
+)
+
+// SaveAuthorizationCode stores hash of issued OpenID Connect authorization code.
+func (s *Storage) SaveAuthorizationCode(ctx context.Context, code models.AuthorizationCode) error {
+	const op = "storage.sqlite.SaveAuthorizationCode"
+
+	stmt, err := s.db.Prepare(`
+	INSERT INTO authorization_codes(
+		code_hash, app_id, user_id, redirect_uri, scope, nonce,
+		code_challenge, code_challenge_method, expires_at
+	)
+	VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?)
+`)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	_, err = stmt.ExecContext(ctx,
+		code.CodeHash, code.AppID, code.UserID, code.RedirectURI, code.Scope, code.Nonce,
+		code.CodeChallenge, code.CodeChallengeMethod, code.ExpiresAt.Unix(),
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// UseAuthorizationCode marks authorization code as used and returns it.
+// Codes are single-use: if code was already used, returns storage.ErrAuthCodeNotFound.
+func (s *Storage) UseAuthorizationCode(ctx context.Context, codeHash string) (models.AuthorizationCode, error) {
+	const op = "storage.sqlite.UseAuthorizationCode"
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return models.AuthorizationCode{}, fmt.Errorf("%s: %w", op, err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	res, err := tx.ExecContext(ctx, "UPDATE authorization_codes SET used = 1 WHERE code_hash = ? AND used = 0", codeHash)
+	if err != nil {
+		return models.AuthorizationCode{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return models.AuthorizationCode{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if affected == 0 {
+		return models.AuthorizationCode{}, fmt.Errorf("%s: %w", op, storage.ErrAuthCodeNotFound)
+	}
+
+	row := tx.QueryRowContext(ctx, `
+	SELECT code_hash, app_id, user_id, redirect_uri, scope, nonce,
+	       code_challenge, code_challenge_method, expires_at
+	FROM authorization_codes
+	WHERE code_hash = ?
+`, codeHash)
+
+	var (
+		code      models.AuthorizationCode
+		expiresAt int64
+	)
+	err = row.Scan(
+		&code.CodeHash, &code.AppID, &code.UserID, &code.RedirectURI, &code.Scope, &code.Nonce,
+		&code.CodeChallenge, &code.CodeChallengeMethod, &expiresAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.AuthorizationCode{}, fmt.Errorf("%s: %w", op, storage.ErrAuthCodeNotFound)
+		}
+
+		return models.AuthorizationCode{}, fmt.Errorf("%s: %w", op, err)
+	}
+	code.ExpiresAt = time.Unix(expiresAt, 0)
+
+	if err := tx.Commit(); err != nil {
+		return models.AuthorizationCode{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return code, nil
+}
@@ -0,0 +1,243 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"sso/internal/domain/models"
+	"sso/internal/storage"
+	"strings"
+	"time"
+)
+
+// bookmarksCursor marks bookmark cursors, they hold bookmark time and id of the last bookmark on the page.
+const bookmarksCursor = "bookmarks"
+
+// bookmarkSources describe content types that can be bookmarked, column is the bookmarks column pointing to them.
+var bookmarkSources = map[string]struct {
+	table    string
+	column   string
+	notFound error
+}{
+	models.ContentTypeArticle: {table: "article", column: "article_id", notFound: storage.ErrArticleNotFound},
+	models.ContentTypeCourse:  {table: "course", column: "course_id", notFound: storage.ErrCourseNotFound},
+}
+
+var bookmarkTypes = []string{models.ContentTypeArticle, models.ContentTypeCourse}
+
+// AddBookmark bookmarks published content for user, bookmarking it again keeps the first bookmark.
+// Returns storage.ErrArticleNotFound or storage.ErrCourseNotFound if there is no such published content.
+func (s *Storage) AddBookmark(ctx context.Context, userID int64, contentType string, contentID int64, createdAt time.Time) error {
+	const op = "storage.sqlite.AddBookmark"
+
+	source, ok := bookmarkSources[contentType]
+	if !ok {
+		return fmt.Errorf("%s: unknown content type %q", op, contentType)
+	}
+
+	res, err := s.db.ExecContext(ctx, fmt.Sprintf(`
+	INSERT INTO bookmarks (user_id, %[1]s, created_at)
+	SELECT ?, id, ? FROM %[2]s WHERE id = ? AND status = ? AND deleted_at IS NULL
+	ON CONFLICT DO NOTHING
+`, source.column, source.table), userID, createdAt.Unix(), contentID, models.ContentStatusPublished)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if affected > 0 {
+		return nil
+	}
+
+	// Nothing is inserted either if content is already bookmarked or if there is no such content.
+	var exists bool
+	err = s.db.QueryRowContext(
+		ctx,
+		fmt.Sprintf("SELECT EXISTS (SELECT 1 FROM bookmarks WHERE user_id = ? AND %s = ?)", source.column),
+		userID,
+		contentID,
+	).Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if !exists {
+		return fmt.Errorf("%s: %w", op, source.notFound)
+	}
+
+	return nil
+}
+
+// RemoveBookmark removes bookmark of content, removing a missing bookmark is not an error.
+func (s *Storage) RemoveBookmark(ctx context.Context, userID int64, contentType string, contentID int64) error {
+	const op = "storage.sqlite.RemoveBookmark"
+
+	source, ok := bookmarkSources[contentType]
+	if !ok {
+		return fmt.Errorf("%s: unknown content type %q", op, contentType)
+	}
+
+	_, err := s.db.ExecContext(
+		ctx,
+		fmt.Sprintf("DELETE FROM bookmarks WHERE user_id = ? AND %s = ?", source.column),
+		userID,
+		contentID,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// Bookmarks returns a page of published content bookmarked by user, the latest bookmarks first.
+// Bookmarks of content that is no longer published are kept, but not listed.
+func (s *Storage) Bookmarks(ctx context.Context, query models.BookmarkQuery) (models.Page[models.Bookmark], error) {
+	const op = "storage.sqlite.Bookmarks"
+
+	limit := query.Limit
+	if limit <= 0 {
+		limit = defaultContentLimit
+	}
+	limit = min(limit, maxContentLimit)
+
+	types := bookmarkTypes
+	if query.Type != "" {
+		types = []string{query.Type}
+	}
+
+	var (
+		selects []string
+		args    []any
+	)
+	for _, typ := range types {
+		source, ok := bookmarkSources[typ]
+		if !ok {
+			return models.Page[models.Bookmark]{}, fmt.Errorf("%s: unknown content type %q", op, typ)
+		}
+
+		selects = append(selects, fmt.Sprintf(`
+		SELECT '%[1]s' AS type, %[2]s.id AS id, %[2]s.slug AS slug, %[2]s.name AS name,
+		       %[2]s.description AS description, %[2]s.image AS image,
+		       bookmarks.id AS bookmark_id, bookmarks.created_at AS created_at
+		FROM bookmarks
+		JOIN %[2]s ON %[2]s.id = bookmarks.%[3]s
+		WHERE bookmarks.user_id = ? AND %[2]s.status = ? AND %[2]s.deleted_at IS NULL`, typ, source.table, source.column))
+		args = append(args, query.UserID, models.ContentStatusPublished)
+	}
+
+	where := "TRUE"
+	if query.Cursor != "" {
+		createdAt, id, err := decodeCursor(query.Cursor, bookmarksCursor)
+		if err != nil {
+			return models.Page[models.Bookmark]{}, fmt.Errorf("%s: %w", op, err)
+		}
+
+		where = "(created_at, bookmark_id) < (?, ?)"
+		args = append(args, createdAt, id)
+	}
+
+	// One extra row tells whether there is a next page.
+	args = append(args, limit+1)
+
+	rows, err := queryRows(ctx, s.db, scanBookmark, `
+	SELECT type, id, slug, name, description, image, bookmark_id, created_at
+	FROM (`+strings.Join(selects, "\n\t\tUNION ALL")+`)
+	WHERE `+where+`
+	ORDER BY created_at DESC, bookmark_id DESC
+	LIMIT ?
+`, args...)
+	if err != nil {
+		return models.Page[models.Bookmark]{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	page := models.Page[models.Bookmark]{Items: make([]models.Bookmark, 0, min(len(rows), limit))}
+	for _, r := range rows[:min(len(rows), limit)] {
+		page.Items = append(page.Items, r.Bookmark)
+	}
+
+	if len(rows) > limit {
+		last := rows[limit-1]
+		page.NextCursor = encodeCursor(bookmarksCursor, last.BookmarkedAt.Unix(), last.bookmarkID)
+	}
+
+	return page, nil
+}
+
+// bookmarkRow is a listed bookmark with its own id, which orders bookmarks made at the same second.
+type bookmarkRow struct {
+	models.Bookmark
+	bookmarkID int64
+}
+
+func scanBookmark(row rowScanner) (bookmarkRow, error) {
+	var (
+		bookmark  bookmarkRow
+		createdAt int64
+	)
+
+	err := row.Scan(
+		&bookmark.Type,
+		&bookmark.ID,
+		&bookmark.Slug,
+		&bookmark.Name,
+		&bookmark.Description,
+		&bookmark.Image,
+		&bookmark.bookmarkID,
+		&createdAt,
+	)
+	if err != nil {
+		return bookmarkRow{}, err
+	}
+
+	bookmark.BookmarkedAt = time.Unix(createdAt, 0)
+
+	return bookmark, nil
+}
+
+// BookmarkedContent returns which of content of type with the given ids user bookmarked.
+func (s *Storage) BookmarkedContent(ctx context.Context, userID int64, contentType string, ids []int64) (map[int64]bool, error) {
+	const op = "storage.sqlite.BookmarkedContent"
+
+	source, ok := bookmarkSources[contentType]
+	if !ok {
+		return nil, fmt.Errorf("%s: unknown content type %q", op, contentType)
+	}
+
+	bookmarked := make(map[int64]bool, len(ids))
+	if len(ids) == 0 {
+		return bookmarked, nil
+	}
+
+	args := []any{userID}
+	for _, id := range ids {
+		args = append(args, id)
+	}
+
+	rows, err := s.db.QueryContext(ctx, fmt.Sprintf(
+		"SELECT %[1]s FROM bookmarks WHERE user_id = ? AND %[1]s IN (%[2]s)",
+		source.column,
+		strings.TrimSuffix(strings.Repeat("?, ", len(ids)), ", "),
+	), args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		bookmarked[id] = true
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return bookmarked, nil
+}
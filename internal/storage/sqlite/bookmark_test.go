@@ -0,0 +1,88 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"sso/internal/domain/models"
+	"sso/internal/storage"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBookmarks(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	userID, err := s.SaveUser(ctx, "reader@example.com", []byte("hash"))
+	require.NoError(t, err)
+
+	for _, q := range []string{
+		`INSERT INTO article (id, slug, name, status, created_at, updated_at) VALUES (1, 'go', 'Go', 'published', 1, 1)`,
+		`INSERT INTO article (id, slug, name, status, created_at, updated_at) VALUES (2, 'sql', 'SQL', 'published', 1, 1)`,
+		`INSERT INTO article (id, slug, name, status, created_at, updated_at) VALUES (3, 'draft', 'Draft', 'draft', 1, 1)`,
+		`INSERT INTO course (id, slug, name, status, created_at, updated_at) VALUES (1, 'go', 'Go', 'published', 1, 1)`,
+	} {
+		_, err := s.db.ExecContext(ctx, q)
+		require.NoError(t, err, q)
+	}
+
+	require.NoError(t, s.AddBookmark(ctx, userID, models.ContentTypeArticle, 1, time.Unix(100, 0)))
+	require.NoError(t, s.AddBookmark(ctx, userID, models.ContentTypeCourse, 1, time.Unix(200, 0)))
+	require.NoError(t, s.AddBookmark(ctx, userID, models.ContentTypeArticle, 2, time.Unix(200, 0)))
+
+	// Bookmarking again keeps the first bookmark time.
+	require.NoError(t, s.AddBookmark(ctx, userID, models.ContentTypeArticle, 1, time.Unix(300, 0)))
+
+	assert.ErrorIs(t, s.AddBookmark(ctx, userID, models.ContentTypeArticle, 3, time.Unix(100, 0)), storage.ErrArticleNotFound)
+	assert.ErrorIs(t, s.AddBookmark(ctx, userID, models.ContentTypeCourse, 2, time.Unix(100, 0)), storage.ErrCourseNotFound)
+
+	list := func(query models.BookmarkQuery) []string {
+		t.Helper()
+
+		query.UserID = userID
+		page, err := s.Bookmarks(ctx, query)
+		require.NoError(t, err)
+
+		items := []string{}
+		for _, b := range page.Items {
+			items = append(items, fmt.Sprintf("%s:%d", b.Type, b.ID))
+		}
+
+		return items
+	}
+
+	// Bookmarks made at the same second come in the reverse order they were made.
+	assert.Equal(t, []string{"article:2", "course:1", "article:1"}, list(models.BookmarkQuery{}))
+	assert.Equal(t, []string{"article:2", "article:1"}, list(models.BookmarkQuery{Type: models.ContentTypeArticle}))
+
+	page, err := s.Bookmarks(ctx, models.BookmarkQuery{UserID: userID, Limit: 2})
+	require.NoError(t, err)
+	require.NotEmpty(t, page.NextCursor)
+	assert.Equal(t, []string{"article:1"}, list(models.BookmarkQuery{Limit: 2, Cursor: page.NextCursor}))
+
+	_, err = s.Bookmarks(ctx, models.BookmarkQuery{UserID: userID, Cursor: "bad"})
+	assert.ErrorIs(t, err, storage.ErrInvalidCursor)
+
+	bookmarked, err := s.BookmarkedContent(ctx, userID, models.ContentTypeArticle, []int64{1, 3})
+	require.NoError(t, err)
+	assert.Equal(t, map[int64]bool{1: true}, bookmarked)
+
+	// Bookmarks of unpublished content are kept, but not listed.
+	_, err = s.db.ExecContext(ctx, `UPDATE article SET status = 'archived' WHERE id = 2`)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"course:1", "article:1"}, list(models.BookmarkQuery{}))
+
+	require.NoError(t, s.RemoveBookmark(ctx, userID, models.ContentTypeCourse, 1))
+	require.NoError(t, s.RemoveBookmark(ctx, userID, models.ContentTypeCourse, 1))
+	assert.Equal(t, []string{"article:1"}, list(models.BookmarkQuery{}))
+
+	// Bookmarks are deleted with their user.
+	require.NoError(t, s.DeleteUser(ctx, userID))
+
+	var count int
+	require.NoError(t, s.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM bookmarks").Scan(&count))
+	assert.Zero(t, count)
+}
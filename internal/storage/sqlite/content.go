@@ -0,0 +1,417 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"fmt"
+	"slices"
+	"sso/internal/domain/models"
+	"sso/internal/storage"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultContentLimit = 20
+	maxContentLimit     = 100
+)
+
+// Columns are listed explicitly and in the order scan functions expect them,
+// so adding a column to a table doesn't break reading it. They are qualified with the table name,
+// so queries can join tables having columns with the same names. Tags are aggregated into a comma separated list.
+const (
+	articleColumns = `article.id, article.slug, article.name, article.description, article.image,
+	article.author_id, article.updated_by, article.difficulty, article.views,
+	(SELECT group_concat(tag) FROM article_tags WHERE article_tags.article_id = article.id),
+	article.status, article.version, article.published_at, article.publish_at, article.created_at, article.updated_at`
+	courseColumns = `course.id, course.slug, course.name, course.description, course.image,
+	course.author_id, course.difficulty, course.views,
+	(SELECT group_concat(tag) FROM course_tags WHERE course_tags.course_id = course.id),
+	course.status, course.version, course.published_at, course.publish_at, course.created_at, course.updated_at`
+	feedColumns = `feed.id, feed.slug, feed.name, feed.description, feed.image, feed.link,
+	feed.author_id, feed.difficulty, feed.views,
+	(SELECT group_concat(tag) FROM feed_tags WHERE feed_tags.feed_id = feed.id),
+	feed.status, feed.version, feed.published_at, feed.publish_at, feed.created_at, feed.updated_at`
+)
+
+// Feeds returns a page of published feed items.
+func (s *Storage) Feeds(ctx context.Context, query models.ContentQuery) (models.Page[models.Feed], error) {
+	const op = "storage.sqlite.Feeds"
+
+	page, err := listContent(ctx, s.db, "feed", feedColumns, scanFeed, feedKey, query)
+	if err != nil {
+		return models.Page[models.Feed]{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return page, nil
+}
+
+// Articles returns a page of published articles.
+func (s *Storage) Articles(ctx context.Context, query models.ContentQuery) (models.Page[models.Article], error) {
+	const op = "storage.sqlite.Articles"
+
+	page, err := listContent(ctx, s.db, "article", articleColumns, scanArticle, articleKey, query)
+	if err != nil {
+		return models.Page[models.Article]{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return page, nil
+}
+
+// Courses returns a page of published courses.
+func (s *Storage) Courses(ctx context.Context, query models.ContentQuery) (models.Page[models.Course], error) {
+	const op = "storage.sqlite.Courses"
+
+	page, err := listContent(ctx, s.db, "course", courseColumns, scanCourse, courseKey, query)
+	if err != nil {
+		return models.Page[models.Course]{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return page, nil
+}
+
+// contentKey holds fields content lists are ordered by.
+type contentKey struct {
+	id          int64
+	publishedAt *time.Time
+	views       int64
+}
+
+func articleKey(a models.Article) contentKey { return contentKey{a.ID, a.PublishedAt, a.Views} }
+func courseKey(c models.Course) contentKey   { return contentKey{c.ID, c.PublishedAt, c.Views} }
+func feedKey(f models.Feed) contentKey       { return contentKey{f.ID, f.PublishedAt, f.Views} }
+
+// listContent selects a page of published rows of table using keyset pagination:
+// the cursor holds sort key and id of the last returned row, and the next page starts right after it,
+// so pages don't shift when content is published in between requests.
+func listContent[T any](
+	ctx context.Context,
+	db *sql.DB,
+	table string,
+	columns string,
+	scan func(rowScanner) (T, error),
+	key func(T) contentKey,
+	query models.ContentQuery,
+) (models.Page[T], error) {
+	limit := query.Limit
+	if limit <= 0 {
+		limit = defaultContentLimit
+	}
+	limit = min(limit, maxContentLimit)
+
+	sort := query.Sort
+	if sort == "" {
+		sort = models.ContentSortDate
+	}
+
+	var sortColumn string
+	switch sort {
+	case models.ContentSortDate:
+		sortColumn = "COALESCE(published_at, 0)"
+	case models.ContentSortPopularity:
+		sortColumn = "views"
+	default:
+		return models.Page[T]{}, fmt.Errorf("unknown sort %q", sort)
+	}
+
+	where := []string{"status = ?", "deleted_at IS NULL"}
+	args := []any{models.ContentStatusPublished}
+
+	if query.AuthorID != 0 {
+		where = append(where, "author_id = ?")
+		args = append(args, query.AuthorID)
+	}
+
+	if query.Difficulty != "" {
+		where = append(where, "difficulty = ?")
+		args = append(args, query.Difficulty)
+	}
+
+	if query.Tag != "" {
+		where = append(where, fmt.Sprintf("EXISTS (SELECT 1 FROM %[1]s_tags WHERE %[1]s_id = %[1]s.id AND tag = ?)", table))
+		args = append(args, query.Tag)
+	}
+
+	if query.Cursor != "" {
+		value, id, err := decodeCursor(query.Cursor, sort)
+		if err != nil {
+			return models.Page[T]{}, err
+		}
+
+		where = append(where, fmt.Sprintf("(%s, %s.id) < (?, ?)", sortColumn, table))
+		args = append(args, value, id)
+	}
+
+	// One extra row tells whether there is a next page.
+	args = append(args, limit+1)
+
+	items, err := queryRows(ctx, db, scan, fmt.Sprintf(`
+	SELECT %s
+	FROM %s
+	WHERE %s
+	ORDER BY %s DESC, %s.id DESC
+	LIMIT ?
+`, columns, table, strings.Join(where, " AND "), sortColumn, table), args...)
+	if err != nil {
+		return models.Page[T]{}, err
+	}
+
+	page := models.Page[T]{Items: items}
+	if len(items) > limit {
+		page.Items = items[:limit]
+
+		last := key(page.Items[limit-1])
+		value := last.views
+		if sort == models.ContentSortDate {
+			value = 0
+			if last.publishedAt != nil {
+				value = last.publishedAt.Unix()
+			}
+		}
+
+		page.NextCursor = encodeCursor(sort, value, last.id)
+	}
+
+	return page, nil
+}
+
+// encodeCursor returns opaque cursor pointing right after the row with the given sort value and id.
+func encodeCursor(sort string, value int64, id int64) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf("%s:%d:%d", sort, value, id)))
+}
+
+// decodeCursor returns sort value and id stored in cursor.
+// Returns storage.ErrInvalidCursor if cursor is malformed or was issued for another sort order.
+func decodeCursor(cursor string, sort string) (int64, int64, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, 0, storage.ErrInvalidCursor
+	}
+
+	parts := strings.Split(string(raw), ":")
+	if len(parts) != 3 || parts[0] != sort {
+		return 0, 0, storage.ErrInvalidCursor
+	}
+
+	value, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return 0, 0, storage.ErrInvalidCursor
+	}
+
+	id, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil {
+		return 0, 0, storage.ErrInvalidCursor
+	}
+
+	return value, id, nil
+}
+
+// queryRows runs query and scans every row with scan. Returns empty, not nil, slice if there are no rows,
+// so handlers encode it as [] rather than null.
+func queryRows[T any](
+	ctx context.Context,
+	db *sql.DB,
+	scan func(rowScanner) (T, error),
+	query string,
+	args ...any,
+) ([]T, error) {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []T{}
+	for rows.Next() {
+		item, err := scan(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		items = append(items, item)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+func scanArticle(row rowScanner) (models.Article, error) {
+	var (
+		article     models.Article
+		authorID    sql.NullInt64
+		updatedBy   sql.NullInt64
+		difficulty  sql.NullString
+		tags        sql.NullString
+		publishedAt sql.NullInt64
+		publishAt   sql.NullInt64
+		createdAt   int64
+		updatedAt   int64
+	)
+
+	err := row.Scan(
+		&article.ID,
+		&article.Slug,
+		&article.Name,
+		&article.Description,
+		&article.Image,
+		&authorID,
+		&updatedBy,
+		&difficulty,
+		&article.Views,
+		&tags,
+		&article.Status,
+		&article.Version,
+		&publishedAt,
+		&publishAt,
+		&createdAt,
+		&updatedAt,
+	)
+	if err != nil {
+		return models.Article{}, err
+	}
+
+	article.AuthorID = nullInt64(authorID)
+	article.UpdatedBy = nullInt64(updatedBy)
+	article.Difficulty = difficulty.String
+	article.Tags = splitTags(tags)
+	article.PublishedAt = nullTime(publishedAt)
+	article.PublishAt = nullTime(publishAt)
+	article.CreatedAt = time.Unix(createdAt, 0)
+	article.UpdatedAt = time.Unix(updatedAt, 0)
+
+	return article, nil
+}
+
+func scanCourse(row rowScanner) (models.Course, error) {
+	var (
+		course      models.Course
+		authorID    sql.NullInt64
+		difficulty  sql.NullString
+		tags        sql.NullString
+		publishedAt sql.NullInt64
+		publishAt   sql.NullInt64
+		createdAt   int64
+		updatedAt   int64
+	)
+
+	err := row.Scan(
+		&course.ID,
+		&course.Slug,
+		&course.Name,
+		&course.Description,
+		&course.Image,
+		&authorID,
+		&difficulty,
+		&course.Views,
+		&tags,
+		&course.Status,
+		&course.Version,
+		&publishedAt,
+		&publishAt,
+		&createdAt,
+		&updatedAt,
+	)
+	if err != nil {
+		return models.Course{}, err
+	}
+
+	course.AuthorID = nullInt64(authorID)
+	course.Difficulty = difficulty.String
+	course.Tags = splitTags(tags)
+	course.PublishedAt = nullTime(publishedAt)
+	course.PublishAt = nullTime(publishAt)
+	course.CreatedAt = time.Unix(createdAt, 0)
+	course.UpdatedAt = time.Unix(updatedAt, 0)
+
+	return course, nil
+}
+
+func scanFeed(row rowScanner) (models.Feed, error) {
+	var (
+		feed        models.Feed
+		authorID    sql.NullInt64
+		difficulty  sql.NullString
+		tags        sql.NullString
+		publishedAt sql.NullInt64
+		publishAt   sql.NullInt64
+		createdAt   int64
+		updatedAt   int64
+	)
+
+	err := row.Scan(
+		&feed.ID,
+		&feed.Slug,
+		&feed.Name,
+		&feed.Description,
+		&feed.Image,
+		&feed.Link,
+		&authorID,
+		&difficulty,
+		&feed.Views,
+		&tags,
+		&feed.Status,
+		&feed.Version,
+		&publishedAt,
+		&publishAt,
+		&createdAt,
+		&updatedAt,
+	)
+	if err != nil {
+		return models.Feed{}, err
+	}
+
+	feed.AuthorID = nullInt64(authorID)
+	feed.Difficulty = difficulty.String
+	feed.Tags = splitTags(tags)
+	feed.PublishedAt = nullTime(publishedAt)
+	feed.PublishAt = nullTime(publishAt)
+	feed.CreatedAt = time.Unix(createdAt, 0)
+	feed.UpdatedAt = time.Unix(updatedAt, 0)
+
+	return feed, nil
+}
+
+// splitTags splits tags aggregated by group_concat, returns empty slice if there are none.
+func splitTags(v sql.NullString) []string {
+	if !v.Valid || v.String == "" {
+		return []string{}
+	}
+
+	tags := strings.Split(v.String, ",")
+	slices.Sort(tags)
+
+	return tags
+}
+
+func nullInt64(v sql.NullInt64) *int64 {
+	if !v.Valid {
+		return nil
+	}
+
+	return &v.Int64
+}
+
+func nullInt(v sql.NullInt64) *int {
+	if !v.Valid {
+		return nil
+	}
+
+	i := int(v.Int64)
+
+	return &i
+}
+
+func nullTime(v sql.NullInt64) *time.Time {
+	if !v.Valid {
+		return nil
+	}
+
+	t := time.Unix(v.Int64, 0)
+
+	return &t
+}
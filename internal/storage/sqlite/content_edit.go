@@ -0,0 +1,519 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/mattn/go-sqlite3"
+	"slices"
+	"sso/internal/domain/models"
+	"sso/internal/storage"
+	"strings"
+	"time"
+)
+
+// EditableArticle returns article of any status with its Markdown body, deleted articles are not found.
+func (s *Storage) EditableArticle(ctx context.Context, id int64) (models.ArticleDetails, error) {
+	const op = "storage.sqlite.EditableArticle"
+
+	row := s.db.QueryRowContext(
+		ctx,
+		"SELECT "+articleColumns+", body FROM article WHERE article.id = ? AND deleted_at IS NULL",
+		id,
+	)
+
+	var details models.ArticleDetails
+	article, err := scanArticle(withExtra(row, &details.Body))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.ArticleDetails{}, fmt.Errorf("%s: %w", op, storage.ErrArticleNotFound)
+		}
+
+		return models.ArticleDetails{}, fmt.Errorf("%s: %w", op, err)
+	}
+	details.Article = article
+
+	return details, nil
+}
+
+// CreateArticle saves new article with its tags and returns it as stored.
+// Returns storage.ErrSlugTaken if another article, even a deleted one, has the same slug.
+func (s *Storage) CreateArticle(ctx context.Context, article models.ArticleDetails) (models.ArticleDetails, error) {
+	const op = "storage.sqlite.CreateArticle"
+
+	id, err := s.createContent(ctx, "article", articleValues(article), article.Tags)
+	if err != nil {
+		return models.ArticleDetails{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	created, err := s.EditableArticle(ctx, id)
+	if err != nil {
+		return models.ArticleDetails{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return created, nil
+}
+
+// UpdateArticle saves changes of article if it is still at article.Version and returns it as stored.
+// Returns storage.ErrVersionConflict if article was changed since that version was read.
+func (s *Storage) UpdateArticle(ctx context.Context, article models.ArticleDetails) (models.ArticleDetails, error) {
+	const op = "storage.sqlite.UpdateArticle"
+
+	values := articleValues(article)
+	delete(values, "author_id")
+	delete(values, "created_at")
+
+	err := s.updateContent(ctx, "article", article.ID, article.Version, values, article.Tags, storage.ErrArticleNotFound)
+	if err != nil {
+		return models.ArticleDetails{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	updated, err := s.EditableArticle(ctx, article.ID)
+	if err != nil {
+		return models.ArticleDetails{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return updated, nil
+}
+
+// DeleteArticle marks article at version deleted, it disappears for readers and editors.
+func (s *Storage) DeleteArticle(ctx context.Context, id int64, version int64, deletedAt time.Time) error {
+	const op = "storage.sqlite.DeleteArticle"
+
+	err := s.updateContent(ctx, "article", id, version, map[string]any{"deleted_at": deletedAt.Unix()}, nil, storage.ErrArticleNotFound)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// EditableCourse returns course of any status, deleted courses are not found.
+func (s *Storage) EditableCourse(ctx context.Context, id int64) (models.Course, error) {
+	const op = "storage.sqlite.EditableCourse"
+
+	row := s.db.QueryRowContext(
+		ctx,
+		"SELECT "+courseColumns+" FROM course WHERE course.id = ? AND deleted_at IS NULL",
+		id,
+	)
+
+	course, err := scanCourse(row)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.Course{}, fmt.Errorf("%s: %w", op, storage.ErrCourseNotFound)
+		}
+
+		return models.Course{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return course, nil
+}
+
+// PreviewCourse returns course of any status with its modules and lessons, deleted courses are not found.
+func (s *Storage) PreviewCourse(ctx context.Context, id int64) (models.CourseDetails, error) {
+	const op = "storage.sqlite.PreviewCourse"
+
+	course, err := s.EditableCourse(ctx, id)
+	if err != nil {
+		return models.CourseDetails{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	modules, err := s.courseModules(ctx, id)
+	if err != nil {
+		return models.CourseDetails{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return models.CourseDetails{Course: course, Modules: modules}, nil
+}
+
+// CreateCourse saves new course with its tags and returns it as stored.
+// Returns storage.ErrSlugTaken if another course, even a deleted one, has the same slug.
+func (s *Storage) CreateCourse(ctx context.Context, course models.Course) (models.Course, error) {
+	const op = "storage.sqlite.CreateCourse"
+
+	id, err := s.createContent(ctx, "course", courseValues(course), course.Tags)
+	if err != nil {
+		return models.Course{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	created, err := s.EditableCourse(ctx, id)
+	if err != nil {
+		return models.Course{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return created, nil
+}
+
+// UpdateCourse saves changes of course if it is still at course.Version and returns it as stored.
+// Returns storage.ErrVersionConflict if course was changed since that version was read.
+func (s *Storage) UpdateCourse(ctx context.Context, course models.Course) (models.Course, error) {
+	const op = "storage.sqlite.UpdateCourse"
+
+	values := courseValues(course)
+	delete(values, "author_id")
+	delete(values, "created_at")
+
+	err := s.updateContent(ctx, "course", course.ID, course.Version, values, course.Tags, storage.ErrCourseNotFound)
+	if err != nil {
+		return models.Course{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	updated, err := s.EditableCourse(ctx, course.ID)
+	if err != nil {
+		return models.Course{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return updated, nil
+}
+
+// DeleteCourse marks course at version deleted. Its modules, lessons and progress of students are kept,
+// but the course disappears for readers and editors.
+func (s *Storage) DeleteCourse(ctx context.Context, id int64, version int64, deletedAt time.Time) error {
+	const op = "storage.sqlite.DeleteCourse"
+
+	err := s.updateContent(ctx, "course", id, version, map[string]any{"deleted_at": deletedAt.Unix()}, nil, storage.ErrCourseNotFound)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// EditableFeed returns feed item of any status, deleted items are not found.
+func (s *Storage) EditableFeed(ctx context.Context, id int64) (models.Feed, error) {
+	const op = "storage.sqlite.EditableFeed"
+
+	row := s.db.QueryRowContext(
+		ctx,
+		"SELECT "+feedColumns+" FROM feed WHERE feed.id = ? AND deleted_at IS NULL",
+		id,
+	)
+
+	feed, err := scanFeed(row)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.Feed{}, fmt.Errorf("%s: %w", op, storage.ErrFeedNotFound)
+		}
+
+		return models.Feed{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return feed, nil
+}
+
+// CreateFeed saves new feed item with its tags and returns it as stored.
+// Returns storage.ErrSlugTaken if another item, even a deleted one, has the same slug.
+func (s *Storage) CreateFeed(ctx context.Context, feed models.Feed) (models.Feed, error) {
+	const op = "storage.sqlite.CreateFeed"
+
+	id, err := s.createContent(ctx, "feed", feedValues(feed), feed.Tags)
+	if err != nil {
+		return models.Feed{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	created, err := s.EditableFeed(ctx, id)
+	if err != nil {
+		return models.Feed{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return created, nil
+}
+
+// UpdateFeed saves changes of feed item if it is still at feed.Version and returns it as stored.
+// Returns storage.ErrVersionConflict if the item was changed since that version was read.
+func (s *Storage) UpdateFeed(ctx context.Context, feed models.Feed) (models.Feed, error) {
+	const op = "storage.sqlite.UpdateFeed"
+
+	values := feedValues(feed)
+	delete(values, "author_id")
+	delete(values, "created_at")
+
+	err := s.updateContent(ctx, "feed", feed.ID, feed.Version, values, feed.Tags, storage.ErrFeedNotFound)
+	if err != nil {
+		return models.Feed{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	updated, err := s.EditableFeed(ctx, feed.ID)
+	if err != nil {
+		return models.Feed{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return updated, nil
+}
+
+// DeleteFeed marks feed item at version deleted, it disappears for readers and editors.
+func (s *Storage) DeleteFeed(ctx context.Context, id int64, version int64, deletedAt time.Time) error {
+	const op = "storage.sqlite.DeleteFeed"
+
+	err := s.updateContent(ctx, "feed", id, version, map[string]any{"deleted_at": deletedAt.Unix()}, nil, storage.ErrFeedNotFound)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// PublishScheduled publishes content in review scheduled to be published by now.
+// Content gets publish date it was scheduled for. Returns the number of published items.
+func (s *Storage) PublishScheduled(ctx context.Context, now time.Time) (int64, error) {
+	const op = "storage.sqlite.PublishScheduled"
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var published int64
+	for _, table := range []string{"article", "course", "feed"} {
+		res, err := tx.ExecContext(ctx, fmt.Sprintf(`
+		UPDATE %s
+		SET status = ?, published_at = COALESCE(published_at, publish_at), publish_at = NULL, updated_at = ?, version = version + 1
+		WHERE status = ? AND publish_at <= ? AND deleted_at IS NULL
+`, table), models.ContentStatusPublished, now.Unix(), models.ContentStatusInReview, now.Unix())
+		if err != nil {
+			return 0, fmt.Errorf("%s: %w", op, err)
+		}
+
+		affected, err := res.RowsAffected()
+		if err != nil {
+			return 0, fmt.Errorf("%s: %w", op, err)
+		}
+		published += affected
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return published, nil
+}
+
+// articleValues returns values of columns editors set by column name.
+// Empty difficulty is stored as NULL to satisfy its CHECK constraint.
+func articleValues(a models.ArticleDetails) map[string]any {
+	return map[string]any{
+		"slug":         a.Slug,
+		"name":         a.Name,
+		"description":  a.Description,
+		"body":         a.Body,
+		"image":        a.Image,
+		"author_id":    a.AuthorID,
+		"updated_by":   a.UpdatedBy,
+		"difficulty":   sql.NullString{String: a.Difficulty, Valid: a.Difficulty != ""},
+		"status":       a.Status,
+		"published_at": unixTime(a.PublishedAt),
+		"publish_at":   unixTime(a.PublishAt),
+		"created_at":   a.CreatedAt.Unix(),
+		"updated_at":   a.UpdatedAt.Unix(),
+	}
+}
+
+func courseValues(c models.Course) map[string]any {
+	return map[string]any{
+		"slug":         c.Slug,
+		"name":         c.Name,
+		"description":  c.Description,
+		"image":        c.Image,
+		"author_id":    c.AuthorID,
+		"difficulty":   sql.NullString{String: c.Difficulty, Valid: c.Difficulty != ""},
+		"status":       c.Status,
+		"published_at": unixTime(c.PublishedAt),
+		"publish_at":   unixTime(c.PublishAt),
+		"created_at":   c.CreatedAt.Unix(),
+		"updated_at":   c.UpdatedAt.Unix(),
+	}
+}
+
+func feedValues(f models.Feed) map[string]any {
+	return map[string]any{
+		"slug":         f.Slug,
+		"name":         f.Name,
+		"description":  f.Description,
+		"image":        f.Image,
+		"link":         f.Link,
+		"author_id":    f.AuthorID,
+		"difficulty":   sql.NullString{String: f.Difficulty, Valid: f.Difficulty != ""},
+		"status":       f.Status,
+		"published_at": unixTime(f.PublishedAt),
+		"publish_at":   unixTime(f.PublishAt),
+		"created_at":   f.CreatedAt.Unix(),
+		"updated_at":   f.UpdatedAt.Unix(),
+	}
+}
+
+// createContent inserts row into table and sets its tags in one transaction, returns id of the row.
+func (s *Storage) createContent(ctx context.Context, table string, values map[string]any, tags []string) (int64, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	columns, args := splitValues(values)
+
+	res, err := tx.ExecContext(ctx, fmt.Sprintf(
+		"INSERT INTO %s (%s) VALUES (%s)",
+		table,
+		strings.Join(columns, ", "),
+		strings.TrimSuffix(strings.Repeat("?, ", len(columns)), ", "),
+	), args...)
+	if err != nil {
+		return 0, contentWriteError(err)
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	if err := setTags(ctx, tx, table, id, tags); err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+// updateContent sets values of row of table if the row is at version and increases the version.
+// Tags of the row are replaced unless tags is nil. Returns notFound if there is no such row
+// and storage.ErrVersionConflict if the row is at another version.
+func (s *Storage) updateContent(
+	ctx context.Context,
+	table string,
+	id int64,
+	version int64,
+	values map[string]any,
+	tags []string,
+	notFound error,
+) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if err := updateVersioned(ctx, tx, table, id, version, values, notFound); err != nil {
+		return err
+	}
+
+	if tags != nil {
+		if err := setTags(ctx, tx, table, id, tags); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// updateVersioned sets values of row of table in tx if the row is at version and increases the version.
+// Returns notFound if there is no such row and storage.ErrVersionConflict if the row is at another version.
+func updateVersioned(
+	ctx context.Context,
+	tx *sql.Tx,
+	table string,
+	id int64,
+	version int64,
+	values map[string]any,
+	notFound error,
+) error {
+	columns, args := splitValues(values)
+	for i, column := range columns {
+		columns[i] = column + " = ?"
+	}
+	args = append(args, id, version)
+
+	res, err := tx.ExecContext(ctx, fmt.Sprintf(
+		"UPDATE %s SET %s, version = version + 1 WHERE id = ? AND version = ? AND deleted_at IS NULL",
+		table,
+		strings.Join(columns, ", "),
+	), args...)
+	if err != nil {
+		return contentWriteError(err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		var exists bool
+		err := tx.QueryRowContext(
+			ctx,
+			fmt.Sprintf("SELECT EXISTS (SELECT 1 FROM %s WHERE id = ? AND deleted_at IS NULL)", table),
+			id,
+		).Scan(&exists)
+		if err != nil {
+			return err
+		}
+
+		if !exists {
+			return notFound
+		}
+
+		return storage.ErrVersionConflict
+	}
+
+	return nil
+}
+
+// setTags replaces tags of row of table.
+func setTags(ctx context.Context, tx *sql.Tx, table string, id int64, tags []string) error {
+	_, err := tx.ExecContext(ctx, fmt.Sprintf("DELETE FROM %[1]s_tags WHERE %[1]s_id = ?", table), id)
+	if err != nil {
+		return err
+	}
+
+	for _, tag := range tags {
+		_, err := tx.ExecContext(
+			ctx,
+			fmt.Sprintf("INSERT OR IGNORE INTO %[1]s_tags (%[1]s_id, tag) VALUES (?, ?)", table),
+			id,
+			tag,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// splitValues returns column names in stable order and their values.
+func splitValues(values map[string]any) ([]string, []any) {
+	columns := make([]string, 0, len(values))
+	for column := range values {
+		columns = append(columns, column)
+	}
+	slices.Sort(columns)
+
+	args := make([]any, 0, len(columns))
+	for _, column := range columns {
+		args = append(args, values[column])
+	}
+
+	return columns, args
+}
+
+func contentWriteError(err error) error {
+	var sqliteErr sqlite3.Error
+	if errors.As(err, &sqliteErr) && errors.Is(sqliteErr.ExtendedCode, sqlite3.ErrConstraintUnique) {
+		return storage.ErrSlugTaken
+	}
+
+	return err
+}
+
+func unixTime(t *time.Time) sql.NullInt64 {
+	if t == nil {
+		return sql.NullInt64{}
+	}
+
+	return sql.NullInt64{Int64: t.Unix(), Valid: true}
+}
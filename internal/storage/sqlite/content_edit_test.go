@@ -0,0 +1,299 @@
+package sqlite
+
+import (
+	"context"
+	"sso/internal/domain/models"
+	"sso/internal/storage"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContentEdit_Article(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	authorID, err := s.SaveUser(ctx, "author@example.com", []byte("hash"))
+	require.NoError(t, err)
+
+	created, err := s.CreateArticle(ctx, models.ArticleDetails{
+		Article: models.Article{
+			Slug:      "go",
+			Name:      "Go",
+			AuthorID:  &authorID,
+			Tags:      []string{"go", "backend"},
+			Status:    models.ContentStatusDraft,
+			CreatedAt: time.Unix(100, 0),
+			UpdatedAt: time.Unix(100, 0),
+		},
+		Body: "# Go",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), created.Version)
+	assert.Equal(t, []string{"backend", "go"}, created.Tags)
+	assert.Equal(t, "# Go", created.Body)
+	assert.Empty(t, created.Difficulty)
+
+	_, err = s.CreateArticle(ctx, models.ArticleDetails{Article: models.Article{Slug: "go", Name: "Another", Status: models.ContentStatusDraft}})
+	assert.ErrorIs(t, err, storage.ErrSlugTaken)
+
+	// Drafts are editable, but not visible to readers.
+	_, err = s.Article(ctx, created.ID)
+	assert.ErrorIs(t, err, storage.ErrArticleNotFound)
+
+	publishedAt := time.Unix(200, 0)
+	edit := created
+	edit.Name = "Go in practice"
+	edit.Difficulty = models.DifficultyAdvanced
+	edit.Tags = []string{"go"}
+	edit.Status = models.ContentStatusPublished
+	edit.PublishedAt = &publishedAt
+	edit.UpdatedAt = publishedAt
+
+	updated, err := s.UpdateArticle(ctx, edit)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), updated.Version)
+	assert.Equal(t, "Go in practice", updated.Name)
+	assert.Equal(t, models.DifficultyAdvanced, updated.Difficulty)
+	assert.Equal(t, []string{"go"}, updated.Tags)
+	assert.Equal(t, time.Unix(100, 0), updated.CreatedAt)
+
+	// Saving changes made to an outdated version fails.
+	_, err = s.UpdateArticle(ctx, edit)
+	assert.ErrorIs(t, err, storage.ErrVersionConflict)
+
+	published, err := s.Article(ctx, created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Go in practice", published.Name)
+
+	assert.ErrorIs(t, s.DeleteArticle(ctx, created.ID, 1, time.Unix(300, 0)), storage.ErrVersionConflict)
+	require.NoError(t, s.DeleteArticle(ctx, created.ID, 2, time.Unix(300, 0)))
+
+	// Deleted article disappears everywhere, but its row is kept.
+	_, err = s.Article(ctx, created.ID)
+	assert.ErrorIs(t, err, storage.ErrArticleNotFound)
+
+	_, err = s.EditableArticle(ctx, created.ID)
+	assert.ErrorIs(t, err, storage.ErrArticleNotFound)
+
+	articles, err := s.Articles(ctx, models.ContentQuery{})
+	require.NoError(t, err)
+	assert.Empty(t, articles.Items)
+
+	results, err := s.Search(ctx, models.SearchQuery{Query: "practice"})
+	require.NoError(t, err)
+	assert.Empty(t, results.Items)
+
+	assert.ErrorIs(t, s.DeleteArticle(ctx, created.ID, 3, time.Unix(400, 0)), storage.ErrArticleNotFound)
+
+	var deletedAt int64
+	require.NoError(t, s.db.QueryRowContext(ctx, "SELECT deleted_at FROM article WHERE id = ?", created.ID).Scan(&deletedAt))
+	assert.Equal(t, int64(300), deletedAt)
+}
+
+func TestContentEdit_CourseAndFeed(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	course, err := s.CreateCourse(ctx, models.Course{Slug: "go", Name: "Go", Tags: []string{}, Status: models.ContentStatusDraft})
+	require.NoError(t, err)
+
+	// Courses and feed items have their own slugs.
+	feed, err := s.CreateFeed(ctx, models.Feed{Slug: "go", Name: "Go", Link: "/course/1", Tags: []string{}, Status: models.ContentStatusDraft})
+	require.NoError(t, err)
+	assert.Equal(t, "/course/1", feed.Link)
+
+	course.Description = "Course"
+	course, err = s.UpdateCourse(ctx, course)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), course.Version)
+	assert.Equal(t, "Course", course.Description)
+
+	feed.Link = ""
+	feed, err = s.UpdateFeed(ctx, feed)
+	require.NoError(t, err)
+	assert.Empty(t, feed.Link)
+
+	_, err = s.UpdateCourse(ctx, models.Course{ID: 100, Slug: "missing", Name: "Missing", Version: 1, Status: models.ContentStatusDraft})
+	assert.ErrorIs(t, err, storage.ErrCourseNotFound)
+
+	require.NoError(t, s.DeleteCourse(ctx, course.ID, course.Version, time.Unix(100, 0)))
+	require.NoError(t, s.DeleteFeed(ctx, feed.ID, feed.Version, time.Unix(100, 0)))
+
+	_, err = s.EditableCourse(ctx, course.ID)
+	assert.ErrorIs(t, err, storage.ErrCourseNotFound)
+
+	_, err = s.EditableFeed(ctx, feed.ID)
+	assert.ErrorIs(t, err, storage.ErrFeedNotFound)
+}
+
+func TestPublishScheduled(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	at := func(sec int64) *time.Time {
+		t := time.Unix(sec, 0)
+		return &t
+	}
+
+	due, err := s.CreateArticle(ctx, models.ArticleDetails{Article: models.Article{
+		Slug: "due", Name: "Due", Tags: []string{}, Status: models.ContentStatusInReview, PublishAt: at(100),
+	}})
+	require.NoError(t, err)
+	assert.Equal(t, time.Unix(100, 0), *due.PublishAt)
+
+	later, err := s.CreateCourse(ctx, models.Course{
+		Slug: "later", Name: "Later", Tags: []string{}, Status: models.ContentStatusInReview, PublishAt: at(300),
+	})
+	require.NoError(t, err)
+
+	// Content in review without publish time waits for an editor.
+	_, err = s.CreateFeed(ctx, models.Feed{Slug: "review", Name: "Review", Tags: []string{}, Status: models.ContentStatusInReview})
+	require.NoError(t, err)
+
+	published, err := s.PublishScheduled(ctx, time.Unix(200, 0))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), published)
+
+	article, err := s.Article(ctx, due.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.ContentStatusPublished, article.Status)
+	assert.Equal(t, time.Unix(100, 0), *article.PublishedAt)
+	assert.Nil(t, article.PublishAt)
+	assert.Equal(t, int64(2), article.Version)
+
+	_, err = s.Course(ctx, later.ID)
+	assert.ErrorIs(t, err, storage.ErrCourseNotFound)
+
+	// Authors preview content before it is published.
+	preview, err := s.PreviewCourse(ctx, later.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.ContentStatusInReview, preview.Status)
+	assert.Empty(t, preview.Modules)
+
+	published, err = s.PublishScheduled(ctx, time.Unix(300, 0))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), published)
+
+	course, err := s.Course(ctx, later.ID)
+	require.NoError(t, err)
+	assert.Equal(t, time.Unix(300, 0), *course.PublishedAt)
+}
+
+func TestContentEdit_ModulesAndLessons(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	authorID, err := s.SaveUser(ctx, "author@example.com", []byte("hash"))
+	require.NoError(t, err)
+
+	course, err := s.CreateCourse(ctx, models.Course{Slug: "go", Name: "Go", Tags: []string{}, Status: models.ContentStatusPublished})
+	require.NoError(t, err)
+
+	now := time.Unix(100, 0)
+
+	second, version, err := s.CreateModule(ctx, course.ID, course.Version, models.CourseModule{Title: "Second"}, now)
+	require.NoError(t, err)
+	assert.Equal(t, course.Version+1, version)
+
+	// Modules and lessons are versioned with their course.
+	_, _, err = s.CreateModule(ctx, course.ID, course.Version, models.CourseModule{Title: "Stale"}, now)
+	assert.ErrorIs(t, err, storage.ErrVersionConflict)
+
+	first, version, err := s.CreateModule(ctx, course.ID, version, models.CourseModule{Title: "First", Position: 1}, now)
+	require.NoError(t, err)
+
+	_, _, err = s.CreateLesson(ctx, course.ID, version, models.Lesson{ModuleID: 1000}, now)
+	assert.ErrorIs(t, err, storage.ErrModuleNotFound)
+
+	lesson := models.Lesson{
+		LessonSummary: models.LessonSummary{Title: "Intro", Type: models.LessonTypeText},
+		ModuleID:      first,
+		UpdatedBy:     &authorID,
+		Body:          "# Intro",
+	}
+	intro, version, err := s.CreateLesson(ctx, course.ID, version, lesson, now)
+	require.NoError(t, err)
+
+	lesson.Title = "Basics"
+	basics, version, err := s.CreateLesson(ctx, course.ID, version, lesson, now)
+	require.NoError(t, err)
+
+	lesson.Title = "Video"
+	lesson.Type = models.LessonTypeVideo
+	lesson.URL = "https://example.com/video"
+	lesson.ModuleID = second
+	video, version, err := s.CreateLesson(ctx, course.ID, version, lesson, now)
+	require.NoError(t, err)
+
+	edit, err := s.EditableLesson(ctx, course.ID, intro)
+	require.NoError(t, err)
+	assert.Equal(t, &authorID, edit.UpdatedBy)
+
+	edit.Body = "# Intro\n\nMore"
+	edit.Position = 2
+	version, err = s.UpdateLesson(ctx, course.ID, version, edit, time.Unix(200, 0))
+	require.NoError(t, err)
+
+	var revisions int
+	require.NoError(t, s.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM lesson_revisions WHERE lesson_id = ?", intro).Scan(&revisions))
+	assert.Equal(t, 2, revisions)
+
+	preview, err := s.PreviewCourse(ctx, course.ID)
+	require.NoError(t, err)
+	assert.Equal(t, version, preview.Version)
+	require.Len(t, preview.Modules, 2)
+	assert.Equal(t, "First", preview.Modules[0].Title)
+	assert.Equal(t, []int64{basics, intro}, lessonIDs(preview.Modules[0].Lessons))
+	assert.Equal(t, []int64{video}, lessonIDs(preview.Modules[1].Lessons))
+
+	uid, err := s.SaveUser(ctx, "student@example.com", []byte("hash"))
+	require.NoError(t, err)
+	_, err = s.Enroll(ctx, uid, course.ID, now)
+	require.NoError(t, err)
+	require.NoError(t, s.CompleteLesson(ctx, uid, basics, now))
+
+	// Deleted lessons disappear from the course and progress, the rest are renumbered.
+	version, err = s.DeleteLesson(ctx, course.ID, version, basics, now)
+	require.NoError(t, err)
+
+	_, err = s.Lesson(ctx, course.ID, basics)
+	assert.ErrorIs(t, err, storage.ErrLessonNotFound)
+
+	published, err := s.Course(ctx, course.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []models.LessonSummary{{ID: intro, Position: 1, Title: "Intro", Type: models.LessonTypeText}}, published.Modules[0].Lessons)
+
+	progress, err := s.Progress(ctx, uid)
+	require.NoError(t, err)
+	require.Len(t, progress, 1)
+	assert.Equal(t, 2, progress[0].TotalLessons)
+	assert.Equal(t, 0, progress[0].CompletedLessons)
+
+	// Deleting module deletes its lessons too.
+	version, err = s.DeleteModule(ctx, course.ID, version, first, now)
+	require.NoError(t, err)
+
+	_, err = s.EditableLesson(ctx, course.ID, intro)
+	assert.ErrorIs(t, err, storage.ErrLessonNotFound)
+
+	_, err = s.UpdateModule(ctx, course.ID, version, models.CourseModule{ID: first, Title: "First"}, now)
+	assert.ErrorIs(t, err, storage.ErrModuleNotFound)
+
+	preview, err = s.PreviewCourse(ctx, course.ID)
+	require.NoError(t, err)
+	require.Len(t, preview.Modules, 1)
+	assert.Equal(t, second, preview.Modules[0].ID)
+	assert.Equal(t, 1, preview.Modules[0].Position)
+}
+
+func lessonIDs(lessons []models.LessonSummary) []int64 {
+	ids := make([]int64, len(lessons))
+	for i, l := range lessons {
+		ids[i] = l.ID
+	}
+
+	return ids
+}
@@ -0,0 +1,408 @@
+package sqlite
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sso/internal/domain/models"
+	"sso/internal/storage"
+	"testing"
+	"time"
+
+	"github.com/golang-migrate/migrate/v4"
+	_ "github.com/golang-migrate/migrate/v4/database/sqlite3"
+	_ "github.com/golang-migrate/migrate/v4/source/file"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const migrationsPath = "../../../migrations"
+
+// newTestStorage returns storage backed by a migrated SQLite database in a temporary directory.
+func newTestStorage(t *testing.T) *Storage {
+	t.Helper()
+
+	storagePath := filepath.Join(t.TempDir(), "sso.db")
+
+	m, err := migrate.New("file://"+migrationsPath, "sqlite3://"+storagePath)
+	require.NoError(t, err)
+	if err := m.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		t.Fatalf("failed to apply migrations: %v", err)
+	}
+	srcErr, dbErr := m.Close()
+	require.NoError(t, srcErr)
+	require.NoError(t, dbErr)
+
+	s, err := New(storagePath)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = s.Stop() })
+
+	return s
+}
+
+func TestContent_EmptyTables(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	articles, err := s.Articles(ctx, models.ContentQuery{})
+	require.NoError(t, err)
+	assert.NotNil(t, articles.Items)
+	assert.Empty(t, articles.Items)
+
+	courses, err := s.Courses(ctx, models.ContentQuery{})
+	require.NoError(t, err)
+	assert.NotNil(t, courses.Items)
+	assert.Empty(t, courses.Items)
+
+	feeds, err := s.Feeds(ctx, models.ContentQuery{})
+	require.NoError(t, err)
+	assert.NotNil(t, feeds.Items)
+	assert.Empty(t, feeds.Items)
+}
+
+func TestContent_Mapping(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	authorID, err := s.SaveUser(ctx, "author@example.com", []byte("hash"))
+	require.NoError(t, err)
+
+	_, err = s.db.ExecContext(ctx, `
+	INSERT INTO article (slug, name, description, body, image, author_id, status, published_at, created_at, updated_at)
+	VALUES ('go', 'Go', 'About Go', 'Body', 'go.png', ?, 'published', 200, 100, 300)
+`, authorID)
+	require.NoError(t, err)
+
+	_, err = s.db.ExecContext(ctx, `
+	INSERT INTO course (slug, name, description, image, author_id, status, published_at, created_at, updated_at)
+	VALUES ('backend', 'Backend', 'About backend', 'backend.png', ?, 'published', 200, 100, 300)
+`, authorID)
+	require.NoError(t, err)
+
+	_, err = s.db.ExecContext(ctx, `
+	INSERT INTO feed (slug, name, description, image, link, author_id, status, published_at, created_at, updated_at)
+	VALUES ('news', 'News', 'About news', 'news.png', '/courses/backend', ?, 'published', 200, 100, 300)
+`, authorID)
+	require.NoError(t, err)
+
+	publishedAt := time.Unix(200, 0)
+
+	articles, err := s.Articles(ctx, models.ContentQuery{})
+	require.NoError(t, err)
+	require.Len(t, articles.Items, 1)
+	assert.Equal(t, models.Article{
+		ID:          articles.Items[0].ID,
+		Slug:        "go",
+		Name:        "Go",
+		Description: "About Go",
+		Image:       "go.png",
+		AuthorID:    &authorID,
+		Tags:        []string{},
+		Status:      models.ContentStatusPublished,
+		Version:     1,
+		PublishedAt: &publishedAt,
+		CreatedAt:   time.Unix(100, 0),
+		UpdatedAt:   time.Unix(300, 0),
+	}, articles.Items[0])
+
+	courses, err := s.Courses(ctx, models.ContentQuery{})
+	require.NoError(t, err)
+	require.Len(t, courses.Items, 1)
+	assert.Equal(t, models.Course{
+		ID:          courses.Items[0].ID,
+		Slug:        "backend",
+		Name:        "Backend",
+		Description: "About backend",
+		Image:       "backend.png",
+		AuthorID:    &authorID,
+		Tags:        []string{},
+		Status:      models.ContentStatusPublished,
+		Version:     1,
+		PublishedAt: &publishedAt,
+		CreatedAt:   time.Unix(100, 0),
+		UpdatedAt:   time.Unix(300, 0),
+	}, courses.Items[0])
+
+	feeds, err := s.Feeds(ctx, models.ContentQuery{})
+	require.NoError(t, err)
+	require.Len(t, feeds.Items, 1)
+	assert.Equal(t, models.Feed{
+		ID:          feeds.Items[0].ID,
+		Slug:        "news",
+		Name:        "News",
+		Description: "About news",
+		Image:       "news.png",
+		Link:        "/courses/backend",
+		AuthorID:    &authorID,
+		Tags:        []string{},
+		Status:      models.ContentStatusPublished,
+		Version:     1,
+		PublishedAt: &publishedAt,
+		CreatedAt:   time.Unix(100, 0),
+		UpdatedAt:   time.Unix(300, 0),
+	}, feeds.Items[0])
+}
+
+func TestContent_NullColumns(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	for _, table := range []string{"article", "course", "feed"} {
+		_, err := s.db.ExecContext(ctx, fmt.Sprintf(`
+		INSERT INTO %s (slug, name, status, created_at, updated_at)
+		VALUES ('no-author', 'No author', 'published', 100, 100)
+`, table))
+		require.NoError(t, err)
+	}
+
+	// Deleting the author keeps content and sets author_id to NULL.
+	authorID, err := s.SaveUser(ctx, "author@example.com", []byte("hash"))
+	require.NoError(t, err)
+	_, err = s.db.ExecContext(ctx, `
+	INSERT INTO article (slug, name, author_id, status, published_at, created_at, updated_at)
+	VALUES ('deleted-author', 'Deleted author', ?, 'published', 50, 50, 50)
+`, authorID)
+	require.NoError(t, err)
+	require.NoError(t, s.DeleteUser(ctx, authorID))
+
+	articles, err := s.Articles(ctx, models.ContentQuery{})
+	require.NoError(t, err)
+	require.Len(t, articles.Items, 2)
+	for _, article := range articles.Items {
+		assert.Nil(t, article.AuthorID)
+		assert.Empty(t, article.Description)
+		assert.Empty(t, article.Image)
+	}
+	assert.Nil(t, articles.Items[1].PublishedAt)
+
+	courses, err := s.Courses(ctx, models.ContentQuery{})
+	require.NoError(t, err)
+	require.Len(t, courses.Items, 1)
+	assert.Nil(t, courses.Items[0].AuthorID)
+	assert.Nil(t, courses.Items[0].PublishedAt)
+
+	feeds, err := s.Feeds(ctx, models.ContentQuery{})
+	require.NoError(t, err)
+	require.Len(t, feeds.Items, 1)
+	assert.Nil(t, feeds.Items[0].AuthorID)
+	assert.Nil(t, feeds.Items[0].PublishedAt)
+	assert.Empty(t, feeds.Items[0].Link)
+}
+
+func TestContent_OnlyPublished(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	for _, status := range []string{
+		models.ContentStatusDraft,
+		models.ContentStatusPublished,
+		models.ContentStatusArchived,
+	} {
+		_, err := s.db.ExecContext(ctx, `
+		INSERT INTO article (slug, name, status, created_at, updated_at) VALUES (?, ?, ?, 100, 100)
+`, status, status, status)
+		require.NoError(t, err)
+	}
+
+	articles, err := s.Articles(ctx, models.ContentQuery{})
+	require.NoError(t, err)
+	require.Len(t, articles.Items, 1)
+	assert.Equal(t, models.ContentStatusPublished, articles.Items[0].Status)
+}
+
+func TestContent_LargeResultSet(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	const n = 5000
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	require.NoError(t, err)
+	for i := 0; i < n; i++ {
+		_, err := tx.ExecContext(ctx, `
+		INSERT INTO feed (slug, name, status, published_at, created_at, updated_at)
+		VALUES (?, ?, 'published', ?, ?, ?)
+`, fmt.Sprintf("feed-%d", i), fmt.Sprintf("Feed %d", i), i, i, i)
+		require.NoError(t, err)
+	}
+	require.NoError(t, tx.Commit())
+
+	// Walking all pages returns every row once, most recently published first.
+	var slugs []string
+	query := models.ContentQuery{Limit: 100}
+	for {
+		feeds, err := s.Feeds(ctx, query)
+		require.NoError(t, err)
+		require.LessOrEqual(t, len(feeds.Items), query.Limit)
+
+		for _, feed := range feeds.Items {
+			slugs = append(slugs, feed.Slug)
+		}
+
+		if feeds.NextCursor == "" {
+			break
+		}
+		query.Cursor = feeds.NextCursor
+	}
+
+	require.Len(t, slugs, n)
+	for i, slug := range slugs {
+		assert.Equal(t, fmt.Sprintf("feed-%d", n-1-i), slug)
+	}
+}
+
+func TestContent_DefaultLimit(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	for i := 0; i < defaultContentLimit+1; i++ {
+		_, err := s.db.ExecContext(ctx, `
+		INSERT INTO course (slug, name, status, created_at, updated_at) VALUES (?, 'Course', 'published', 100, 100)
+`, fmt.Sprintf("course-%d", i))
+		require.NoError(t, err)
+	}
+
+	first, err := s.Courses(ctx, models.ContentQuery{})
+	require.NoError(t, err)
+	assert.Len(t, first.Items, defaultContentLimit)
+	assert.NotEmpty(t, first.NextCursor)
+
+	// Rows without published_at and with equal sort keys are still paged by id.
+	last, err := s.Courses(ctx, models.ContentQuery{Cursor: first.NextCursor})
+	require.NoError(t, err)
+	require.Len(t, last.Items, 1)
+	assert.Empty(t, last.NextCursor)
+	assert.Equal(t, "course-0", last.Items[0].Slug)
+}
+
+func TestContent_SortByPopularity(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	for slug, views := range map[string]int{"rare": 1, "popular": 100, "average": 10} {
+		_, err := s.db.ExecContext(ctx, `
+		INSERT INTO article (slug, name, views, status, published_at, created_at, updated_at)
+		VALUES (?, ?, ?, 'published', ?, 100, 100)
+`, slug, slug, views, views)
+		require.NoError(t, err)
+	}
+
+	var slugs []string
+	query := models.ContentQuery{Sort: models.ContentSortPopularity, Limit: 1}
+	for {
+		articles, err := s.Articles(ctx, query)
+		require.NoError(t, err)
+
+		for _, article := range articles.Items {
+			slugs = append(slugs, article.Slug)
+		}
+
+		if articles.NextCursor == "" {
+			break
+		}
+		query.Cursor = articles.NextCursor
+	}
+
+	assert.Equal(t, []string{"popular", "average", "rare"}, slugs)
+}
+
+func TestContent_Filters(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	authorID, err := s.SaveUser(ctx, "author@example.com", []byte("hash"))
+	require.NoError(t, err)
+
+	insert := func(slug string, authorID any, difficulty any, tags ...string) {
+		res, err := s.db.ExecContext(ctx, `
+		INSERT INTO article (slug, name, author_id, difficulty, status, created_at, updated_at)
+		VALUES (?, ?, ?, ?, 'published', 100, 100)
+`, slug, slug, authorID, difficulty)
+		require.NoError(t, err)
+
+		id, err := res.LastInsertId()
+		require.NoError(t, err)
+
+		for _, tag := range tags {
+			_, err := s.db.ExecContext(ctx, "INSERT INTO article_tags (article_id, tag) VALUES (?, ?)", id, tag)
+			require.NoError(t, err)
+		}
+	}
+
+	insert("go-basics", authorID, models.DifficultyBeginner, "go", "backend")
+	insert("go-advanced", authorID, models.DifficultyAdvanced, "go")
+	insert("sql-basics", nil, models.DifficultyBeginner, "sql")
+	insert("untagged", nil, nil)
+
+	tests := []struct {
+		name  string
+		query models.ContentQuery
+		slugs []string
+	}{
+		{
+			name:  "No filters",
+			query: models.ContentQuery{},
+			slugs: []string{"untagged", "sql-basics", "go-advanced", "go-basics"},
+		},
+		{
+			name:  "Tag",
+			query: models.ContentQuery{Tag: "go"},
+			slugs: []string{"go-advanced", "go-basics"},
+		},
+		{
+			name:  "Author",
+			query: models.ContentQuery{AuthorID: authorID},
+			slugs: []string{"go-advanced", "go-basics"},
+		},
+		{
+			name:  "Difficulty",
+			query: models.ContentQuery{Difficulty: models.DifficultyBeginner},
+			slugs: []string{"sql-basics", "go-basics"},
+		},
+		{
+			name:  "All filters",
+			query: models.ContentQuery{Tag: "go", AuthorID: authorID, Difficulty: models.DifficultyBeginner},
+			slugs: []string{"go-basics"},
+		},
+		{
+			name:  "Unknown tag",
+			query: models.ContentQuery{Tag: "rust"},
+			slugs: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			articles, err := s.Articles(ctx, tt.query)
+			require.NoError(t, err)
+
+			slugs := []string{}
+			for _, article := range articles.Items {
+				slugs = append(slugs, article.Slug)
+			}
+			assert.Equal(t, tt.slugs, slugs)
+		})
+	}
+
+	articles, err := s.Articles(ctx, models.ContentQuery{Tag: "backend"})
+	require.NoError(t, err)
+	require.Len(t, articles.Items, 1)
+	assert.Equal(t, []string{"backend", "go"}, articles.Items[0].Tags)
+	assert.Equal(t, models.DifficultyBeginner, articles.Items[0].Difficulty)
+}
+
+func TestContent_InvalidCursor(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	for _, cursor := range []string{
+		"not base64!",
+		encodeCursor(models.ContentSortPopularity, 1, 1),
+		base64.RawURLEncoding.EncodeToString([]byte("date:x:1")),
+	} {
+		_, err := s.Feeds(ctx, models.ContentQuery{Sort: models.ContentSortDate, Cursor: cursor})
+		assert.ErrorIs(t, err, storage.ErrInvalidCursor, cursor)
+	}
+}
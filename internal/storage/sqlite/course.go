@@ -0,0 +1,206 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sso/internal/domain/models"
+	"sso/internal/storage"
+)
+
+// Course returns published course with its modules and lessons in order.
+func (s *Storage) Course(ctx context.Context, id int64) (models.CourseDetails, error) {
+	const op = "storage.sqlite.Course"
+
+	row := s.db.QueryRowContext(
+		ctx,
+		"SELECT "+courseColumns+" FROM course WHERE course.id = ? AND status = ? AND deleted_at IS NULL",
+		id,
+		models.ContentStatusPublished,
+	)
+
+	course, err := scanCourse(row)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.CourseDetails{}, fmt.Errorf("%s: %w", op, storage.ErrCourseNotFound)
+		}
+
+		return models.CourseDetails{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	modules, err := s.courseModules(ctx, course.ID)
+	if err != nil {
+		return models.CourseDetails{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return models.CourseDetails{Course: course, Modules: modules}, nil
+}
+
+// courseModules returns modules of course with their lessons in order.
+func (s *Storage) courseModules(ctx context.Context, courseID int64) ([]models.CourseModule, error) {
+	rows, err := s.db.QueryContext(ctx, `
+	SELECT m.id, m.position, m.title, m.description,
+	       l.id, l.position, l.title, l.type, l.duration_minutes
+	FROM course_modules m
+	LEFT JOIN course_lessons l ON l.module_id = m.id AND l.deleted_at IS NULL
+	WHERE m.course_id = ? AND m.deleted_at IS NULL
+	ORDER BY m.position, l.position
+`, courseID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	modules := []models.CourseModule{}
+	for rows.Next() {
+		var (
+			module   models.CourseModule
+			lessonID sql.NullInt64
+			position sql.NullInt64
+			title    sql.NullString
+			typ      sql.NullString
+			duration sql.NullInt64
+		)
+
+		err := rows.Scan(
+			&module.ID,
+			&module.Position,
+			&module.Title,
+			&module.Description,
+			&lessonID,
+			&position,
+			&title,
+			&typ,
+			&duration,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		// Rows are ordered by module, so a new module starts whenever the id changes.
+		if n := len(modules); n == 0 || modules[n-1].ID != module.ID {
+			module.Lessons = []models.LessonSummary{}
+			modules = append(modules, module)
+		}
+
+		// Modules without lessons come with NULL lesson columns.
+		if !lessonID.Valid {
+			continue
+		}
+
+		current := &modules[len(modules)-1]
+		current.Lessons = append(current.Lessons, models.LessonSummary{
+			ID:              lessonID.Int64,
+			Position:        int(position.Int64),
+			Title:           title.String,
+			Type:            typ.String,
+			DurationMinutes: nullInt(duration),
+		})
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return modules, nil
+}
+
+// Lesson returns lesson of published course, quiz lessons come with their questions.
+// Returns storage.ErrLessonNotFound if the lesson doesn't belong to the course.
+func (s *Storage) Lesson(ctx context.Context, courseID int64, lessonID int64) (models.Lesson, error) {
+	const op = "storage.sqlite.Lesson"
+
+	var (
+		lesson   models.Lesson
+		duration sql.NullInt64
+	)
+
+	err := s.db.QueryRowContext(ctx, `
+	SELECT l.id, l.position, l.title, l.type, l.duration_minutes, c.id, m.id, l.body, l.url
+	FROM course_lessons l
+	JOIN course_modules m ON m.id = l.module_id
+	JOIN course c ON c.id = m.course_id
+	WHERE l.id = ? AND c.id = ? AND c.status = ? AND c.deleted_at IS NULL AND l.deleted_at IS NULL
+`, lessonID, courseID, models.ContentStatusPublished).Scan(
+		&lesson.ID,
+		&lesson.Position,
+		&lesson.Title,
+		&lesson.Type,
+		&duration,
+		&lesson.CourseID,
+		&lesson.ModuleID,
+		&lesson.Body,
+		&lesson.URL,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.Lesson{}, fmt.Errorf("%s: %w", op, storage.ErrLessonNotFound)
+		}
+
+		return models.Lesson{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	lesson.DurationMinutes = nullInt(duration)
+
+	if lesson.Type == models.LessonTypeQuiz {
+		lesson.Quiz, err = s.quiz(ctx, lesson.ID)
+		if err != nil {
+			return models.Lesson{}, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	return lesson, nil
+}
+
+// quiz returns questions of quiz lesson with their options in order.
+func (s *Storage) quiz(ctx context.Context, lessonID int64) ([]models.QuizQuestion, error) {
+	rows, err := s.db.QueryContext(ctx, `
+	SELECT q.id, q.text, o.id, o.text, o.correct
+	FROM lesson_quiz_questions q
+	JOIN lesson_quiz_options o ON o.question_id = q.id
+	WHERE q.lesson_id = ?
+	ORDER BY q.position, o.position
+`, lessonID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	questions := []models.QuizQuestion{}
+	for rows.Next() {
+		var (
+			question models.QuizQuestion
+			option   models.QuizOption
+		)
+
+		if err := rows.Scan(&question.ID, &question.Text, &option.ID, &option.Text, &option.Correct); err != nil {
+			return nil, err
+		}
+
+		if n := len(questions); n == 0 || questions[n-1].ID != question.ID {
+			questions = append(questions, question)
+		}
+
+		current := &questions[len(questions)-1]
+		current.Options = append(current.Options, option)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return questions, nil
+}
+
+// IncrementCourseViews counts a view of course, views are used to sort lists by popularity.
+func (s *Storage) IncrementCourseViews(ctx context.Context, courseID int64) error {
+	const op = "storage.sqlite.IncrementCourseViews"
+
+	_, err := s.db.ExecContext(ctx, "UPDATE course SET views = views + 1 WHERE id = ?", courseID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
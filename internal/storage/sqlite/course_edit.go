@@ -0,0 +1,489 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"sso/internal/domain/models"
+	"sso/internal/storage"
+	"time"
+)
+
+// EditableLesson returns lesson of course of any status, quiz lessons come with their questions.
+// Deleted lessons and lessons of deleted courses are not found.
+func (s *Storage) EditableLesson(ctx context.Context, courseID int64, lessonID int64) (models.Lesson, error) {
+	const op = "storage.sqlite.EditableLesson"
+
+	var (
+		lesson    models.Lesson
+		duration  sql.NullInt64
+		updatedBy sql.NullInt64
+	)
+
+	err := s.db.QueryRowContext(ctx, `
+	SELECT l.id, l.position, l.title, l.type, l.duration_minutes, c.id, m.id, l.body, l.url, l.updated_by
+	FROM course_lessons l
+	JOIN course_modules m ON m.id = l.module_id
+	JOIN course c ON c.id = m.course_id
+	WHERE l.id = ? AND c.id = ? AND c.deleted_at IS NULL AND l.deleted_at IS NULL
+`, lessonID, courseID).Scan(
+		&lesson.ID,
+		&lesson.Position,
+		&lesson.Title,
+		&lesson.Type,
+		&duration,
+		&lesson.CourseID,
+		&lesson.ModuleID,
+		&lesson.Body,
+		&lesson.URL,
+		&updatedBy,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.Lesson{}, fmt.Errorf("%s: %w", op, storage.ErrLessonNotFound)
+		}
+
+		return models.Lesson{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	lesson.DurationMinutes = nullInt(duration)
+	lesson.UpdatedBy = nullInt64(updatedBy)
+
+	if lesson.Type == models.LessonTypeQuiz {
+		lesson.Quiz, err = s.quiz(ctx, lesson.ID)
+		if err != nil {
+			return models.Lesson{}, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	return lesson, nil
+}
+
+// CreateModule adds module to course at version, returns id of the module and the new version of the course.
+// Module is put at module.Position, following modules move down, or after the last one if position is 0.
+func (s *Storage) CreateModule(
+	ctx context.Context,
+	courseID int64,
+	version int64,
+	module models.CourseModule,
+	now time.Time,
+) (int64, int64, error) {
+	const op = "storage.sqlite.CreateModule"
+
+	var id int64
+	newVersion, err := s.editCourse(ctx, courseID, version, now, func(tx *sql.Tx) error {
+		res, err := tx.ExecContext(ctx, `
+		INSERT INTO course_modules (course_id, position, title, description, created_at, updated_at)
+		VALUES (?, (SELECT COALESCE(MAX(position), 0) + 1 FROM course_modules WHERE course_id = ?), ?, ?, ?, ?)
+`, courseID, courseID, module.Title, module.Description, now.Unix(), now.Unix())
+		if err != nil {
+			return err
+		}
+
+		id, err = res.LastInsertId()
+		if err != nil {
+			return err
+		}
+
+		if module.Position == 0 {
+			return nil
+		}
+
+		return movePosition(ctx, tx, "course_modules", "course_id", courseID, id, module.Position)
+	})
+	if err != nil {
+		return 0, 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return id, newVersion, nil
+}
+
+// UpdateModule saves title and description of module of course at version and returns the new version of the course.
+// Module is moved to module.Position unless it is 0.
+func (s *Storage) UpdateModule(
+	ctx context.Context,
+	courseID int64,
+	version int64,
+	module models.CourseModule,
+	now time.Time,
+) (int64, error) {
+	const op = "storage.sqlite.UpdateModule"
+
+	newVersion, err := s.editCourse(ctx, courseID, version, now, func(tx *sql.Tx) error {
+		res, err := tx.ExecContext(ctx, `
+		UPDATE course_modules SET title = ?, description = ?, updated_at = ?
+		WHERE id = ? AND course_id = ? AND deleted_at IS NULL
+`, module.Title, module.Description, now.Unix(), module.ID, courseID)
+		if err != nil {
+			return err
+		}
+
+		affected, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+
+		if affected == 0 {
+			return storage.ErrModuleNotFound
+		}
+
+		if module.Position == 0 {
+			return nil
+		}
+
+		return movePosition(ctx, tx, "course_modules", "course_id", courseID, module.ID, module.Position)
+	})
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return newVersion, nil
+}
+
+// DeleteModule marks module of course at version deleted together with its lessons
+// and returns the new version of the course.
+func (s *Storage) DeleteModule(
+	ctx context.Context,
+	courseID int64,
+	version int64,
+	moduleID int64,
+	deletedAt time.Time,
+) (int64, error) {
+	const op = "storage.sqlite.DeleteModule"
+
+	newVersion, err := s.editCourse(ctx, courseID, version, deletedAt, func(tx *sql.Tx) error {
+		res, err := tx.ExecContext(ctx, `
+		UPDATE course_modules SET deleted_at = ?, position = -id
+		WHERE id = ? AND course_id = ? AND deleted_at IS NULL
+`, deletedAt.Unix(), moduleID, courseID)
+		if err != nil {
+			return err
+		}
+
+		affected, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+
+		if affected == 0 {
+			return storage.ErrModuleNotFound
+		}
+
+		_, err = tx.ExecContext(ctx, `
+		UPDATE course_lessons SET deleted_at = ?, position = -id
+		WHERE module_id = ? AND deleted_at IS NULL
+`, deletedAt.Unix(), moduleID)
+		if err != nil {
+			return err
+		}
+
+		return renumber(ctx, tx, "course_modules", "course_id", courseID)
+	})
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return newVersion, nil
+}
+
+// CreateLesson adds lesson to module lesson.ModuleID of course at version,
+// returns id of the lesson and the new version of the course.
+// Lesson is put at lesson.Position, following lessons move down, or after the last one if position is 0.
+func (s *Storage) CreateLesson(
+	ctx context.Context,
+	courseID int64,
+	version int64,
+	lesson models.Lesson,
+	now time.Time,
+) (int64, int64, error) {
+	const op = "storage.sqlite.CreateLesson"
+
+	var id int64
+	newVersion, err := s.editCourse(ctx, courseID, version, now, func(tx *sql.Tx) error {
+		var exists bool
+		err := tx.QueryRowContext(
+			ctx,
+			"SELECT EXISTS (SELECT 1 FROM course_modules WHERE id = ? AND course_id = ? AND deleted_at IS NULL)",
+			lesson.ModuleID,
+			courseID,
+		).Scan(&exists)
+		if err != nil {
+			return err
+		}
+
+		if !exists {
+			return storage.ErrModuleNotFound
+		}
+
+		res, err := tx.ExecContext(ctx, `
+		INSERT INTO course_lessons (module_id, position, title, type, body, url, duration_minutes, updated_by, created_at, updated_at)
+		VALUES (?, (SELECT COALESCE(MAX(position), 0) + 1 FROM course_lessons WHERE module_id = ?), ?, ?, ?, ?, ?, ?, ?, ?)
+`,
+			lesson.ModuleID,
+			lesson.ModuleID,
+			lesson.Title,
+			lesson.Type,
+			lesson.Body,
+			lesson.URL,
+			lesson.DurationMinutes,
+			lesson.UpdatedBy,
+			now.Unix(),
+			now.Unix(),
+		)
+		if err != nil {
+			return err
+		}
+
+		id, err = res.LastInsertId()
+		if err != nil {
+			return err
+		}
+
+		if lesson.Position == 0 {
+			return nil
+		}
+
+		return movePosition(ctx, tx, "course_lessons", "module_id", lesson.ModuleID, id, lesson.Position)
+	})
+	if err != nil {
+		return 0, 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return id, newVersion, nil
+}
+
+// UpdateLesson saves lesson of course at version and returns the new version of the course.
+// Lesson stays in its module, it is moved to lesson.Position unless it is 0.
+// A revision of the lesson is saved if its title, body or url changes.
+func (s *Storage) UpdateLesson(
+	ctx context.Context,
+	courseID int64,
+	version int64,
+	lesson models.Lesson,
+	now time.Time,
+) (int64, error) {
+	const op = "storage.sqlite.UpdateLesson"
+
+	newVersion, err := s.editCourse(ctx, courseID, version, now, func(tx *sql.Tx) error {
+		moduleID, err := lessonModule(ctx, tx, courseID, lesson.ID)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, `
+		UPDATE course_lessons
+		SET title = ?, type = ?, body = ?, url = ?, duration_minutes = ?, updated_by = ?, updated_at = ?
+		WHERE id = ?
+`,
+			lesson.Title,
+			lesson.Type,
+			lesson.Body,
+			lesson.URL,
+			lesson.DurationMinutes,
+			lesson.UpdatedBy,
+			now.Unix(),
+			lesson.ID,
+		)
+		if err != nil {
+			return err
+		}
+
+		if lesson.Position == 0 {
+			return nil
+		}
+
+		return movePosition(ctx, tx, "course_lessons", "module_id", moduleID, lesson.ID, lesson.Position)
+	})
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return newVersion, nil
+}
+
+// DeleteLesson marks lesson of course at version deleted and returns the new version of the course.
+// Revisions of the lesson and progress of students are kept.
+func (s *Storage) DeleteLesson(
+	ctx context.Context,
+	courseID int64,
+	version int64,
+	lessonID int64,
+	deletedAt time.Time,
+) (int64, error) {
+	const op = "storage.sqlite.DeleteLesson"
+
+	newVersion, err := s.editCourse(ctx, courseID, version, deletedAt, func(tx *sql.Tx) error {
+		moduleID, err := lessonModule(ctx, tx, courseID, lessonID)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(
+			ctx,
+			"UPDATE course_lessons SET deleted_at = ?, position = -id WHERE id = ?",
+			deletedAt.Unix(),
+			lessonID,
+		)
+		if err != nil {
+			return err
+		}
+
+		return renumber(ctx, tx, "course_lessons", "module_id", moduleID)
+	})
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return newVersion, nil
+}
+
+// editCourse runs edit of modules and lessons of course in one transaction with increasing version of the course,
+// returns the new version. Returns storage.ErrCourseNotFound if there is no such course
+// and storage.ErrVersionConflict if the course is at another version.
+func (s *Storage) editCourse(
+	ctx context.Context,
+	courseID int64,
+	version int64,
+	now time.Time,
+	edit func(tx *sql.Tx) error,
+) (int64, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	err = updateVersioned(ctx, tx, "course", courseID, version, map[string]any{"updated_at": now.Unix()}, storage.ErrCourseNotFound)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := edit(tx); err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	return version + 1, nil
+}
+
+// lessonModule returns module of lesson of course, deleted lessons are not found.
+func lessonModule(ctx context.Context, tx *sql.Tx, courseID int64, lessonID int64) (int64, error) {
+	var moduleID int64
+	err := tx.QueryRowContext(ctx, `
+	SELECT l.module_id
+	FROM course_lessons l
+	JOIN course_modules m ON m.id = l.module_id
+	WHERE l.id = ? AND m.course_id = ? AND l.deleted_at IS NULL
+`, lessonID, courseID).Scan(&moduleID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, storage.ErrLessonNotFound
+		}
+
+		return 0, err
+	}
+
+	return moduleID, nil
+}
+
+// movePosition moves row id of table to position among rows with the same parent, the first position is 1.
+// Positions past the last row put it after the last one.
+func movePosition(
+	ctx context.Context,
+	tx *sql.Tx,
+	table string,
+	parentColumn string,
+	parentID int64,
+	id int64,
+	position int,
+) error {
+	ids, err := positioned(ctx, tx, table, parentColumn, parentID)
+	if err != nil {
+		return err
+	}
+
+	ids = slices.DeleteFunc(ids, func(v int64) bool { return v == id })
+	ids = slices.Insert(ids, min(max(position, 1), len(ids)+1)-1, id)
+
+	return setPositions(ctx, tx, table, parentColumn, parentID, ids)
+}
+
+// renumber closes gaps in positions of rows with the same parent left by deleted rows.
+func renumber(ctx context.Context, tx *sql.Tx, table string, parentColumn string, parentID int64) error {
+	ids, err := positioned(ctx, tx, table, parentColumn, parentID)
+	if err != nil {
+		return err
+	}
+
+	return setPositions(ctx, tx, table, parentColumn, parentID, ids)
+}
+
+// positioned returns ids of rows of table with the given parent which are not deleted, in order of position.
+func positioned(ctx context.Context, tx *sql.Tx, table string, parentColumn string, parentID int64) ([]int64, error) {
+	rows, err := tx.QueryContext(ctx, fmt.Sprintf(
+		"SELECT id FROM %s WHERE %s = ? AND deleted_at IS NULL ORDER BY position",
+		table,
+		parentColumn,
+	), parentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
+
+// setPositions numbers rows of table in order of ids starting from 1. Positions are unique within the parent
+// and SQLite checks it row by row, so the rows are moved past the largest position first.
+// Deleted rows have negative positions and stay where they are.
+func setPositions(
+	ctx context.Context,
+	tx *sql.Tx,
+	table string,
+	parentColumn string,
+	parentID int64,
+	ids []int64,
+) error {
+	var last int
+	err := tx.QueryRowContext(
+		ctx,
+		fmt.Sprintf("SELECT COALESCE(MAX(position), 0) FROM %s WHERE %s = ?", table, parentColumn),
+		parentID,
+	).Scan(&last)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(
+		ctx,
+		fmt.Sprintf("UPDATE %s SET position = position + ? WHERE %s = ? AND deleted_at IS NULL", table, parentColumn),
+		last,
+		parentID,
+	)
+	if err != nil {
+		return err
+	}
+
+	for i, id := range ids {
+		_, err := tx.ExecContext(ctx, fmt.Sprintf("UPDATE %s SET position = ? WHERE id = ?", table), i+1, id)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
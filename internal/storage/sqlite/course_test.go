@@ -0,0 +1,82 @@
+package sqlite
+
+import (
+	"context"
+	"sso/internal/domain/models"
+	"sso/internal/storage"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCourse_Tree(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	for _, q := range []string{
+		`INSERT INTO course (id, slug, name, status, created_at, updated_at) VALUES (1, 'go', 'Go', 'published', 1, 1)`,
+		`INSERT INTO course (id, slug, name, status, created_at, updated_at) VALUES (2, 'draft', 'Draft', 'draft', 1, 1)`,
+		// Modules and lessons are inserted out of order to check they are returned by position.
+		`INSERT INTO course_modules (id, course_id, position, title, created_at, updated_at) VALUES (10, 1, 2, 'Second', 1, 1)`,
+		`INSERT INTO course_modules (id, course_id, position, title, created_at, updated_at) VALUES (11, 1, 1, 'First', 1, 1)`,
+		`INSERT INTO course_modules (id, course_id, position, title, created_at, updated_at) VALUES (12, 1, 3, 'Empty', 1, 1)`,
+		`INSERT INTO course_lessons (id, module_id, position, title, type, duration_minutes, created_at, updated_at)
+		 VALUES (100, 11, 2, 'Quiz', 'quiz', NULL, 1, 1)`,
+		`INSERT INTO course_lessons (id, module_id, position, title, type, duration_minutes, created_at, updated_at)
+		 VALUES (101, 11, 1, 'Intro', 'text', 5, 1, 1)`,
+		`INSERT INTO course_lessons (id, module_id, position, title, type, url, created_at, updated_at)
+		 VALUES (102, 10, 1, 'Video', 'video', 'https://example.com/video', 1, 1)`,
+		`INSERT INTO lesson_quiz_questions (id, lesson_id, position, text) VALUES (1000, 100, 1, 'Question')`,
+		`INSERT INTO lesson_quiz_options (question_id, position, text, correct) VALUES (1000, 2, 'Wrong', 0)`,
+		`INSERT INTO lesson_quiz_options (question_id, position, text, correct) VALUES (1000, 1, 'Right', 1)`,
+	} {
+		_, err := s.db.ExecContext(ctx, q)
+		require.NoError(t, err, q)
+	}
+
+	course, err := s.Course(ctx, 1)
+	require.NoError(t, err)
+
+	duration := 5
+	assert.Equal(t, []models.CourseModule{
+		{
+			ID: 11, Position: 1, Title: "First",
+			Lessons: []models.LessonSummary{
+				{ID: 101, Position: 1, Title: "Intro", Type: models.LessonTypeText, DurationMinutes: &duration},
+				{ID: 100, Position: 2, Title: "Quiz", Type: models.LessonTypeQuiz},
+			},
+		},
+		{
+			ID: 10, Position: 2, Title: "Second",
+			Lessons: []models.LessonSummary{
+				{ID: 102, Position: 1, Title: "Video", Type: models.LessonTypeVideo},
+			},
+		},
+		{ID: 12, Position: 3, Title: "Empty", Lessons: []models.LessonSummary{}},
+	}, course.Modules)
+
+	_, err = s.Course(ctx, 2)
+	assert.ErrorIs(t, err, storage.ErrCourseNotFound)
+
+	quiz, err := s.Lesson(ctx, 1, 100)
+	require.NoError(t, err)
+	assert.Equal(t, int64(11), quiz.ModuleID)
+	assert.Equal(t, []models.QuizQuestion{{
+		ID:   1000,
+		Text: "Question",
+		Options: []models.QuizOption{
+			{ID: quiz.Quiz[0].Options[0].ID, Text: "Right", Correct: true},
+			{ID: quiz.Quiz[0].Options[1].ID, Text: "Wrong"},
+		},
+	}}, quiz.Quiz)
+
+	video, err := s.Lesson(ctx, 1, 102)
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com/video", video.URL)
+	assert.Nil(t, video.Quiz)
+
+	// Lesson of another course.
+	_, err = s.Lesson(ctx, 2, 100)
+	assert.ErrorIs(t, err, storage.ErrLessonNotFound)
+}
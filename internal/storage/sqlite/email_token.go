@@ -0,0 +1,104 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sso/internal/domain/models"
+	"sso/internal/storage"
+	"time"
+)
+
+// SaveEmailToken stores hash of a token sent to user by email.
+func (s *Storage) SaveEmailToken(ctx context.Context, token models.EmailToken) error {
+	const op = "storage.sqlite.SaveEmailToken"
+
+	stmt, err := s.db.Prepare(`
+	INSERT INTO email_tokens(token_hash, user_id, purpose, email, expires_at)
+	VALUES(?, ?, ?, ?, ?)
+`)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	_, err = stmt.ExecContext(ctx, token.TokenHash, token.UserID, token.Purpose, token.Email, token.ExpiresAt.Unix())
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// UseEmailToken marks email token with given purpose as used and returns it.
+// Tokens are single-use: if token was already used, returns storage.ErrEmailTokenNotFound.
+func (s *Storage) UseEmailToken(ctx context.Context, tokenHash string, purpose string) (models.EmailToken, error) {
+	const op = "storage.sqlite.UseEmailToken"
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return models.EmailToken{}, fmt.Errorf("%s: %w", op, err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	res, err := tx.ExecContext(ctx,
+		"UPDATE email_tokens SET used = 1 WHERE token_hash = ? AND purpose = ? AND used = 0",
+		tokenHash, purpose,
+	)
+	if err != nil {
+		return models.EmailToken{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return models.EmailToken{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if affected == 0 {
+		return models.EmailToken{}, fmt.Errorf("%s: %w", op, storage.ErrEmailTokenNotFound)
+	}
+
+	row := tx.QueryRowContext(ctx, `
+	SELECT token_hash, user_id, purpose, email, expires_at
+	FROM email_tokens
+	WHERE token_hash = ?
+`, tokenHash)
+
+	var (
+		token     models.EmailToken
+		expiresAt int64
+	)
+	err = row.Scan(&token.TokenHash, &token.UserID, &token.Purpose, &token.Email, &expiresAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.EmailToken{}, fmt.Errorf("%s: %w", op, storage.ErrEmailTokenNotFound)
+		}
+
+		return models.EmailToken{}, fmt.Errorf("%s: %w", op, err)
+	}
+	token.ExpiresAt = time.Unix(expiresAt, 0)
+
+	if err := tx.Commit(); err != nil {
+		return models.EmailToken{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return token, nil
+}
+
+// InvalidateEmailTokens marks all unused tokens of user with given purpose as used,
+// so that only the most recently sent one stays valid.
+func (s *Storage) InvalidateEmailTokens(ctx context.Context, userID int64, purpose string) error {
+	const op = "storage.sqlite.InvalidateEmailTokens"
+
+	stmt, err := s.db.Prepare("UPDATE email_tokens SET used = 1 WHERE user_id = ? AND purpose = ? AND used = 0")
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	_, err = stmt.ExecContext(ctx, userID, purpose)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
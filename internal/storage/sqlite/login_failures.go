@@ -0,0 +1,97 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sso/internal/domain/models"
+	"sso/internal/storage"
+	"time"
+)
+
+// LoginFailures returns failed login attempts counted for the key.
+func (s *Storage) LoginFailures(ctx context.Context, key string) (models.LoginFailures, error) {
+	const op = "storage.sqlite.LoginFailures"
+
+	stmt, err := s.db.Prepare(`
+	SELECT key, count, last_failure_at, blocked_until
+	FROM login_failures
+	WHERE key = ? AND expires_at > ?
+`)
+	if err != nil {
+		return models.LoginFailures{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	row := stmt.QueryRowContext(ctx, key, time.Now().Unix())
+
+	var (
+		failures      models.LoginFailures
+		lastFailureAt int64
+		blockedUntil  int64
+	)
+	err = row.Scan(&failures.Key, &failures.Count, &lastFailureAt, &blockedUntil)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.LoginFailures{}, fmt.Errorf("%s: %w", op, storage.ErrLoginFailuresNotFound)
+		}
+
+		return models.LoginFailures{}, fmt.Errorf("%s: %w", op, err)
+	}
+	failures.LastFailureAt = time.Unix(lastFailureAt, 0)
+	failures.BlockedUntil = time.Unix(blockedUntil, 0)
+
+	return failures, nil
+}
+
+// SaveLoginFailures stores failed login attempts counted for the key until expiresAt.
+func (s *Storage) SaveLoginFailures(ctx context.Context, failures models.LoginFailures, expiresAt time.Time) error {
+	const op = "storage.sqlite.SaveLoginFailures"
+
+	stmt, err := s.db.Prepare(`
+	INSERT INTO login_failures(key, count, last_failure_at, blocked_until, expires_at)
+	VALUES(?, ?, ?, ?, ?)
+	ON CONFLICT(key) DO UPDATE SET
+		count = excluded.count,
+		last_failure_at = excluded.last_failure_at,
+		blocked_until = excluded.blocked_until,
+		expires_at = excluded.expires_at
+`)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	_, err = stmt.ExecContext(ctx,
+		failures.Key, failures.Count, failures.LastFailureAt.Unix(), failures.BlockedUntil.Unix(), expiresAt.Unix(),
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	cleanup, err := s.db.Prepare("DELETE FROM login_failures WHERE expires_at < ?")
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if _, err := cleanup.ExecContext(ctx, time.Now().Unix()); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// DeleteLoginFailures forgets failed login attempts counted for the key.
+func (s *Storage) DeleteLoginFailures(ctx context.Context, key string) error {
+	const op = "storage.sqlite.DeleteLoginFailures"
+
+	stmt, err := s.db.Prepare("DELETE FROM login_failures WHERE key = ?")
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if _, err := stmt.ExecContext(ctx, key); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
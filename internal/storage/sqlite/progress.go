@@ -0,0 +1,240 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sso/internal/domain/models"
+	"sso/internal/storage"
+	"time"
+)
+
+// Enroll enrolls user in published course, enrolling again is not an error.
+// Reports whether the user was enrolled by this call.
+func (s *Storage) Enroll(ctx context.Context, userID int64, courseID int64, enrolledAt time.Time) (bool, error) {
+	const op = "storage.sqlite.Enroll"
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var exists bool
+	err = tx.QueryRowContext(
+		ctx,
+		"SELECT EXISTS (SELECT 1 FROM course WHERE id = ? AND status = ? AND deleted_at IS NULL)",
+		courseID,
+		models.ContentStatusPublished,
+	).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if !exists {
+		return false, fmt.Errorf("%s: %w", op, storage.ErrCourseNotFound)
+	}
+
+	res, err := tx.ExecContext(ctx, `
+	INSERT INTO enrollments(user_id, course_id, enrolled_at) VALUES(?, ?, ?)
+	ON CONFLICT (user_id, course_id) DO NOTHING
+`, userID, courseID, enrolledAt.Unix())
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return affected > 0, nil
+}
+
+// CompleteLesson marks lesson completed by user, it also becomes the last opened lesson of its course.
+// Returns storage.ErrLessonNotFound if there is no such lesson and storage.ErrNotEnrolled
+// if user is not enrolled in its course. Completing lesson again keeps the first completion time.
+func (s *Storage) CompleteLesson(ctx context.Context, userID int64, lessonID int64, completedAt time.Time) error {
+	const op = "storage.sqlite.CompleteLesson"
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var (
+		courseID int64
+		enrolled bool
+	)
+
+	err = tx.QueryRowContext(ctx, `
+	SELECT m.course_id, e.user_id IS NOT NULL
+	FROM course_lessons l
+	JOIN course_modules m ON m.id = l.module_id
+	LEFT JOIN enrollments e ON e.course_id = m.course_id AND e.user_id = ?
+	WHERE l.id = ? AND l.deleted_at IS NULL
+`, userID, lessonID).Scan(&courseID, &enrolled)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("%s: %w", op, storage.ErrLessonNotFound)
+		}
+
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if !enrolled {
+		return fmt.Errorf("%s: %w", op, storage.ErrNotEnrolled)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+	INSERT INTO lesson_progress(user_id, lesson_id, completed_at) VALUES(?, ?, ?)
+	ON CONFLICT (user_id, lesson_id) DO NOTHING
+`, userID, lessonID, completedAt.Unix())
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	_, err = tx.ExecContext(
+		ctx,
+		"UPDATE enrollments SET last_lesson_id = ?, last_opened_at = ? WHERE user_id = ? AND course_id = ?",
+		lessonID,
+		completedAt.Unix(),
+		userID,
+		courseID,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// OpenLesson records lesson of the course as the last one user opened.
+// Does nothing if user is not enrolled in the course.
+func (s *Storage) OpenLesson(ctx context.Context, userID int64, courseID int64, lessonID int64, openedAt time.Time) error {
+	const op = "storage.sqlite.OpenLesson"
+
+	_, err := s.db.ExecContext(
+		ctx,
+		"UPDATE enrollments SET last_lesson_id = ?, last_opened_at = ? WHERE user_id = ? AND course_id = ?",
+		lessonID,
+		openedAt.Unix(),
+		userID,
+		courseID,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// Progress returns progress of user in every course they are enrolled in, most recently studied first.
+func (s *Storage) Progress(ctx context.Context, userID int64) ([]models.CourseProgress, error) {
+	const op = "storage.sqlite.Progress"
+
+	progress, err := queryRows(ctx, s.db, scanCourseProgress, `
+	SELECT `+courseColumns+`,
+	       e.enrolled_at,
+	       (SELECT COUNT(*)
+	        FROM course_lessons cl
+	        JOIN course_modules cm ON cm.id = cl.module_id
+	        WHERE cm.course_id = course.id AND cl.deleted_at IS NULL),
+	       (SELECT COUNT(*)
+	        FROM lesson_progress lp
+	        JOIN course_lessons cl ON cl.id = lp.lesson_id
+	        JOIN course_modules cm ON cm.id = cl.module_id
+	        WHERE cm.course_id = course.id AND lp.user_id = e.user_id AND cl.deleted_at IS NULL),
+	       l.id, l.position, l.title, l.type, l.duration_minutes,
+	       e.last_opened_at
+	FROM enrollments e
+	JOIN course ON course.id = e.course_id AND course.deleted_at IS NULL
+	LEFT JOIN course_lessons l ON l.id = e.last_lesson_id AND l.deleted_at IS NULL
+	WHERE e.user_id = ?
+	ORDER BY COALESCE(e.last_opened_at, e.enrolled_at) DESC, course.id DESC
+`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return progress, nil
+}
+
+// LearningSummary counts courses user is enrolled in and lessons they completed.
+func (s *Storage) LearningSummary(ctx context.Context, userID int64) (models.LearningSummary, error) {
+	const op = "storage.sqlite.LearningSummary"
+
+	progress, err := s.Progress(ctx, userID)
+	if err != nil {
+		return models.LearningSummary{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	summary := models.LearningSummary{EnrolledCourses: len(progress)}
+	for _, p := range progress {
+		summary.CompletedLessons += p.CompletedLessons
+		if p.TotalLessons > 0 && p.CompletedLessons == p.TotalLessons {
+			summary.CompletedCourses++
+		}
+	}
+
+	return summary, nil
+}
+
+func scanCourseProgress(row rowScanner) (models.CourseProgress, error) {
+	var (
+		progress       models.CourseProgress
+		enrolledAt     int64
+		lastLessonID   sql.NullInt64
+		lessonPosition sql.NullInt64
+		lessonTitle    sql.NullString
+		lessonType     sql.NullString
+		lessonDuration sql.NullInt64
+		lastOpenedAt   sql.NullInt64
+	)
+
+	course, err := scanCourse(withExtra(
+		row,
+		&enrolledAt,
+		&progress.TotalLessons,
+		&progress.CompletedLessons,
+		&lastLessonID,
+		&lessonPosition,
+		&lessonTitle,
+		&lessonType,
+		&lessonDuration,
+		&lastOpenedAt,
+	))
+	if err != nil {
+		return models.CourseProgress{}, err
+	}
+
+	progress.Course = course
+	progress.EnrolledAt = time.Unix(enrolledAt, 0)
+	progress.LastOpenedAt = nullTime(lastOpenedAt)
+
+	if progress.TotalLessons > 0 {
+		progress.CompletionPercent = progress.CompletedLessons * 100 / progress.TotalLessons
+	}
+
+	if lastLessonID.Valid {
+		progress.LastLesson = &models.LessonSummary{
+			ID:              lastLessonID.Int64,
+			Position:        int(lessonPosition.Int64),
+			Title:           lessonTitle.String,
+			Type:            lessonType.String,
+			DurationMinutes: nullInt(lessonDuration),
+		}
+	}
+
+	return progress, nil
+}
@@ -0,0 +1,100 @@
+package sqlite
+
+import (
+	"context"
+	"sso/internal/domain/models"
+	"sso/internal/storage"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProgress(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	uid, err := s.SaveUser(ctx, "student@example.com", []byte("hash"))
+	require.NoError(t, err)
+
+	for _, q := range []string{
+		`INSERT INTO course (id, slug, name, status, created_at, updated_at) VALUES (1, 'go', 'Go', 'published', 1, 1)`,
+		`INSERT INTO course (id, slug, name, status, created_at, updated_at) VALUES (2, 'sql', 'SQL', 'published', 1, 1)`,
+		`INSERT INTO course (id, slug, name, status, created_at, updated_at) VALUES (3, 'draft', 'Draft', 'draft', 1, 1)`,
+		`INSERT INTO course_modules (id, course_id, position, title, created_at, updated_at) VALUES (10, 1, 1, 'Go', 1, 1)`,
+		`INSERT INTO course_modules (id, course_id, position, title, created_at, updated_at) VALUES (20, 2, 1, 'SQL', 1, 1)`,
+		`INSERT INTO course_lessons (id, module_id, position, title, type, created_at, updated_at)
+		 VALUES (100, 10, 1, 'One', 'text', 1, 1), (101, 10, 2, 'Two', 'text', 1, 1), (102, 10, 3, 'Three', 'text', 1, 1),
+		        (200, 20, 1, 'Only', 'text', 1, 1)`,
+	} {
+		_, err := s.db.ExecContext(ctx, q)
+		require.NoError(t, err, q)
+	}
+
+	enrolled, err := s.Enroll(ctx, uid, 1, time.Unix(1000, 0))
+	require.NoError(t, err)
+	assert.True(t, enrolled)
+
+	enrolled, err = s.Enroll(ctx, uid, 1, time.Unix(1001, 0))
+	require.NoError(t, err)
+	assert.False(t, enrolled)
+
+	_, err = s.Enroll(ctx, uid, 3, time.Unix(1000, 0))
+	assert.ErrorIs(t, err, storage.ErrCourseNotFound)
+
+	err = s.CompleteLesson(ctx, uid, 200, time.Unix(1100, 0))
+	assert.ErrorIs(t, err, storage.ErrNotEnrolled)
+
+	err = s.CompleteLesson(ctx, uid, 999, time.Unix(1100, 0))
+	assert.ErrorIs(t, err, storage.ErrLessonNotFound)
+
+	require.NoError(t, s.CompleteLesson(ctx, uid, 100, time.Unix(1100, 0)))
+	require.NoError(t, s.CompleteLesson(ctx, uid, 100, time.Unix(1150, 0)))
+	require.NoError(t, s.OpenLesson(ctx, uid, 1, 101, time.Unix(1200, 0)))
+
+	// Opening lesson of a course user is not enrolled in is ignored.
+	require.NoError(t, s.OpenLesson(ctx, uid, 2, 200, time.Unix(1300, 0)))
+
+	_, err = s.Enroll(ctx, uid, 2, time.Unix(1250, 0))
+	require.NoError(t, err)
+	require.NoError(t, s.CompleteLesson(ctx, uid, 200, time.Unix(1260, 0)))
+
+	progress, err := s.Progress(ctx, uid)
+	require.NoError(t, err)
+	require.Len(t, progress, 2)
+
+	// Most recently studied course first.
+	sqlCourse := progress[0]
+	assert.Equal(t, "sql", sqlCourse.Course.Slug)
+	assert.Equal(t, 1, sqlCourse.TotalLessons)
+	assert.Equal(t, 1, sqlCourse.CompletedLessons)
+	assert.Equal(t, 100, sqlCourse.CompletionPercent)
+	require.NotNil(t, sqlCourse.LastLesson)
+	assert.Equal(t, int64(200), sqlCourse.LastLesson.ID)
+
+	goCourse := progress[1]
+	assert.Equal(t, "go", goCourse.Course.Slug)
+	assert.Equal(t, time.Unix(1000, 0), goCourse.EnrolledAt)
+	assert.Equal(t, 3, goCourse.TotalLessons)
+	assert.Equal(t, 1, goCourse.CompletedLessons)
+	assert.Equal(t, 33, goCourse.CompletionPercent)
+	require.NotNil(t, goCourse.LastLesson)
+	assert.Equal(t, "Two", goCourse.LastLesson.Title)
+	require.NotNil(t, goCourse.LastOpenedAt)
+	assert.Equal(t, time.Unix(1200, 0), *goCourse.LastOpenedAt)
+
+	summary, err := s.LearningSummary(ctx, uid)
+	require.NoError(t, err)
+	assert.Equal(t, models.LearningSummary{EnrolledCourses: 2, CompletedCourses: 1, CompletedLessons: 2}, summary)
+
+	// Progress is deleted together with the user.
+	require.NoError(t, s.DeleteUser(ctx, uid))
+
+	var rows int
+	err = s.db.QueryRowContext(ctx, `
+	SELECT (SELECT COUNT(*) FROM enrollments) + (SELECT COUNT(*) FROM lesson_progress)
+`).Scan(&rows)
+	require.NoError(t, err)
+	assert.Zero(t, rows)
+}
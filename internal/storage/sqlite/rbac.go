@@ -0,0 +1,152 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/mattn/go-sqlite3"
+	"sso/internal/storage"
+	"time"
+)
+
+// UserRoles returns names of roles assigned to user.
+func (s *Storage) UserRoles(ctx context.Context, userID int64) ([]string, error) {
+	const op = "storage.sqlite.UserRoles"
+
+	stmt, err := s.db.Prepare(`
+	SELECT r.name
+	FROM user_roles ur
+	JOIN roles r ON r.id = ur.role_id
+	WHERE ur.user_id = ?
+	ORDER BY r.name
+`)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return s.names(ctx, op, stmt, userID)
+}
+
+// RolePermissions returns names of permissions granted to role.
+func (s *Storage) RolePermissions(ctx context.Context, role string) ([]string, error) {
+	const op = "storage.sqlite.RolePermissions"
+
+	stmt, err := s.db.Prepare(`
+	SELECT p.name
+	FROM role_permissions rp
+	JOIN roles r ON r.id = rp.role_id
+	JOIN permissions p ON p.id = rp.permission_id
+	WHERE r.name = ?
+	ORDER BY p.name
+`)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return s.names(ctx, op, stmt, role)
+}
+
+// AssignRole assigns role to user, assigning already assigned role is not an error.
+func (s *Storage) AssignRole(ctx context.Context, userID int64, role string) error {
+	const op = "storage.sqlite.AssignRole"
+
+	roleID, err := s.roleID(ctx, role)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	stmt, err := s.db.Prepare("INSERT INTO user_roles(user_id, role_id) VALUES(?, ?) ON CONFLICT DO NOTHING")
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	_, err = stmt.ExecContext(ctx, userID, roleID)
+	if err != nil {
+		var sqliteErr sqlite3.Error
+		if errors.As(err, &sqliteErr) && errors.Is(sqliteErr.ExtendedCode, sqlite3.ErrConstraintForeignKey) {
+			return fmt.Errorf("%s: %w", op, storage.ErrUserNotFound)
+		}
+
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// RevokeRole removes role from user.
+func (s *Storage) RevokeRole(ctx context.Context, userID int64, role string) error {
+	const op = "storage.sqlite.RevokeRole"
+
+	roleID, err := s.roleID(ctx, role)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	stmt, err := s.db.Prepare("DELETE FROM user_roles WHERE user_id = ? AND role_id = ?")
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if _, err := stmt.ExecContext(ctx, userID, roleID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// InvalidateAccessTokens makes access tokens of user issued before revokedAt invalid,
+// refresh tokens are kept, so clients get new access tokens without logging in again.
+func (s *Storage) InvalidateAccessTokens(ctx context.Context, userID int64, revokedAt time.Time) error {
+	const op = "storage.sqlite.InvalidateAccessTokens"
+
+	stmt, err := s.db.Prepare("UPDATE users SET tokens_revoked_at = ? WHERE id = ?")
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if _, err := stmt.ExecContext(ctx, revokedAt.Unix(), userID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *Storage) roleID(ctx context.Context, role string) (int64, error) {
+	var id int64
+
+	err := s.db.QueryRowContext(ctx, "SELECT id FROM roles WHERE name = ?", role).Scan(&id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, storage.ErrRoleNotFound
+		}
+
+		return 0, err
+	}
+
+	return id, nil
+}
+
+// names runs query selecting a single text column.
+func (s *Storage) names(ctx context.Context, op string, stmt *sql.Stmt, args ...any) ([]string, error) {
+	rows, err := stmt.QueryContext(ctx, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	names := []string{}
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		names = append(names, name)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return names, nil
+}
@@ -0,0 +1,118 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sso/internal/domain/models"
+	"sso/internal/storage"
+	"time"
+)
+
+// SaveRefreshToken stores hash of a newly issued refresh token.
+func (s *Storage) SaveRefreshToken(ctx context.Context, token models.RefreshToken) error {
+	const op = "storage.sqlite.SaveRefreshToken"
+
+	stmt, err := s.db.Prepare(`
+	INSERT INTO refresh_tokens(user_id, app_id, family_id, token_hash, expires_at)
+	VALUES(?, ?, ?, ?, ?)
+`)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	_, err = stmt.ExecContext(ctx, token.UserID, token.AppID, token.FamilyID, token.TokenHash, token.ExpiresAt.Unix())
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// RefreshToken returns refresh token by its hash.
+func (s *Storage) RefreshToken(ctx context.Context, tokenHash string) (models.RefreshToken, error) {
+	const op = "storage.sqlite.RefreshToken"
+
+	stmt, err := s.db.Prepare(`
+	SELECT id, user_id, app_id, family_id, token_hash, expires_at, used, revoked
+	FROM refresh_tokens
+	WHERE token_hash = ?
+`)
+	if err != nil {
+		return models.RefreshToken{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	row := stmt.QueryRowContext(ctx, tokenHash)
+
+	var (
+		token     models.RefreshToken
+		expiresAt int64
+	)
+	err = row.Scan(&token.ID, &token.UserID, &token.AppID, &token.FamilyID, &token.TokenHash, &expiresAt, &token.Used, &token.Revoked)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.RefreshToken{}, fmt.Errorf("%s: %w", op, storage.ErrRefreshTokenNotFound)
+		}
+
+		return models.RefreshToken{}, fmt.Errorf("%s: %w", op, err)
+	}
+	token.ExpiresAt = time.Unix(expiresAt, 0)
+
+	return token, nil
+}
+
+// UseRefreshToken atomically marks refresh token as used.
+// If token was already used, returns storage.ErrRefreshTokenUsed.
+func (s *Storage) UseRefreshToken(ctx context.Context, id int64) error {
+	const op = "storage.sqlite.UseRefreshToken"
+
+	stmt, err := s.db.Prepare("UPDATE refresh_tokens SET used = 1 WHERE id = ? AND used = 0")
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	res, err := stmt.ExecContext(ctx, id)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if affected == 0 {
+		return fmt.Errorf("%s: %w", op, storage.ErrRefreshTokenUsed)
+	}
+
+	return nil
+}
+
+// RevokeRefreshTokenFamily revokes every refresh token descending from the same login
+// and ends the session of that login.
+func (s *Storage) RevokeRefreshTokenFamily(ctx context.Context, familyID string) error {
+	const op = "storage.sqlite.RevokeRefreshTokenFamily"
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	_, err = tx.ExecContext(ctx, "UPDATE refresh_tokens SET revoked = 1 WHERE family_id = ?", familyID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	_, err = tx.ExecContext(ctx, "UPDATE sessions SET revoked = 1 WHERE family_id = ?", familyID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,92 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sso/internal/domain/models"
+	"sso/internal/storage"
+	"time"
+)
+
+// ArticleRevisions returns revisions of article without their bodies, the latest first.
+// Every article has at least one revision, so an empty history means there is no such article.
+func (s *Storage) ArticleRevisions(ctx context.Context, articleID int64) ([]models.Revision, error) {
+	const op = "storage.sqlite.ArticleRevisions"
+
+	rows, err := s.db.QueryContext(ctx, `
+	SELECT r.revision, r.name, r.description, r.author_id, r.created_at
+	FROM article_revisions r
+	JOIN article a ON a.id = r.article_id
+	WHERE r.article_id = ? AND a.deleted_at IS NULL
+	ORDER BY r.revision DESC
+`, articleID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	revisions := []models.Revision{}
+	for rows.Next() {
+		revision, err := scanRevision(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		revisions = append(revisions, revision)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if len(revisions) == 0 {
+		return nil, fmt.Errorf("%s: %w", op, storage.ErrArticleNotFound)
+	}
+
+	return revisions, nil
+}
+
+// ArticleRevision returns revision of article with its body.
+// Returns storage.ErrRevisionNotFound if article has no such revision or is deleted.
+func (s *Storage) ArticleRevision(ctx context.Context, articleID int64, number int64) (models.RevisionDetails, error) {
+	const op = "storage.sqlite.ArticleRevision"
+
+	row := s.db.QueryRowContext(ctx, `
+	SELECT r.revision, r.name, r.description, r.author_id, r.created_at, r.body
+	FROM article_revisions r
+	JOIN article a ON a.id = r.article_id
+	WHERE r.article_id = ? AND r.revision = ? AND a.deleted_at IS NULL
+`, articleID, number)
+
+	var details models.RevisionDetails
+	revision, err := scanRevision(withExtra(row, &details.Body))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.RevisionDetails{}, fmt.Errorf("%s: %w", op, storage.ErrRevisionNotFound)
+		}
+
+		return models.RevisionDetails{}, fmt.Errorf("%s: %w", op, err)
+	}
+	details.Revision = revision
+
+	return details, nil
+}
+
+func scanRevision(row rowScanner) (models.Revision, error) {
+	var (
+		revision  models.Revision
+		authorID  sql.NullInt64
+		createdAt int64
+	)
+
+	err := row.Scan(&revision.Number, &revision.Name, &revision.Description, &authorID, &createdAt)
+	if err != nil {
+		return models.Revision{}, err
+	}
+
+	revision.AuthorID = nullInt64(authorID)
+	revision.CreatedAt = time.Unix(createdAt, 0)
+
+	return revision, nil
+}
@@ -0,0 +1,113 @@
+package sqlite
+
+import (
+	"context"
+	"sso/internal/domain/models"
+	"sso/internal/storage"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestArticleRevisions(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	authorID, err := s.SaveUser(ctx, "author@example.com", []byte("hash"))
+	require.NoError(t, err)
+
+	editorID, err := s.SaveUser(ctx, "editor@example.com", []byte("hash"))
+	require.NoError(t, err)
+
+	article, err := s.CreateArticle(ctx, models.ArticleDetails{
+		Article: models.Article{
+			Slug:      "go",
+			Name:      "Go",
+			AuthorID:  &authorID,
+			UpdatedBy: &authorID,
+			Tags:      []string{},
+			Status:    models.ContentStatusDraft,
+			CreatedAt: time.Unix(100, 0),
+			UpdatedAt: time.Unix(100, 0),
+		},
+		Body: "first",
+	})
+	require.NoError(t, err)
+
+	article.Body = "first\nsecond"
+	article.UpdatedBy = &editorID
+	article.UpdatedAt = time.Unix(200, 0)
+	article, err = s.UpdateArticle(ctx, article)
+	require.NoError(t, err)
+
+	// Changes of anything but text don't make revisions.
+	article.Status = models.ContentStatusInReview
+	article, err = s.UpdateArticle(ctx, article)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), article.Version)
+	assert.Equal(t, editorID, *article.UpdatedBy)
+
+	revisions, err := s.ArticleRevisions(ctx, article.ID)
+	require.NoError(t, err)
+	require.Len(t, revisions, 2)
+	assert.Equal(t, models.Revision{Number: 2, Name: "Go", AuthorID: &editorID, CreatedAt: time.Unix(200, 0)}, revisions[0])
+	assert.Equal(t, models.Revision{Number: 1, Name: "Go", AuthorID: &authorID, CreatedAt: time.Unix(100, 0)}, revisions[1])
+
+	first, err := s.ArticleRevision(ctx, article.ID, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "first", first.Body)
+
+	_, err = s.ArticleRevision(ctx, article.ID, 3)
+	assert.ErrorIs(t, err, storage.ErrRevisionNotFound)
+
+	_, err = s.ArticleRevisions(ctx, 100)
+	assert.ErrorIs(t, err, storage.ErrArticleNotFound)
+
+	// History of deleted article is hidden with the article.
+	require.NoError(t, s.DeleteArticle(ctx, article.ID, article.Version, time.Unix(300, 0)))
+
+	_, err = s.ArticleRevisions(ctx, article.ID)
+	assert.ErrorIs(t, err, storage.ErrArticleNotFound)
+
+	_, err = s.ArticleRevision(ctx, article.ID, 1)
+	assert.ErrorIs(t, err, storage.ErrRevisionNotFound)
+}
+
+func TestLessonRevisions(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	for _, q := range []string{
+		`INSERT INTO course (id, slug, name, status, created_at, updated_at) VALUES (1, 'go', 'Go', 'published', 1, 1)`,
+		`INSERT INTO course_modules (id, course_id, position, title, created_at, updated_at) VALUES (1, 1, 1, 'Basics', 1, 1)`,
+		`INSERT INTO course_lessons (id, module_id, position, title, type, body, created_at, updated_at)
+		 VALUES (1, 1, 1, 'Install', 'text', 'first', 1, 1)`,
+		`UPDATE course_lessons SET duration_minutes = 5 WHERE id = 1`,
+		`UPDATE course_lessons SET body = 'second', updated_at = 2 WHERE id = 1`,
+	} {
+		_, err := s.db.ExecContext(ctx, q)
+		require.NoError(t, err, q)
+	}
+
+	rows, err := s.db.QueryContext(ctx, `SELECT revision, body, created_at FROM lesson_revisions WHERE lesson_id = 1 ORDER BY revision`)
+	require.NoError(t, err)
+	defer rows.Close()
+
+	type revision struct {
+		number    int64
+		body      string
+		createdAt int64
+	}
+
+	var revisions []revision
+	for rows.Next() {
+		var r revision
+		require.NoError(t, rows.Scan(&r.number, &r.body, &r.createdAt))
+		revisions = append(revisions, r)
+	}
+	require.NoError(t, rows.Err())
+
+	assert.Equal(t, []revision{{1, "first", 1}, {2, "second", 2}}, revisions)
+}
@@ -0,0 +1,61 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// RevokeToken stores id of the access token that must not be accepted anymore.
+// Records are kept only until the token expires by itself.
+func (s *Storage) RevokeToken(ctx context.Context, jti string, expiresAt time.Time) error {
+	const op = "storage.sqlite.RevokeToken"
+
+	stmt, err := s.db.Prepare(`
+	INSERT INTO revoked_tokens(jti, expires_at)
+	VALUES(?, ?)
+	ON CONFLICT DO NOTHING
+`)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if _, err := stmt.ExecContext(ctx, jti, expiresAt.Unix()); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	cleanup, err := s.db.Prepare("DELETE FROM revoked_tokens WHERE expires_at < ?")
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if _, err := cleanup.ExecContext(ctx, time.Now().Unix()); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// IsTokenRevoked checks if access token with given id was revoked.
+func (s *Storage) IsTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	const op = "storage.sqlite.IsTokenRevoked"
+
+	stmt, err := s.db.Prepare("SELECT 1 FROM revoked_tokens WHERE jti = ?")
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var found int
+	err = stmt.QueryRowContext(ctx, jti).Scan(&found)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, nil
+		}
+
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return true, nil
+}
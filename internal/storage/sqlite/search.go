@@ -0,0 +1,134 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"sso/internal/domain/models"
+	"strings"
+	"unicode"
+)
+
+const (
+	// maxSearchTerms bounds the size of the match expression built from user query.
+	maxSearchTerms = 10
+	// searchCursor marks search cursors, they hold offset of the next page as ranks can't be compared across pages.
+	searchCursor = "search"
+
+	// Snippet highlights are marked with control characters and turned into <mark> after escaping the snippet.
+	highlightStart = "\x02"
+	highlightEnd   = "\x03"
+)
+
+// searchSources describe how to search each content type, column weights of bm25 follow FTS columns.
+var searchSources = map[string]struct {
+	table   string
+	weights string
+}{
+	models.ContentTypeArticle: {table: "article", weights: "10.0, 5.0, 1.0"},
+	models.ContentTypeCourse:  {table: "course", weights: "10.0, 5.0"},
+	models.ContentTypeFeed:    {table: "feed", weights: "10.0, 5.0"},
+}
+
+var searchTypes = []string{models.ContentTypeArticle, models.ContentTypeCourse, models.ContentTypeFeed}
+
+// Search returns a page of published content matching query, best matches first.
+func (s *Storage) Search(ctx context.Context, query models.SearchQuery) (models.Page[models.SearchResult], error) {
+	const op = "storage.sqlite.Search"
+
+	limit := query.Limit
+	if limit <= 0 {
+		limit = defaultContentLimit
+	}
+	limit = min(limit, maxContentLimit)
+
+	var offset int64
+	if query.Cursor != "" {
+		var err error
+		if offset, _, err = decodeCursor(query.Cursor, searchCursor); err != nil {
+			return models.Page[models.SearchResult]{}, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	match := matchExpression(query.Query)
+	if match == "" {
+		return models.Page[models.SearchResult]{Items: []models.SearchResult{}}, nil
+	}
+
+	types := query.Types
+	if len(types) == 0 {
+		types = searchTypes
+	}
+
+	var (
+		selects []string
+		args    []any
+	)
+	for _, typ := range types {
+		source, ok := searchSources[typ]
+		if !ok {
+			return models.Page[models.SearchResult]{}, fmt.Errorf("%s: unknown content type %q", op, typ)
+		}
+
+		selects = append(selects, fmt.Sprintf(`
+		SELECT '%[1]s' AS type, %[2]s.id AS id, %[2]s.slug AS slug, %[2]s.name AS name, %[2]s.image AS image,
+		       snippet(%[2]s_search, -1, ?, ?, '…', 16) AS snippet,
+		       bm25(%[2]s_search, %[3]s) AS rank
+		FROM %[2]s_search
+		JOIN %[2]s ON %[2]s.id = %[2]s_search.rowid
+		WHERE %[2]s_search MATCH ? AND %[2]s.status = ? AND %[2]s.deleted_at IS NULL`, typ, source.table, source.weights))
+		args = append(args, highlightStart, highlightEnd, match, models.ContentStatusPublished)
+	}
+
+	// One extra row tells whether there is a next page.
+	args = append(args, limit+1, offset)
+
+	results, err := queryRows(ctx, s.db, scanSearchResult, `
+	SELECT type, id, slug, name, image, snippet
+	FROM (`+strings.Join(selects, "\n\t\tUNION ALL")+`)
+	ORDER BY rank, type, id
+	LIMIT ? OFFSET ?
+`, args...)
+	if err != nil {
+		return models.Page[models.SearchResult]{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	page := models.Page[models.SearchResult]{Items: results}
+	if len(results) > limit {
+		page.Items = results[:limit]
+		page.NextCursor = encodeCursor(searchCursor, offset+int64(limit), 0)
+	}
+
+	return page, nil
+}
+
+// matchExpression turns user query into FTS5 match expression requiring every word as a prefix,
+// so "прогр яз" finds "Язык программирования". Punctuation and FTS5 syntax in the query are ignored.
+func matchExpression(query string) string {
+	words := strings.FieldsFunc(query, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+
+	terms := make([]string, 0, min(len(words), maxSearchTerms))
+	for _, word := range words[:min(len(words), maxSearchTerms)] {
+		terms = append(terms, `"`+word+`"*`)
+	}
+
+	return strings.Join(terms, " ")
+}
+
+func scanSearchResult(row rowScanner) (models.SearchResult, error) {
+	var result models.SearchResult
+
+	err := row.Scan(&result.Type, &result.ID, &result.Slug, &result.Name, &result.Image, &result.Snippet)
+	if err != nil {
+		return models.SearchResult{}, err
+	}
+
+	result.Snippet = strings.NewReplacer(
+		highlightStart, "<mark>",
+		highlightEnd, "</mark>",
+	).Replace(html.EscapeString(result.Snippet))
+
+	return result, nil
+}
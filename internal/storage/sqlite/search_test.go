@@ -0,0 +1,124 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"sso/internal/domain/models"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSearch(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	for _, q := range []string{
+		`INSERT INTO article (id, slug, name, description, body, status, created_at, updated_at)
+		 VALUES (1, 'go-intro', 'Язык программирования Go', 'Введение', 'Ёмкий <b>синтаксис</b> и горутины', 'published', 1, 1)`,
+		`INSERT INTO article (id, slug, name, description, body, status, created_at, updated_at)
+		 VALUES (2, 'go-draft', 'Черновик про Go', '', '', 'draft', 1, 1)`,
+		`INSERT INTO article (id, slug, name, description, body, status, created_at, updated_at)
+		 VALUES (3, 'sql', 'Основы SQL', 'Запросы к базе данных', 'Язык запросов SQL', 'published', 1, 1)`,
+		`INSERT INTO course (id, slug, name, description, status, created_at, updated_at)
+		 VALUES (1, 'go-course', 'Курс по Go', 'Программирование на Go с нуля', 'published', 1, 1)`,
+		`INSERT INTO feed (id, slug, name, description, status, created_at, updated_at)
+		 VALUES (1, 'go-news', 'Вышел Go 1.24', 'Новости языка', 'published', 1, 1)`,
+	} {
+		_, err := s.db.ExecContext(ctx, q)
+		require.NoError(t, err, q)
+	}
+
+	search := func(query models.SearchQuery) []string {
+		t.Helper()
+
+		page, err := s.Search(ctx, query)
+		require.NoError(t, err)
+
+		ids := []string{}
+		for _, r := range page.Items {
+			ids = append(ids, fmt.Sprintf("%s:%s", r.Type, r.Slug))
+		}
+
+		return ids
+	}
+
+	// Prefixes of Russian words in any case, drafts are not found.
+	assert.ElementsMatch(t,
+		[]string{"article:go-intro", "course:go-course"},
+		search(models.SearchQuery{Query: "ПРОГРАММИР"}),
+	)
+
+	// Every word must match.
+	assert.Equal(t, []string{"article:sql"}, search(models.SearchQuery{Query: "язык запрос"}))
+
+	// Matches in name rank above matches in body.
+	assert.Equal(t,
+		[]string{"article:go-intro", "article:sql"},
+		search(models.SearchQuery{Query: "язык", Types: []string{models.ContentTypeArticle}}),
+	)
+
+	assert.Equal(t,
+		[]string{"feed:go-news"},
+		search(models.SearchQuery{Query: "go", Types: []string{models.ContentTypeFeed}}),
+	)
+
+	// FTS5 syntax in user query is treated as text.
+	assert.Empty(t, search(models.SearchQuery{Query: `"NEAR(*`}))
+	assert.Equal(t, []string{"article:sql"}, search(models.SearchQuery{Query: `(sql)^ -"`}))
+
+	// Snippets are escaped and matches highlighted.
+	page, err := s.Search(ctx, models.SearchQuery{Query: "синтакс"})
+	require.NoError(t, err)
+	require.Len(t, page.Items, 1)
+	assert.Contains(t, page.Items[0].Snippet, "&lt;b&gt;<mark>синтаксис</mark>&lt;/b&gt;")
+
+	// Index follows changes of content.
+	_, err = s.db.ExecContext(ctx, `UPDATE article SET name = 'Основы PostgreSQL' WHERE id = 3`)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"article:sql"}, search(models.SearchQuery{Query: "postgres"}))
+
+	_, err = s.db.ExecContext(ctx, `DELETE FROM article WHERE id = 3`)
+	require.NoError(t, err)
+	assert.Empty(t, search(models.SearchQuery{Query: "postgres"}))
+}
+
+func TestSearch_Pagination(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	const n = 25
+
+	for i := 0; i < n; i++ {
+		_, err := s.db.ExecContext(ctx, `
+		INSERT INTO article (slug, name, status, created_at, updated_at) VALUES (?, 'Статья про Go', 'published', 1, 1)
+`, fmt.Sprintf("go-%d", i))
+		require.NoError(t, err)
+	}
+
+	seen := map[int64]bool{}
+	query := models.SearchQuery{Query: "статья", Limit: 10}
+	pages := 0
+	for {
+		page, err := s.Search(ctx, query)
+		require.NoError(t, err)
+		pages++
+
+		for _, r := range page.Items {
+			assert.False(t, seen[r.ID], "result %d returned twice", r.ID)
+			seen[r.ID] = true
+		}
+
+		if page.NextCursor == "" {
+			break
+		}
+		query.Cursor = page.NextCursor
+	}
+
+	assert.Len(t, seen, n)
+	assert.Equal(t, 3, pages)
+
+	_, err := s.Search(ctx, models.SearchQuery{Query: "статья", Cursor: encodeCursor(models.ContentSortDate, 1, 1)})
+	assert.Error(t, err)
+}
@@ -0,0 +1,269 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sso/internal/domain/models"
+	"sso/internal/storage"
+	"time"
+)
+
+const sessionColumns = `id, user_id, app_id, family_id, device_name, user_agent, ip, created_at, last_seen_at, revoked`
+
+// SaveSession stores a new session and returns its id.
+func (s *Storage) SaveSession(ctx context.Context, session models.Session) (int64, error) {
+	const op = "storage.sqlite.SaveSession"
+
+	stmt, err := s.db.Prepare(`
+	INSERT INTO sessions(user_id, app_id, family_id, device_name, user_agent, ip, created_at, last_seen_at)
+	VALUES(?, ?, ?, ?, ?, ?, ?, ?)
+`)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	res, err := stmt.ExecContext(
+		ctx,
+		session.UserID,
+		session.AppID,
+		session.FamilyID,
+		session.DeviceName,
+		session.UserAgent,
+		session.IP,
+		session.CreatedAt.Unix(),
+		session.LastSeenAt.Unix(),
+	)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return id, nil
+}
+
+// SessionByFamily returns session the refresh token family belongs to.
+func (s *Storage) SessionByFamily(ctx context.Context, familyID string) (models.Session, error) {
+	const op = "storage.sqlite.SessionByFamily"
+
+	stmt, err := s.db.Prepare("SELECT " + sessionColumns + " FROM sessions WHERE family_id = ?")
+	if err != nil {
+		return models.Session{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	session, err := scanSession(stmt.QueryRowContext(ctx, familyID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.Session{}, fmt.Errorf("%s: %w", op, storage.ErrSessionNotFound)
+		}
+
+		return models.Session{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return session, nil
+}
+
+// Sessions returns sessions of user which are not revoked and were seen since the given time,
+// most recently used first.
+func (s *Storage) Sessions(ctx context.Context, userID int64, seenSince time.Time) ([]models.Session, error) {
+	const op = "storage.sqlite.Sessions"
+
+	stmt, err := s.db.Prepare(`
+	SELECT ` + sessionColumns + `
+	FROM sessions
+	WHERE user_id = ? AND revoked = 0 AND last_seen_at >= ?
+	ORDER BY last_seen_at DESC, id DESC
+`)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rows, err := stmt.QueryContext(ctx, userID, seenSince.Unix())
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	sessions := []models.Session{}
+	for rows.Next() {
+		session, err := scanSession(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		sessions = append(sessions, session)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return sessions, nil
+}
+
+// TouchSession updates last seen time of session.
+// Returns storage.ErrSessionNotFound if session doesn't exist or is revoked.
+func (s *Storage) TouchSession(ctx context.Context, sessionID int64, lastSeenAt time.Time) error {
+	const op = "storage.sqlite.TouchSession"
+
+	stmt, err := s.db.Prepare("UPDATE sessions SET last_seen_at = MAX(last_seen_at, ?) WHERE id = ? AND revoked = 0")
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	res, err := stmt.ExecContext(ctx, lastSeenAt.Unix(), sessionID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if affected == 0 {
+		return fmt.Errorf("%s: %w", op, storage.ErrSessionNotFound)
+	}
+
+	return nil
+}
+
+// RevokeSession revokes session of user together with its refresh tokens.
+// Returns storage.ErrSessionNotFound if user has no such active session.
+func (s *Storage) RevokeSession(ctx context.Context, userID int64, sessionID int64) error {
+	const op = "storage.sqlite.RevokeSession"
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	res, err := tx.ExecContext(
+		ctx,
+		"UPDATE sessions SET revoked = 1 WHERE id = ? AND user_id = ? AND revoked = 0",
+		sessionID,
+		userID,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if affected == 0 {
+		return fmt.Errorf("%s: %w", op, storage.ErrSessionNotFound)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+	UPDATE refresh_tokens SET revoked = 1
+	WHERE family_id = (SELECT family_id FROM sessions WHERE id = ?)
+`, sessionID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// RevokeOtherSessions revokes all sessions of user except keepSessionID together with their refresh tokens
+// and returns ids of revoked sessions.
+func (s *Storage) RevokeOtherSessions(ctx context.Context, userID int64, keepSessionID int64) ([]int64, error) {
+	const op = "storage.sqlite.RevokeOtherSessions"
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	rows, err := tx.QueryContext(
+		ctx,
+		"SELECT id FROM sessions WHERE user_id = ? AND id != ? AND revoked = 0",
+		userID,
+		keepSessionID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			_ = rows.Close()
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		ids = append(ids, id)
+	}
+	_ = rows.Close()
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+	UPDATE refresh_tokens SET revoked = 1
+	WHERE user_id = ? AND family_id NOT IN (SELECT family_id FROM sessions WHERE id = ?)
+`, userID, keepSessionID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	_, err = tx.ExecContext(ctx, "UPDATE sessions SET revoked = 1 WHERE user_id = ? AND id != ?", userID, keepSessionID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return ids, nil
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanSession(row rowScanner) (models.Session, error) {
+	var (
+		session    models.Session
+		createdAt  int64
+		lastSeenAt int64
+	)
+
+	err := row.Scan(
+		&session.ID,
+		&session.UserID,
+		&session.AppID,
+		&session.FamilyID,
+		&session.DeviceName,
+		&session.UserAgent,
+		&session.IP,
+		&createdAt,
+		&lastSeenAt,
+		&session.Revoked,
+	)
+	if err != nil {
+		return models.Session{}, err
+	}
+
+	session.CreatedAt = time.Unix(createdAt, 0)
+	session.LastSeenAt = time.Unix(lastSeenAt, 0)
+
+	return session, nil
+}
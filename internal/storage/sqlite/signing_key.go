@@ -0,0 +1,88 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sso/internal/domain/models"
+	"time"
+)
+
+// SigningKeys returns all signing keys, newest first.
+func (s *Storage) SigningKeys(ctx context.Context) ([]models.SigningKey, error) {
+	const op = "storage.sqlite.SigningKeys"
+
+	stmt, err := s.db.Prepare(`
+	SELECT kid, algorithm, private_key, public_key, created_at, retired_at
+	FROM signing_keys
+	ORDER BY created_at DESC
+`)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rows, err := stmt.QueryContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var keys []models.SigningKey
+	for rows.Next() {
+		var (
+			key       models.SigningKey
+			createdAt int64
+			retiredAt sql.NullInt64
+		)
+		err := rows.Scan(&key.ID, &key.Algorithm, &key.PrivateKey, &key.PublicKey, &createdAt, &retiredAt)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		key.CreatedAt = time.Unix(createdAt, 0)
+		if retiredAt.Valid {
+			key.RetiredAt = time.Unix(retiredAt.Int64, 0)
+		}
+
+		keys = append(keys, key)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return keys, nil
+}
+
+// RotateSigningKey retires currently active keys and saves the new one in a single transaction.
+func (s *Storage) RotateSigningKey(ctx context.Context, key models.SigningKey) error {
+	const op = "storage.sqlite.RotateSigningKey"
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	_, err = tx.ExecContext(ctx,
+		"UPDATE signing_keys SET retired_at = ? WHERE retired_at IS NULL",
+		key.CreatedAt.Unix(),
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+	INSERT INTO signing_keys(kid, algorithm, private_key, public_key, created_at)
+	VALUES(?, ?, ?, ?, ?)
+`, key.ID, key.Algorithm, key.PrivateKey, key.PublicKey, key.CreatedAt.Unix())
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
@@ -8,6 +8,8 @@ import (
 	"github.com/mattn/go-sqlite3"
 	"sso/internal/domain/models"
 	"sso/internal/storage"
+	"strings"
+	"time"
 )
 
 type Storage struct {
@@ -17,7 +19,8 @@ type Storage struct {
 func New(storagePath string) (*Storage, error) {
 	const op = "storage.sqlite.New"
 
-	db, err := sql.Open("sqlite3", storagePath)
+	// Foreign keys are off by default in SQLite, dependent rows rely on ON DELETE CASCADE.
+	db, err := sql.Open("sqlite3", storagePath+"?_foreign_keys=on")
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
@@ -32,12 +35,12 @@ func (s *Storage) Stop() error {
 func (s *Storage) SaveUser(ctx context.Context, email string, passHash []byte) (int64, error) {
 	const op = "storage.sqlite.SaveUser"
 
-	stmt, err := s.db.Prepare("INSERT INTO users(email, pass_hash, name, image) VALUES(?, ?, ?, ?)")
+	stmt, err := s.db.Prepare("INSERT INTO users(email, pass_hash, name, image, created_at) VALUES(?, ?, ?, ?, ?)")
 	if err != nil {
 		return 0, fmt.Errorf("%s: %w", op, err)
 	}
 
-	res, err := stmt.ExecContext(ctx, email, passHash, "Профиль", "")
+	res, err := stmt.ExecContext(ctx, email, passHash, "Профиль", "", time.Now().Unix())
 	if err != nil {
 		var sqliteErr sqlite3.Error
 		if errors.As(err, &sqliteErr) && errors.Is(sqliteErr.ExtendedCode, sqlite3.ErrConstraintUnique) {
@@ -54,116 +57,211 @@ func (s *Storage) SaveUser(ctx context.Context, email string, passHash []byte) (
 	return id, nil
 }
 
-func (s *Storage) Feeds(ctx context.Context) ([]models.Feed, error) {
-	query := fmt.Sprintf("SELECT * FROM feed")
-	rows, err := s.db.QueryContext(ctx, query)
+// User returns user by email.
+func (s *Storage) User(ctx context.Context, email string) (models.User, error) {
+	const op = "storage.sqlite.User"
+
+	stmt, err := s.db.Prepare(`
+	SELECT id, email, pass_hash, name, image, verified, tokens_revoked_at,
+	       EXISTS(SELECT 1 FROM user_totp WHERE user_id = users.id AND confirmed = 1)
+	FROM users
+	WHERE email = ?
+`)
 	if err != nil {
-		return nil, err
+		return models.User{}, fmt.Errorf("%s: %w", op, err)
 	}
-	defer rows.Close()
 
-	var feeds []models.Feed
-	for rows.Next() {
-		var feed models.Feed
-		if err := rows.Scan(&feed); err != nil {
-			return nil, err
+	row := stmt.QueryRowContext(ctx, email)
+
+	var (
+		user            models.User
+		tokensRevokedAt int64
+	)
+	err = row.Scan(
+		&user.ID, &user.Email, &user.PassHash, &user.Name, &user.Image,
+		&user.Verified, &tokensRevokedAt, &user.MFAEnabled,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.User{}, fmt.Errorf("%s: %w", op, storage.ErrUserNotFound)
 		}
-		feeds = append(feeds, feed)
-	}
-	if err := rows.Err(); err != nil {
-		return nil, err
+
+		return models.User{}, fmt.Errorf("%s: %w", op, err)
 	}
+	user.TokensRevokedAt = time.Unix(tokensRevokedAt, 0)
 
-	return feeds, nil
+	return user, nil
 }
 
-func (s *Storage) Articles(ctx context.Context) ([]models.Article, error) {
-	query := fmt.Sprintf("SELECT ID, Name, Description, Image FROM article")
-	rows, err := s.db.QueryContext(ctx, query)
+// UserByID returns user by id.
+func (s *Storage) UserByID(ctx context.Context, userID int64) (models.User, error) {
+	const op = "storage.sqlite.UserByID"
+
+	stmt, err := s.db.Prepare(`
+	SELECT id, email, pass_hash, name, image, verified, tokens_revoked_at,
+	       EXISTS(SELECT 1 FROM user_totp WHERE user_id = users.id AND confirmed = 1)
+	FROM users
+	WHERE id = ?
+`)
 	if err != nil {
-		return nil, err
+		return models.User{}, fmt.Errorf("%s: %w", op, err)
 	}
-	defer rows.Close()
 
-	var articles []models.Article
-	for rows.Next() {
-		var article models.Article
-		if err := rows.Scan(&article); err != nil {
-			return nil, err
+	row := stmt.QueryRowContext(ctx, userID)
+
+	var (
+		user            models.User
+		tokensRevokedAt int64
+	)
+	err = row.Scan(
+		&user.ID, &user.Email, &user.PassHash, &user.Name, &user.Image,
+		&user.Verified, &tokensRevokedAt, &user.MFAEnabled,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.User{}, fmt.Errorf("%s: %w", op, storage.ErrUserNotFound)
 		}
-		articles = append(articles, article)
+
+		return models.User{}, fmt.Errorf("%s: %w", op, err)
+	}
+	user.TokensRevokedAt = time.Unix(tokensRevokedAt, 0)
+
+	return user, nil
+}
+
+// VerifyUser marks user email as confirmed.
+func (s *Storage) VerifyUser(ctx context.Context, userID int64) error {
+	const op = "storage.sqlite.VerifyUser"
+
+	stmt, err := s.db.Prepare("UPDATE users SET verified = 1 WHERE id = ?")
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	res, err := stmt.ExecContext(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
 	}
-	if err := rows.Err(); err != nil {
-		return nil, err
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if affected == 0 {
+		return fmt.Errorf("%s: %w", op, storage.ErrUserNotFound)
 	}
 
-	return articles, nil
+	return nil
 }
 
-func (s *Storage) Courses(ctx context.Context) ([]models.Course, error) {
-	const op = "storage.sqlite.GetCourses"
+// UpdatePassword replaces user password hash.
+func (s *Storage) UpdatePassword(ctx context.Context, userID int64, passHash []byte) error {
+	const op = "storage.sqlite.UpdatePassword"
 
-	stmt, err := s.db.Prepare(`
-	SELECT id, name, image
-	FROM course
-`)
+	stmt, err := s.db.Prepare("UPDATE users SET pass_hash = ? WHERE id = ?")
 	if err != nil {
-		return nil, fmt.Errorf("%s: %w", op, err)
+		return fmt.Errorf("%s: %w", op, err)
 	}
 
-	rows, err := stmt.QueryContext(ctx)
+	res, err := stmt.ExecContext(ctx, passHash, userID)
 	if err != nil {
-		return nil, fmt.Errorf("%s: %w", op, err)
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if affected == 0 {
+		return fmt.Errorf("%s: %w", op, storage.ErrUserNotFound)
+	}
+
+	return nil
+}
+
+// UpdateEmail replaces user email, the new address counts as verified.
+// Returns storage.ErrUserExists if the address belongs to another user.
+func (s *Storage) UpdateEmail(ctx context.Context, userID int64, email string) error {
+	const op = "storage.sqlite.UpdateEmail"
+
+	stmt, err := s.db.Prepare("UPDATE users SET email = ?, verified = 1 WHERE id = ?")
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
 	}
-	defer rows.Close()
 
-	var courses []models.Course
-	for rows.Next() {
-		var course models.Course
-		err := rows.Scan(&course.ID, &course.Name, &course.Image)
-		if err != nil {
-			return nil, fmt.Errorf("%s: %w", op, err)
+	res, err := stmt.ExecContext(ctx, email, userID)
+	if err != nil {
+		var sqliteErr sqlite3.Error
+		if errors.As(err, &sqliteErr) && errors.Is(sqliteErr.ExtendedCode, sqlite3.ErrConstraintUnique) {
+			return fmt.Errorf("%s: %w", op, storage.ErrUserExists)
 		}
-		courses = append(courses, course)
+		return fmt.Errorf("%s: %w", op, err)
 	}
 
-	if err := rows.Err(); err != nil {
-		return courses, fmt.Errorf("%s: %w", op, err)
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
 	}
 
-	return courses, nil
+	if affected == 0 {
+		return fmt.Errorf("%s: %w", op, storage.ErrUserNotFound)
+	}
 
+	return nil
 }
 
-// User returns user by email.
-func (s *Storage) User(ctx context.Context, email string) (models.User, error) {
-	const op = "storage.sqlite.User"
+// ResetPassword replaces password hash of user, revokes all refresh tokens and sessions
+// and invalidates access tokens issued before revokedAt, all in a single transaction.
+func (s *Storage) ResetPassword(ctx context.Context, userID int64, passHash []byte, revokedAt time.Time) error {
+	const op = "storage.sqlite.ResetPassword"
 
-	stmt, err := s.db.Prepare("SELECT id, email, pass_hash, name, image FROM users WHERE email = ?")
+	tx, err := s.db.BeginTx(ctx, nil)
 	if err != nil {
-		return models.User{}, fmt.Errorf("%s: %w", op, err)
+		return fmt.Errorf("%s: %w", op, err)
 	}
+	defer func() { _ = tx.Rollback() }()
 
-	row := stmt.QueryRowContext(ctx, email)
+	res, err := tx.ExecContext(ctx,
+		"UPDATE users SET pass_hash = ?, tokens_revoked_at = ? WHERE id = ?",
+		passHash, revokedAt.Unix(), userID,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
 
-	var user models.User
-	err = row.Scan(&user.ID, &user.Email, &user.PassHash, &user.Name, &user.Image)
+	affected, err := res.RowsAffected()
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			return models.User{}, fmt.Errorf("%s: %w", op, storage.ErrUserNotFound)
-		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
 
-		return models.User{}, fmt.Errorf("%s: %w", op, err)
+	if affected == 0 {
+		return fmt.Errorf("%s: %w", op, storage.ErrUserNotFound)
 	}
 
-	return user, nil
+	_, err = tx.ExecContext(ctx, "UPDATE refresh_tokens SET revoked = 1 WHERE user_id = ?", userID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	_, err = tx.ExecContext(ctx, "UPDATE sessions SET revoked = 1 WHERE user_id = ?", userID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
 }
 
 // GetUser returns user by id.
 func (s *Storage) GetUser(ctx context.Context, userId int64) (models.UserData, error) {
 	const op = "storage.sqlite.GetUser"
 
-	stmt, err := s.db.Prepare("SELECT name, image FROM users WHERE id = ?")
+	stmt, err := s.db.Prepare("SELECT name, image, bio FROM users WHERE id = ?")
 	if err != nil {
 		return models.UserData{}, fmt.Errorf("%s: %w", op, err)
 	}
@@ -171,7 +269,7 @@ func (s *Storage) GetUser(ctx context.Context, userId int64) (models.UserData, e
 	row := stmt.QueryRowContext(ctx, userId)
 
 	var user models.UserData
-	err = row.Scan(&user.Name, &user.Image)
+	err = row.Scan(&user.Name, &user.Image, &user.Bio)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return models.UserData{}, fmt.Errorf("%s: %w", op, storage.ErrUserNotFound)
@@ -183,6 +281,102 @@ func (s *Storage) GetUser(ctx context.Context, userId int64) (models.UserData, e
 	return user, nil
 }
 
+// Profile returns profile of user, roles are not filled.
+func (s *Storage) Profile(ctx context.Context, userID int64) (models.Profile, error) {
+	const op = "storage.sqlite.Profile"
+
+	stmt, err := s.db.Prepare(`
+	SELECT id, email, name, image, bio, created_at, verified,
+	       EXISTS(SELECT 1 FROM user_totp WHERE user_id = users.id AND confirmed = 1)
+	FROM users
+	WHERE id = ?
+`)
+	if err != nil {
+		return models.Profile{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var (
+		profile   models.Profile
+		createdAt sql.NullInt64
+	)
+	err = stmt.QueryRowContext(ctx, userID).Scan(
+		&profile.ID, &profile.Email, &profile.Name, &profile.Image, &profile.Bio,
+		&createdAt, &profile.Verified, &profile.MFAEnabled,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.Profile{}, fmt.Errorf("%s: %w", op, storage.ErrUserNotFound)
+		}
+
+		return models.Profile{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if createdAt.Valid {
+		t := time.Unix(createdAt.Int64, 0)
+		profile.CreatedAt = &t
+	}
+
+	return profile, nil
+}
+
+// UpdateProfile replaces editable profile fields of user.
+func (s *Storage) UpdateProfile(ctx context.Context, userID int64, profile models.UserData) error {
+	const op = "storage.sqlite.UpdateProfile"
+
+	stmt, err := s.db.Prepare("UPDATE users SET name = ?, bio = ? WHERE id = ?")
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	res, err := stmt.ExecContext(ctx, profile.Name, profile.Bio, userID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if affected == 0 {
+		return fmt.Errorf("%s: %w", op, storage.ErrUserNotFound)
+	}
+
+	return nil
+}
+
+// UpdateAvatar sets image URL and blob key of user avatar and returns the previous key.
+func (s *Storage) UpdateAvatar(ctx context.Context, userID int64, image string, avatarKey string) (string, error) {
+	const op = "storage.sqlite.UpdateAvatar"
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var oldKey string
+	err = tx.QueryRowContext(ctx, "SELECT avatar_key FROM users WHERE id = ?", userID).Scan(&oldKey)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", fmt.Errorf("%s: %w", op, storage.ErrUserNotFound)
+		}
+
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	_, err = tx.ExecContext(ctx, "UPDATE users SET image = ?, avatar_key = ? WHERE id = ?", image, avatarKey, userID)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	return oldKey, nil
+}
+
 // DeleteUser deletes a user by their ID.
 func (s *Storage) DeleteUser(ctx context.Context, userID int64) error {
 	const op = "storage.sqlite.DeleteUser"
@@ -200,18 +394,22 @@ func (s *Storage) DeleteUser(ctx context.Context, userID int64) error {
 	return nil
 }
 
-func (s *Storage) App(ctx context.Context) (models.App, error) {
+// App returns app by id.
+func (s *Storage) App(ctx context.Context, appID int) (models.App, error) {
 	const op = "storage.sqlite.App"
 
-	stmt, err := s.db.Prepare("SELECT name, secret FROM apps")
+	stmt, err := s.db.Prepare("SELECT id, name, secret, redirect_uris FROM apps WHERE id = ?")
 	if err != nil {
 		return models.App{}, fmt.Errorf("%s: %w", op, err)
 	}
 
-	row := stmt.QueryRowContext(ctx)
+	row := stmt.QueryRowContext(ctx, appID)
 
-	var app models.App
-	err = row.Scan(&app.Name, &app.Secret)
+	var (
+		app          models.App
+		redirectURIs string
+	)
+	err = row.Scan(&app.ID, &app.Name, &app.Secret, &redirectURIs)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return models.App{}, fmt.Errorf("%s: %w", op, storage.ErrAppNotFound)
@@ -220,5 +418,8 @@ func (s *Storage) App(ctx context.Context) (models.App, error) {
 		return models.App{}, fmt.Errorf("%s: %w", op, err)
 	}
 
+	// Redirect URIs are stored space separated, like in OAuth scope strings.
+	app.RedirectURIs = strings.Fields(redirectURIs)
+
 	return app, nil
 }
@@ -0,0 +1,73 @@
+package sqlite
+
+import (
+	"context"
+	"sso/internal/domain/models"
+	"sso/internal/storage"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApp_PublicClients(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	// Public clients have no secret, any number of them can be registered.
+	for _, q := range []string{
+		`INSERT INTO apps (id, name, secret, redirect_uris) VALUES (10, 'spa', '', 'http://localhost:3000/callback')`,
+		`INSERT INTO apps (id, name, secret, redirect_uris) VALUES (11, 'mobile', '', 'app://callback')`,
+	} {
+		_, err := s.db.ExecContext(ctx, q)
+		require.NoError(t, err, q)
+	}
+
+	app, err := s.App(ctx, 11)
+	require.NoError(t, err)
+	assert.Equal(t, "mobile", app.Name)
+	assert.Empty(t, app.Secret)
+	assert.Equal(t, []string{"app://callback"}, app.RedirectURIs)
+
+	_, err = s.db.ExecContext(ctx, `INSERT INTO apps (id, name, secret) VALUES (12, 'spa', '')`)
+	assert.Error(t, err, "names are still unique")
+}
+
+func TestResetPassword(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	_, err := s.db.ExecContext(ctx, `INSERT INTO apps (id, name, secret) VALUES (10, 'reset', 'secret')`)
+	require.NoError(t, err)
+
+	uid, err := s.SaveUser(ctx, "reader@example.com", []byte("old"))
+	require.NoError(t, err)
+
+	now := time.Now()
+	require.NoError(t, s.SaveRefreshToken(ctx, models.RefreshToken{
+		UserID: uid, AppID: 10, FamilyID: "family", TokenHash: "hash", ExpiresAt: now.Add(time.Hour),
+	}))
+	_, err = s.SaveSession(ctx, models.Session{
+		UserID: uid, AppID: 10, FamilyID: "family", CreatedAt: now, LastSeenAt: now,
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, s.ResetPassword(ctx, uid, []byte("new"), now))
+
+	user, err := s.UserByID(ctx, uid)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("new"), user.PassHash)
+	assert.Equal(t, now.Unix(), user.TokensRevokedAt.Unix())
+
+	token, err := s.RefreshToken(ctx, "hash")
+	require.NoError(t, err)
+	assert.True(t, token.Revoked)
+
+	sessions, err := s.Sessions(ctx, uid, time.Time{})
+	require.NoError(t, err)
+	assert.Empty(t, sessions)
+
+	err = s.ResetPassword(ctx, uid+1, []byte("new"), now)
+	assert.ErrorIs(t, err, storage.ErrUserNotFound)
+}
@@ -3,7 +3,27 @@ package storage
 import "errors"
 
 var (
-	ErrUserExists   = errors.New("user already exists")
-	ErrUserNotFound = errors.New("user not found")
-	ErrAppNotFound  = errors.New("app not found")
+	ErrUserExists            = errors.New("user already exists")
+	ErrUserNotFound          = errors.New("user not found")
+	ErrAppNotFound           = errors.New("app not found")
+	ErrRefreshTokenNotFound  = errors.New("refresh token not found")
+	ErrRefreshTokenUsed      = errors.New("refresh token already used")
+	ErrAuthCodeNotFound      = errors.New("authorization code not found")
+	ErrEmailTokenNotFound    = errors.New("email token not found")
+	ErrTOTPNotFound          = errors.New("totp not found")
+	ErrTOTPStepUsed          = errors.New("totp code already used")
+	ErrRecoveryCodeNotFound  = errors.New("recovery code not found")
+	ErrLoginFailuresNotFound = errors.New("login failures not found")
+	ErrRoleNotFound          = errors.New("role not found")
+	ErrSessionNotFound       = errors.New("session not found")
+	ErrInvalidCursor         = errors.New("invalid cursor")
+	ErrArticleNotFound       = errors.New("article not found")
+	ErrCourseNotFound        = errors.New("course not found")
+	ErrModuleNotFound        = errors.New("module not found")
+	ErrLessonNotFound        = errors.New("lesson not found")
+	ErrNotEnrolled           = errors.New("user is not enrolled in course")
+	ErrFeedNotFound          = errors.New("feed item not found")
+	ErrSlugTaken             = errors.New("slug already taken")
+	ErrVersionConflict       = errors.New("content version conflict")
+	ErrRevisionNotFound      = errors.New("revision not found")
 )
@@ -0,0 +1,2 @@
+-- Access tokens issued before this moment are rejected, unix seconds.
+ALTER TABLE users ADD COLUMN tokens_revoked_at INTEGER NOT NULL DEFAULT 0;
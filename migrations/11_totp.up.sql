@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS user_totp
+(
+    user_id   INTEGER PRIMARY KEY REFERENCES users (id) ON DELETE CASCADE,
+    secret    TEXT    NOT NULL,
+    confirmed INTEGER NOT NULL DEFAULT 0,
+    -- Last accepted time step, codes are not accepted twice.
+    last_step INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE TABLE IF NOT EXISTS recovery_codes
+(
+    id        INTEGER PRIMARY KEY,
+    user_id   INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    code_hash TEXT    NOT NULL,
+    used      INTEGER NOT NULL DEFAULT 0
+);
+CREATE INDEX IF NOT EXISTS idx_recovery_codes_user ON recovery_codes (user_id);
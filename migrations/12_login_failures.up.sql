@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS login_failures
+(
+    key             TEXT PRIMARY KEY,
+    count           INTEGER NOT NULL,
+    last_failure_at INTEGER NOT NULL,
+    blocked_until   INTEGER NOT NULL,
+    expires_at      INTEGER NOT NULL
+);
@@ -0,0 +1,21 @@
+CREATE TABLE IF NOT EXISTS sessions
+(
+    id           INTEGER PRIMARY KEY,
+    user_id      INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    app_id       INTEGER NOT NULL,
+    family_id    TEXT    NOT NULL UNIQUE,
+    device_name  TEXT    NOT NULL DEFAULT '',
+    user_agent   TEXT    NOT NULL DEFAULT '',
+    ip           TEXT    NOT NULL DEFAULT '',
+    created_at   INTEGER NOT NULL,
+    last_seen_at INTEGER NOT NULL,
+    revoked      INTEGER NOT NULL DEFAULT 0
+);
+CREATE INDEX IF NOT EXISTS idx_sessions_user ON sessions (user_id);
+
+-- Logins made before sessions were recorded keep working, device details are unknown for them.
+INSERT INTO sessions (user_id, app_id, family_id, created_at, last_seen_at)
+SELECT user_id, MIN(app_id), family_id, strftime('%s', 'now'), strftime('%s', 'now')
+FROM refresh_tokens
+WHERE revoked = 0
+GROUP BY family_id, user_id;
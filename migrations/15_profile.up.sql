@@ -0,0 +1,3 @@
+ALTER TABLE users ADD COLUMN bio TEXT NOT NULL DEFAULT '';
+-- avatar_key is the blob key prefix of uploaded avatar thumbnails, empty if avatar was never uploaded.
+ALTER TABLE users ADD COLUMN avatar_key TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,2 @@
+-- Registration time of users created before this migration is unknown and stays NULL.
+ALTER TABLE users ADD COLUMN created_at INTEGER;
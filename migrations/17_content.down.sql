@@ -0,0 +1,11 @@
+DROP INDEX IF EXISTS idx_feed_author;
+DROP INDEX IF EXISTS idx_feed_status_published;
+DROP TABLE IF EXISTS feed;
+
+DROP INDEX IF EXISTS idx_course_author;
+DROP INDEX IF EXISTS idx_course_status_published;
+DROP TABLE IF EXISTS course;
+
+DROP INDEX IF EXISTS idx_article_author;
+DROP INDEX IF EXISTS idx_article_status_published;
+DROP TABLE IF EXISTS article;
@@ -0,0 +1,53 @@
+-- Content tables keep the singular names storage has always queried.
+-- status is one of draft, published, archived; only published content is shown to readers.
+
+CREATE TABLE IF NOT EXISTS article
+(
+    id           INTEGER PRIMARY KEY,
+    slug         TEXT    NOT NULL UNIQUE,
+    name         TEXT    NOT NULL,
+    description  TEXT    NOT NULL DEFAULT '',
+    body         TEXT    NOT NULL DEFAULT '',
+    image        TEXT    NOT NULL DEFAULT '',
+    author_id    INTEGER REFERENCES users (id) ON DELETE SET NULL,
+    status       TEXT    NOT NULL DEFAULT 'draft' CHECK (status IN ('draft', 'published', 'archived')),
+    published_at INTEGER,
+    created_at   INTEGER NOT NULL,
+    updated_at   INTEGER NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_article_status_published ON article (status, published_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_article_author ON article (author_id);
+
+CREATE TABLE IF NOT EXISTS course
+(
+    id           INTEGER PRIMARY KEY,
+    slug         TEXT    NOT NULL UNIQUE,
+    name         TEXT    NOT NULL,
+    description  TEXT    NOT NULL DEFAULT '',
+    image        TEXT    NOT NULL DEFAULT '',
+    author_id    INTEGER REFERENCES users (id) ON DELETE SET NULL,
+    status       TEXT    NOT NULL DEFAULT 'draft' CHECK (status IN ('draft', 'published', 'archived')),
+    published_at INTEGER,
+    created_at   INTEGER NOT NULL,
+    updated_at   INTEGER NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_course_status_published ON course (status, published_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_course_author ON course (author_id);
+
+CREATE TABLE IF NOT EXISTS feed
+(
+    id           INTEGER PRIMARY KEY,
+    slug         TEXT    NOT NULL UNIQUE,
+    name         TEXT    NOT NULL,
+    description  TEXT    NOT NULL DEFAULT '',
+    image        TEXT    NOT NULL DEFAULT '',
+    -- link points to the article, course or external page the feed item announces.
+    link         TEXT    NOT NULL DEFAULT '',
+    author_id    INTEGER REFERENCES users (id) ON DELETE SET NULL,
+    status       TEXT    NOT NULL DEFAULT 'draft' CHECK (status IN ('draft', 'published', 'archived')),
+    published_at INTEGER,
+    created_at   INTEGER NOT NULL,
+    updated_at   INTEGER NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_feed_status_published ON feed (status, published_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_feed_author ON feed (author_id);
@@ -0,0 +1,32 @@
+DROP INDEX IF EXISTS idx_feed_tags_tag;
+DROP TABLE IF EXISTS feed_tags;
+
+DROP INDEX IF EXISTS idx_feed_difficulty;
+DROP INDEX IF EXISTS idx_feed_status_views;
+DROP INDEX IF EXISTS idx_feed_status_published;
+CREATE INDEX IF NOT EXISTS idx_feed_status_published ON feed (status, published_at DESC, id DESC);
+
+ALTER TABLE feed DROP COLUMN views;
+ALTER TABLE feed DROP COLUMN difficulty;
+
+DROP INDEX IF EXISTS idx_course_tags_tag;
+DROP TABLE IF EXISTS course_tags;
+
+DROP INDEX IF EXISTS idx_course_difficulty;
+DROP INDEX IF EXISTS idx_course_status_views;
+DROP INDEX IF EXISTS idx_course_status_published;
+CREATE INDEX IF NOT EXISTS idx_course_status_published ON course (status, published_at DESC, id DESC);
+
+ALTER TABLE course DROP COLUMN views;
+ALTER TABLE course DROP COLUMN difficulty;
+
+DROP INDEX IF EXISTS idx_article_tags_tag;
+DROP TABLE IF EXISTS article_tags;
+
+DROP INDEX IF EXISTS idx_article_difficulty;
+DROP INDEX IF EXISTS idx_article_status_views;
+DROP INDEX IF EXISTS idx_article_status_published;
+CREATE INDEX IF NOT EXISTS idx_article_status_published ON article (status, published_at DESC, id DESC);
+
+ALTER TABLE article DROP COLUMN views;
+ALTER TABLE article DROP COLUMN difficulty;
@@ -0,0 +1,51 @@
+-- Difficulty, popularity and tags for filtering and sorting content lists.
+-- views counts detail page views and is what lists are sorted by for "popularity".
+-- Lists are paged by (sort key, id), the published_at key is NULL-safe so rows without it still get a stable position.
+
+ALTER TABLE article ADD COLUMN difficulty TEXT CHECK (difficulty IN ('beginner', 'intermediate', 'advanced'));
+ALTER TABLE article ADD COLUMN views INTEGER NOT NULL DEFAULT 0;
+
+DROP INDEX IF EXISTS idx_article_status_published;
+CREATE INDEX IF NOT EXISTS idx_article_status_published ON article (status, COALESCE(published_at, 0) DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_article_status_views ON article (status, views DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_article_difficulty ON article (difficulty);
+
+CREATE TABLE IF NOT EXISTS article_tags
+(
+    article_id INTEGER NOT NULL REFERENCES article (id) ON DELETE CASCADE,
+    tag        TEXT    NOT NULL,
+    PRIMARY KEY (article_id, tag)
+);
+CREATE INDEX IF NOT EXISTS idx_article_tags_tag ON article_tags (tag, article_id);
+
+ALTER TABLE course ADD COLUMN difficulty TEXT CHECK (difficulty IN ('beginner', 'intermediate', 'advanced'));
+ALTER TABLE course ADD COLUMN views INTEGER NOT NULL DEFAULT 0;
+
+DROP INDEX IF EXISTS idx_course_status_published;
+CREATE INDEX IF NOT EXISTS idx_course_status_published ON course (status, COALESCE(published_at, 0) DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_course_status_views ON course (status, views DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_course_difficulty ON course (difficulty);
+
+CREATE TABLE IF NOT EXISTS course_tags
+(
+    course_id INTEGER NOT NULL REFERENCES course (id) ON DELETE CASCADE,
+    tag       TEXT    NOT NULL,
+    PRIMARY KEY (course_id, tag)
+);
+CREATE INDEX IF NOT EXISTS idx_course_tags_tag ON course_tags (tag, course_id);
+
+ALTER TABLE feed ADD COLUMN difficulty TEXT CHECK (difficulty IN ('beginner', 'intermediate', 'advanced'));
+ALTER TABLE feed ADD COLUMN views INTEGER NOT NULL DEFAULT 0;
+
+DROP INDEX IF EXISTS idx_feed_status_published;
+CREATE INDEX IF NOT EXISTS idx_feed_status_published ON feed (status, COALESCE(published_at, 0) DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_feed_status_views ON feed (status, views DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_feed_difficulty ON feed (difficulty);
+
+CREATE TABLE IF NOT EXISTS feed_tags
+(
+    feed_id INTEGER NOT NULL REFERENCES feed (id) ON DELETE CASCADE,
+    tag     TEXT    NOT NULL,
+    PRIMARY KEY (feed_id, tag)
+);
+CREATE INDEX IF NOT EXISTS idx_feed_tags_tag ON feed_tags (tag, feed_id);
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS lesson_quiz_options;
+DROP TABLE IF EXISTS lesson_quiz_questions;
+DROP TABLE IF EXISTS course_lessons;
+DROP TABLE IF EXISTS course_modules;
@@ -0,0 +1,50 @@
+-- Courses consist of ordered modules, modules of ordered lessons.
+-- Lesson type is one of text, video, quiz, external:
+-- body is Markdown text of text lessons and a description for the others,
+-- url is the video or external resource link.
+
+CREATE TABLE IF NOT EXISTS course_modules
+(
+    id          INTEGER PRIMARY KEY,
+    course_id   INTEGER NOT NULL REFERENCES course (id) ON DELETE CASCADE,
+    position    INTEGER NOT NULL,
+    title       TEXT    NOT NULL,
+    description TEXT    NOT NULL DEFAULT '',
+    created_at  INTEGER NOT NULL,
+    updated_at  INTEGER NOT NULL,
+    UNIQUE (course_id, position)
+);
+
+CREATE TABLE IF NOT EXISTS course_lessons
+(
+    id               INTEGER PRIMARY KEY,
+    module_id        INTEGER NOT NULL REFERENCES course_modules (id) ON DELETE CASCADE,
+    position         INTEGER NOT NULL,
+    title            TEXT    NOT NULL,
+    type             TEXT    NOT NULL CHECK (type IN ('text', 'video', 'quiz', 'external')),
+    body             TEXT    NOT NULL DEFAULT '',
+    url              TEXT    NOT NULL DEFAULT '',
+    duration_minutes INTEGER,
+    created_at       INTEGER NOT NULL,
+    updated_at       INTEGER NOT NULL,
+    UNIQUE (module_id, position)
+);
+
+CREATE TABLE IF NOT EXISTS lesson_quiz_questions
+(
+    id        INTEGER PRIMARY KEY,
+    lesson_id INTEGER NOT NULL REFERENCES course_lessons (id) ON DELETE CASCADE,
+    position  INTEGER NOT NULL,
+    text      TEXT    NOT NULL,
+    UNIQUE (lesson_id, position)
+);
+
+CREATE TABLE IF NOT EXISTS lesson_quiz_options
+(
+    id          INTEGER PRIMARY KEY,
+    question_id INTEGER NOT NULL REFERENCES lesson_quiz_questions (id) ON DELETE CASCADE,
+    position    INTEGER NOT NULL,
+    text        TEXT    NOT NULL,
+    correct     INTEGER NOT NULL DEFAULT 0,
+    UNIQUE (question_id, position)
+);
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_lesson_progress_lesson;
+DROP TABLE IF EXISTS lesson_progress;
+
+DROP INDEX IF EXISTS idx_enrollments_course;
+DROP TABLE IF EXISTS enrollments;
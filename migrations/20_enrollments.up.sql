@@ -0,0 +1,22 @@
+-- Course enrollments and completed lessons of users, both are deleted together with the user.
+
+CREATE TABLE IF NOT EXISTS enrollments
+(
+    user_id        INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    course_id      INTEGER NOT NULL REFERENCES course (id) ON DELETE CASCADE,
+    enrolled_at    INTEGER NOT NULL,
+    -- Lesson of the course the user opened last, NULL until one is opened.
+    last_lesson_id INTEGER REFERENCES course_lessons (id) ON DELETE SET NULL,
+    last_opened_at INTEGER,
+    PRIMARY KEY (user_id, course_id)
+);
+CREATE INDEX IF NOT EXISTS idx_enrollments_course ON enrollments (course_id);
+
+CREATE TABLE IF NOT EXISTS lesson_progress
+(
+    user_id      INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    lesson_id    INTEGER NOT NULL REFERENCES course_lessons (id) ON DELETE CASCADE,
+    completed_at INTEGER NOT NULL,
+    PRIMARY KEY (user_id, lesson_id)
+);
+CREATE INDEX IF NOT EXISTS idx_lesson_progress_lesson ON lesson_progress (lesson_id);
@@ -0,0 +1,14 @@
+DROP TRIGGER IF EXISTS feed_search_update;
+DROP TRIGGER IF EXISTS feed_search_delete;
+DROP TRIGGER IF EXISTS feed_search_insert;
+DROP TABLE IF EXISTS feed_search;
+
+DROP TRIGGER IF EXISTS course_search_update;
+DROP TRIGGER IF EXISTS course_search_delete;
+DROP TRIGGER IF EXISTS course_search_insert;
+DROP TABLE IF EXISTS course_search;
+
+DROP TRIGGER IF EXISTS article_search_update;
+DROP TRIGGER IF EXISTS article_search_delete;
+DROP TRIGGER IF EXISTS article_search_insert;
+DROP TABLE IF EXISTS article_search;
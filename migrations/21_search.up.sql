@@ -0,0 +1,100 @@
+-- Full-text indexes of content. They are external content tables reading text from the content tables,
+-- triggers keep them in sync. unicode61 folds case of Cyrillic letters as well as Latin ones,
+-- so Russian text is matched regardless of case.
+
+CREATE VIRTUAL TABLE IF NOT EXISTS article_search USING fts5
+(
+    name, description, body,
+    content = 'article',
+    content_rowid = 'id',
+    tokenize = 'unicode61 remove_diacritics 2'
+);
+
+CREATE TRIGGER IF NOT EXISTS article_search_insert
+    AFTER INSERT
+    ON article
+BEGIN
+    INSERT INTO article_search(rowid, name, description, body) VALUES (new.id, new.name, new.description, new.body);
+END;
+
+CREATE TRIGGER IF NOT EXISTS article_search_delete
+    AFTER DELETE
+    ON article
+BEGIN
+    INSERT INTO article_search(article_search, rowid, name, description, body) VALUES ('delete', old.id, old.name, old.description, old.body);
+END;
+
+-- Only changes of indexed columns reindex the row, so counting views doesn't touch the index.
+CREATE TRIGGER IF NOT EXISTS article_search_update
+    AFTER UPDATE OF name, description, body
+    ON article
+BEGIN
+    INSERT INTO article_search(article_search, rowid, name, description, body) VALUES ('delete', old.id, old.name, old.description, old.body);
+    INSERT INTO article_search(rowid, name, description, body) VALUES (new.id, new.name, new.description, new.body);
+END;
+
+INSERT INTO article_search(article_search) VALUES ('rebuild');
+
+CREATE VIRTUAL TABLE IF NOT EXISTS course_search USING fts5
+(
+    name, description,
+    content = 'course',
+    content_rowid = 'id',
+    tokenize = 'unicode61 remove_diacritics 2'
+);
+
+CREATE TRIGGER IF NOT EXISTS course_search_insert
+    AFTER INSERT
+    ON course
+BEGIN
+    INSERT INTO course_search(rowid, name, description) VALUES (new.id, new.name, new.description);
+END;
+
+CREATE TRIGGER IF NOT EXISTS course_search_delete
+    AFTER DELETE
+    ON course
+BEGIN
+    INSERT INTO course_search(course_search, rowid, name, description) VALUES ('delete', old.id, old.name, old.description);
+END;
+
+CREATE TRIGGER IF NOT EXISTS course_search_update
+    AFTER UPDATE OF name, description
+    ON course
+BEGIN
+    INSERT INTO course_search(course_search, rowid, name, description) VALUES ('delete', old.id, old.name, old.description);
+    INSERT INTO course_search(rowid, name, description) VALUES (new.id, new.name, new.description);
+END;
+
+INSERT INTO course_search(course_search) VALUES ('rebuild');
+
+CREATE VIRTUAL TABLE IF NOT EXISTS feed_search USING fts5
+(
+    name, description,
+    content = 'feed',
+    content_rowid = 'id',
+    tokenize = 'unicode61 remove_diacritics 2'
+);
+
+CREATE TRIGGER IF NOT EXISTS feed_search_insert
+    AFTER INSERT
+    ON feed
+BEGIN
+    INSERT INTO feed_search(rowid, name, description) VALUES (new.id, new.name, new.description);
+END;
+
+CREATE TRIGGER IF NOT EXISTS feed_search_delete
+    AFTER DELETE
+    ON feed
+BEGIN
+    INSERT INTO feed_search(feed_search, rowid, name, description) VALUES ('delete', old.id, old.name, old.description);
+END;
+
+CREATE TRIGGER IF NOT EXISTS feed_search_update
+    AFTER UPDATE OF name, description
+    ON feed
+BEGIN
+    INSERT INTO feed_search(feed_search, rowid, name, description) VALUES ('delete', old.id, old.name, old.description);
+    INSERT INTO feed_search(rowid, name, description) VALUES (new.id, new.name, new.description);
+END;
+
+INSERT INTO feed_search(feed_search) VALUES ('rebuild');
@@ -0,0 +1,8 @@
+ALTER TABLE feed DROP COLUMN deleted_at;
+ALTER TABLE feed DROP COLUMN version;
+
+ALTER TABLE course DROP COLUMN deleted_at;
+ALTER TABLE course DROP COLUMN version;
+
+ALTER TABLE article DROP COLUMN deleted_at;
+ALTER TABLE article DROP COLUMN version;
@@ -0,0 +1,12 @@
+-- Editing content through the API. version is increased by every change and is what ETag of content is made of,
+-- so concurrent edits don't overwrite each other. Deleted content keeps its row with deleted_at set,
+-- it is hidden from readers and editors but can be restored by hand.
+
+ALTER TABLE article ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE article ADD COLUMN deleted_at INTEGER;
+
+ALTER TABLE course ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE course ADD COLUMN deleted_at INTEGER;
+
+ALTER TABLE feed ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE feed ADD COLUMN deleted_at INTEGER;
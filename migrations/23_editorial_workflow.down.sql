@@ -0,0 +1,157 @@
+DELETE FROM role_permissions WHERE permission_id IN (SELECT id FROM permissions WHERE name = 'content:publish');
+DELETE FROM permissions WHERE name = 'content:publish';
+
+-- Content in review goes back to drafts, schedules are lost.
+
+CREATE TABLE IF NOT EXISTS feed_new
+(
+    id           INTEGER PRIMARY KEY,
+    slug         TEXT    NOT NULL UNIQUE,
+    name         TEXT    NOT NULL,
+    description  TEXT    NOT NULL DEFAULT '',
+    image        TEXT    NOT NULL DEFAULT '',
+    -- link points to the article, course or external page the feed item announces.
+    link         TEXT    NOT NULL DEFAULT '',
+    author_id    INTEGER REFERENCES users (id) ON DELETE SET NULL,
+    status       TEXT    NOT NULL DEFAULT 'draft' CHECK (status IN ('draft', 'published', 'archived')),
+    published_at INTEGER,
+    created_at   INTEGER NOT NULL,
+    updated_at   INTEGER NOT NULL,
+    difficulty   TEXT CHECK (difficulty IN ('beginner', 'intermediate', 'advanced')),
+    views        INTEGER NOT NULL DEFAULT 0,
+    version      INTEGER NOT NULL DEFAULT 1,
+    deleted_at   INTEGER
+);
+INSERT INTO feed_new (id, slug, name, description, image, link, author_id, status, published_at, created_at, updated_at, difficulty, views, version, deleted_at)
+SELECT id, slug, name, description, image, link, author_id, CASE status WHEN 'in_review' THEN 'draft' ELSE status END, published_at, created_at, updated_at, difficulty, views, version, deleted_at
+FROM feed;
+DROP TABLE feed;
+ALTER TABLE feed_new RENAME TO feed;
+
+CREATE INDEX IF NOT EXISTS idx_feed_author ON feed (author_id);
+CREATE INDEX IF NOT EXISTS idx_feed_status_published ON feed (status, COALESCE(published_at, 0) DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_feed_status_views ON feed (status, views DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_feed_difficulty ON feed (difficulty);
+
+CREATE TRIGGER IF NOT EXISTS feed_search_insert
+    AFTER INSERT
+    ON feed
+BEGIN
+    INSERT INTO feed_search(rowid, name, description) VALUES (new.id, new.name, new.description);
+END;
+
+CREATE TRIGGER IF NOT EXISTS feed_search_delete
+    AFTER DELETE
+    ON feed
+BEGIN
+    INSERT INTO feed_search(feed_search, rowid, name, description) VALUES ('delete', old.id, old.name, old.description);
+END;
+
+CREATE TRIGGER IF NOT EXISTS feed_search_update
+    AFTER UPDATE OF name, description
+    ON feed
+BEGIN
+    INSERT INTO feed_search(feed_search, rowid, name, description) VALUES ('delete', old.id, old.name, old.description);
+    INSERT INTO feed_search(rowid, name, description) VALUES (new.id, new.name, new.description);
+END;
+
+CREATE TABLE IF NOT EXISTS course_new
+(
+    id           INTEGER PRIMARY KEY,
+    slug         TEXT    NOT NULL UNIQUE,
+    name         TEXT    NOT NULL,
+    description  TEXT    NOT NULL DEFAULT '',
+    image        TEXT    NOT NULL DEFAULT '',
+    author_id    INTEGER REFERENCES users (id) ON DELETE SET NULL,
+    status       TEXT    NOT NULL DEFAULT 'draft' CHECK (status IN ('draft', 'published', 'archived')),
+    published_at INTEGER,
+    created_at   INTEGER NOT NULL,
+    updated_at   INTEGER NOT NULL,
+    difficulty   TEXT CHECK (difficulty IN ('beginner', 'intermediate', 'advanced')),
+    views        INTEGER NOT NULL DEFAULT 0,
+    version      INTEGER NOT NULL DEFAULT 1,
+    deleted_at   INTEGER
+);
+INSERT INTO course_new (id, slug, name, description, image, author_id, status, published_at, created_at, updated_at, difficulty, views, version, deleted_at)
+SELECT id, slug, name, description, image, author_id, CASE status WHEN 'in_review' THEN 'draft' ELSE status END, published_at, created_at, updated_at, difficulty, views, version, deleted_at
+FROM course;
+DROP TABLE course;
+ALTER TABLE course_new RENAME TO course;
+
+CREATE INDEX IF NOT EXISTS idx_course_author ON course (author_id);
+CREATE INDEX IF NOT EXISTS idx_course_status_published ON course (status, COALESCE(published_at, 0) DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_course_status_views ON course (status, views DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_course_difficulty ON course (difficulty);
+
+CREATE TRIGGER IF NOT EXISTS course_search_insert
+    AFTER INSERT
+    ON course
+BEGIN
+    INSERT INTO course_search(rowid, name, description) VALUES (new.id, new.name, new.description);
+END;
+
+CREATE TRIGGER IF NOT EXISTS course_search_delete
+    AFTER DELETE
+    ON course
+BEGIN
+    INSERT INTO course_search(course_search, rowid, name, description) VALUES ('delete', old.id, old.name, old.description);
+END;
+
+CREATE TRIGGER IF NOT EXISTS course_search_update
+    AFTER UPDATE OF name, description
+    ON course
+BEGIN
+    INSERT INTO course_search(course_search, rowid, name, description) VALUES ('delete', old.id, old.name, old.description);
+    INSERT INTO course_search(rowid, name, description) VALUES (new.id, new.name, new.description);
+END;
+
+CREATE TABLE IF NOT EXISTS article_new
+(
+    id           INTEGER PRIMARY KEY,
+    slug         TEXT    NOT NULL UNIQUE,
+    name         TEXT    NOT NULL,
+    description  TEXT    NOT NULL DEFAULT '',
+    body         TEXT    NOT NULL DEFAULT '',
+    image        TEXT    NOT NULL DEFAULT '',
+    author_id    INTEGER REFERENCES users (id) ON DELETE SET NULL,
+    status       TEXT    NOT NULL DEFAULT 'draft' CHECK (status IN ('draft', 'published', 'archived')),
+    published_at INTEGER,
+    created_at   INTEGER NOT NULL,
+    updated_at   INTEGER NOT NULL,
+    difficulty   TEXT CHECK (difficulty IN ('beginner', 'intermediate', 'advanced')),
+    views        INTEGER NOT NULL DEFAULT 0,
+    version      INTEGER NOT NULL DEFAULT 1,
+    deleted_at   INTEGER
+);
+INSERT INTO article_new (id, slug, name, description, body, image, author_id, status, published_at, created_at, updated_at, difficulty, views, version, deleted_at)
+SELECT id, slug, name, description, body, image, author_id, CASE status WHEN 'in_review' THEN 'draft' ELSE status END, published_at, created_at, updated_at, difficulty, views, version, deleted_at
+FROM article;
+DROP TABLE article;
+ALTER TABLE article_new RENAME TO article;
+
+CREATE INDEX IF NOT EXISTS idx_article_author ON article (author_id);
+CREATE INDEX IF NOT EXISTS idx_article_status_published ON article (status, COALESCE(published_at, 0) DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_article_status_views ON article (status, views DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_article_difficulty ON article (difficulty);
+
+CREATE TRIGGER IF NOT EXISTS article_search_insert
+    AFTER INSERT
+    ON article
+BEGIN
+    INSERT INTO article_search(rowid, name, description, body) VALUES (new.id, new.name, new.description, new.body);
+END;
+
+CREATE TRIGGER IF NOT EXISTS article_search_delete
+    AFTER DELETE
+    ON article
+BEGIN
+    INSERT INTO article_search(article_search, rowid, name, description, body) VALUES ('delete', old.id, old.name, old.description, old.body);
+END;
+
+CREATE TRIGGER IF NOT EXISTS article_search_update
+    AFTER UPDATE OF name, description, body
+    ON article
+BEGIN
+    INSERT INTO article_search(article_search, rowid, name, description, body) VALUES ('delete', old.id, old.name, old.description, old.body);
+    INSERT INTO article_search(rowid, name, description, body) VALUES (new.id, new.name, new.description, new.body);
+END;
@@ -0,0 +1,10 @@
+DROP TRIGGER IF EXISTS lesson_revisions_update;
+DROP TRIGGER IF EXISTS lesson_revisions_insert;
+DROP TABLE IF EXISTS lesson_revisions;
+
+DROP TRIGGER IF EXISTS article_revisions_update;
+DROP TRIGGER IF EXISTS article_revisions_insert;
+DROP TABLE IF EXISTS article_revisions;
+
+ALTER TABLE course_lessons DROP COLUMN updated_by;
+ALTER TABLE article DROP COLUMN updated_by;
@@ -0,0 +1,78 @@
+-- Revisions of article and lesson text. Triggers save a revision whenever the text changes,
+-- whatever writes it. updated_by is the user who made the last change, it becomes the author of the revision.
+-- Revisions are numbered one by one for every article and lesson.
+
+ALTER TABLE article ADD COLUMN updated_by INTEGER REFERENCES users (id) ON DELETE SET NULL;
+ALTER TABLE course_lessons ADD COLUMN updated_by INTEGER REFERENCES users (id) ON DELETE SET NULL;
+
+CREATE TABLE IF NOT EXISTS article_revisions
+(
+    id          INTEGER PRIMARY KEY,
+    article_id  INTEGER NOT NULL REFERENCES article (id) ON DELETE CASCADE,
+    revision    INTEGER NOT NULL,
+    name        TEXT    NOT NULL,
+    description TEXT    NOT NULL,
+    body        TEXT    NOT NULL,
+    author_id   INTEGER REFERENCES users (id) ON DELETE SET NULL,
+    created_at  INTEGER NOT NULL,
+    UNIQUE (article_id, revision)
+);
+
+CREATE TRIGGER IF NOT EXISTS article_revisions_insert
+    AFTER INSERT
+    ON article
+BEGIN
+    INSERT INTO article_revisions (article_id, revision, name, description, body, author_id, created_at)
+    VALUES (new.id, 1, new.name, new.description, new.body, COALESCE(new.updated_by, new.author_id), new.updated_at);
+END;
+
+CREATE TRIGGER IF NOT EXISTS article_revisions_update
+    AFTER UPDATE OF name, description, body
+    ON article
+    WHEN old.name IS NOT new.name OR old.description IS NOT new.description OR old.body IS NOT new.body
+BEGIN
+    INSERT INTO article_revisions (article_id, revision, name, description, body, author_id, created_at)
+    VALUES (new.id,
+            (SELECT COALESCE(MAX(revision), 0) + 1 FROM article_revisions WHERE article_id = new.id),
+            new.name, new.description, new.body, new.updated_by, new.updated_at);
+END;
+
+INSERT INTO article_revisions (article_id, revision, name, description, body, author_id, created_at)
+SELECT id, 1, name, description, body, author_id, updated_at
+FROM article;
+
+CREATE TABLE IF NOT EXISTS lesson_revisions
+(
+    id         INTEGER PRIMARY KEY,
+    lesson_id  INTEGER NOT NULL REFERENCES course_lessons (id) ON DELETE CASCADE,
+    revision   INTEGER NOT NULL,
+    title      TEXT    NOT NULL,
+    body       TEXT    NOT NULL,
+    url        TEXT    NOT NULL,
+    author_id  INTEGER REFERENCES users (id) ON DELETE SET NULL,
+    created_at INTEGER NOT NULL,
+    UNIQUE (lesson_id, revision)
+);
+
+CREATE TRIGGER IF NOT EXISTS lesson_revisions_insert
+    AFTER INSERT
+    ON course_lessons
+BEGIN
+    INSERT INTO lesson_revisions (lesson_id, revision, title, body, url, author_id, created_at)
+    VALUES (new.id, 1, new.title, new.body, new.url, new.updated_by, new.updated_at);
+END;
+
+CREATE TRIGGER IF NOT EXISTS lesson_revisions_update
+    AFTER UPDATE OF title, body, url
+    ON course_lessons
+    WHEN old.title IS NOT new.title OR old.body IS NOT new.body OR old.url IS NOT new.url
+BEGIN
+    INSERT INTO lesson_revisions (lesson_id, revision, title, body, url, author_id, created_at)
+    VALUES (new.id,
+            (SELECT COALESCE(MAX(revision), 0) + 1 FROM lesson_revisions WHERE lesson_id = new.id),
+            new.title, new.body, new.url, new.updated_by, new.updated_at);
+END;
+
+INSERT INTO lesson_revisions (lesson_id, revision, title, body, url, author_id, created_at)
+SELECT id, 1, title, body, url, NULL, updated_at
+FROM course_lessons;
@@ -0,0 +1,17 @@
+-- Content saved by users for later. Every bookmark points to exactly one article or course,
+-- bookmarks go away with the user or the content they point to.
+
+CREATE TABLE IF NOT EXISTS bookmarks
+(
+    id         INTEGER PRIMARY KEY,
+    user_id    INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    article_id INTEGER REFERENCES article (id) ON DELETE CASCADE,
+    course_id  INTEGER REFERENCES course (id) ON DELETE CASCADE,
+    created_at INTEGER NOT NULL,
+    CHECK ((article_id IS NULL) != (course_id IS NULL)),
+    UNIQUE (user_id, article_id),
+    UNIQUE (user_id, course_id)
+);
+CREATE INDEX IF NOT EXISTS idx_bookmarks_user_created ON bookmarks (user_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_bookmarks_article ON bookmarks (article_id);
+CREATE INDEX IF NOT EXISTS idx_bookmarks_course ON bookmarks (course_id);
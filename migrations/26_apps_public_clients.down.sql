@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS apps_old
+(
+    id            INTEGER PRIMARY KEY,
+    name          TEXT NOT NULL UNIQUE,
+    secret        TEXT NOT NULL UNIQUE,
+    redirect_uris TEXT NOT NULL DEFAULT ''
+);
+INSERT INTO apps_old (id, name, secret, redirect_uris)
+SELECT id, name, secret, redirect_uris
+FROM apps;
+DROP TABLE apps;
+ALTER TABLE apps_old RENAME TO apps;
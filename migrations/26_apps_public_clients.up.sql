@@ -0,0 +1,13 @@
+-- Public clients authenticate with PKCE only and all have an empty secret, so it can't be unique.
+CREATE TABLE IF NOT EXISTS apps_new
+(
+    id            INTEGER PRIMARY KEY,
+    name          TEXT NOT NULL UNIQUE,
+    secret        TEXT NOT NULL,
+    redirect_uris TEXT NOT NULL DEFAULT ''
+);
+INSERT INTO apps_new (id, name, secret, redirect_uris)
+SELECT id, name, secret, redirect_uris
+FROM apps;
+DROP TABLE apps;
+ALTER TABLE apps_new RENAME TO apps;
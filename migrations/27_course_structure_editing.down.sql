@@ -0,0 +1,2 @@
+ALTER TABLE course_lessons DROP COLUMN deleted_at;
+ALTER TABLE course_modules DROP COLUMN deleted_at;
@@ -0,0 +1,7 @@
+-- Editing modules and lessons through the API. They have no version of their own, every change
+-- increases version of their course, so ETag of the course covers the whole tree.
+-- Deleted modules and lessons keep their rows with deleted_at set, like content does, so revisions
+-- and progress of students are kept. Their position is set to -id to free it for the remaining ones.
+
+ALTER TABLE course_modules ADD COLUMN deleted_at INTEGER;
+ALTER TABLE course_lessons ADD COLUMN deleted_at INTEGER;
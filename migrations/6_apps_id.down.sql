@@ -0,0 +1,13 @@
+ALTER TABLE refresh_tokens DROP COLUMN app_id;
+
+CREATE TABLE IF NOT EXISTS apps_old
+(
+    name   TEXT NOT NULL UNIQUE,
+    secret TEXT NOT NULL UNIQUE
+);
+INSERT INTO apps_old (name, secret)
+SELECT name, secret
+FROM apps
+ORDER BY id;
+DROP TABLE apps;
+ALTER TABLE apps_old RENAME TO apps;
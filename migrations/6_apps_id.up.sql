@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS apps_new
+(
+    id     INTEGER PRIMARY KEY,
+    name   TEXT NOT NULL UNIQUE,
+    secret TEXT NOT NULL UNIQUE
+);
+INSERT INTO apps_new (id, name, secret)
+SELECT rowid, name, secret
+FROM apps;
+DROP TABLE apps;
+ALTER TABLE apps_new RENAME TO apps;
+
+ALTER TABLE refresh_tokens ADD COLUMN app_id INTEGER NOT NULL DEFAULT 1;
@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS signing_keys
+(
+    kid         TEXT PRIMARY KEY,
+    algorithm   TEXT    NOT NULL,
+    private_key BLOB    NOT NULL,
+    public_key  BLOB    NOT NULL,
+    created_at  INTEGER NOT NULL,
+    retired_at  INTEGER
+);
@@ -0,0 +1,15 @@
+ALTER TABLE apps ADD COLUMN redirect_uris TEXT NOT NULL DEFAULT '';
+
+CREATE TABLE IF NOT EXISTS authorization_codes
+(
+    code_hash             TEXT PRIMARY KEY,
+    app_id                INTEGER NOT NULL REFERENCES apps (id) ON DELETE CASCADE,
+    user_id               INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    redirect_uri          TEXT    NOT NULL,
+    scope                 TEXT    NOT NULL,
+    nonce                 TEXT    NOT NULL,
+    code_challenge        TEXT    NOT NULL,
+    code_challenge_method TEXT    NOT NULL,
+    expires_at            INTEGER NOT NULL,
+    used                  INTEGER NOT NULL DEFAULT 0
+);
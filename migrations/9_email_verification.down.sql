@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS email_tokens;
+
+ALTER TABLE users DROP COLUMN verified;
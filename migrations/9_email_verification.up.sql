@@ -0,0 +1,15 @@
+ALTER TABLE users ADD COLUMN verified INTEGER NOT NULL DEFAULT 0;
+
+-- Accounts created before verification was introduced are trusted.
+UPDATE users SET verified = 1;
+
+CREATE TABLE IF NOT EXISTS email_tokens
+(
+    token_hash TEXT PRIMARY KEY,
+    user_id    INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    purpose    TEXT    NOT NULL,
+    email      TEXT    NOT NULL,
+    expires_at INTEGER NOT NULL,
+    used       INTEGER NOT NULL DEFAULT 0
+);
+CREATE INDEX IF NOT EXISTS idx_email_tokens_user ON email_tokens (user_id, purpose);
@@ -0,0 +1,45 @@
+-- Sample content for local development, applied by `task seed`.
+-- Rows are matched by slug, so running the seed again leaves existing content untouched.
+
+INSERT INTO article (slug, name, description, body, image, status, published_at, created_at, updated_at)
+VALUES ('go-first-steps', 'Первые шаги в Go',
+        'Устанавливаем Go и пишем первую программу.',
+        '# Первые шаги в Go' || char(10) || char(10) ||
+        'Скачайте дистрибутив с go.dev и проверьте установку командой `go version`.',
+        'https://picsum.photos/seed/go-first-steps/800/450',
+        'published', 1735689600, 1735689600, 1735689600),
+       ('sql-basics', 'Основы SQL',
+        'SELECT, WHERE и JOIN на простых примерах.',
+        '# Основы SQL' || char(10) || char(10) ||
+        'Запрос `SELECT` выбирает строки из таблицы, `WHERE` отбирает нужные из них.',
+        'https://picsum.photos/seed/sql-basics/800/450',
+        'published', 1736294400, 1736294400, 1736294400),
+       ('git-workflow', 'Работа с Git в команде',
+        'Ветки, pull request и code review.',
+        '# Работа с Git в команде' || char(10) || char(10) ||
+        'Каждая задача делается в отдельной ветке и попадает в main через pull request.',
+        'https://picsum.photos/seed/git-workflow/800/450',
+        'draft', NULL, 1736899200, 1736899200)
+ON CONFLICT (slug) DO NOTHING;
+
+INSERT INTO course (slug, name, description, image, status, published_at, created_at, updated_at)
+VALUES ('go-backend', 'Backend-разработка на Go',
+        'От первой программы до собственного HTTP-сервиса.',
+        'https://picsum.photos/seed/go-backend/800/450',
+        'published', 1735689600, 1735689600, 1735689600),
+       ('databases', 'Базы данных для разработчика',
+        'Проектирование схем, индексы и транзакции.',
+        'https://picsum.photos/seed/databases/800/450',
+        'published', 1736294400, 1736294400, 1736294400)
+ON CONFLICT (slug) DO NOTHING;
+
+INSERT INTO feed (slug, name, description, image, link, status, published_at, created_at, updated_at)
+VALUES ('welcome', 'Добро пожаловать в IT-Navigator',
+        'Статьи и курсы для тех, кто начинает путь в IT.',
+        'https://picsum.photos/seed/welcome/800/450',
+        '', 'published', 1735689600, 1735689600, 1735689600),
+       ('new-course-databases', 'Новый курс: базы данных',
+        'Проектирование схем, индексы и транзакции.',
+        'https://picsum.photos/seed/databases/800/450',
+        '/courses/databases', 'published', 1736294400, 1736294400, 1736294400)
+ON CONFLICT (slug) DO NOTHING;
@@ -0,0 +1,26 @@
+-- Difficulty and tags of sample content from 1_content.sql.
+
+UPDATE article SET difficulty = 'beginner' WHERE slug IN ('go-first-steps', 'sql-basics', 'git-workflow') AND difficulty IS NULL;
+UPDATE course SET difficulty = 'beginner' WHERE slug = 'go-backend' AND difficulty IS NULL;
+UPDATE course SET difficulty = 'intermediate' WHERE slug = 'databases' AND difficulty IS NULL;
+
+INSERT OR IGNORE INTO article_tags (article_id, tag)
+SELECT id, tag
+FROM article
+         JOIN (SELECT 'go-first-steps' AS slug, 'go' AS tag
+               UNION ALL SELECT 'sql-basics', 'sql'
+               UNION ALL SELECT 'sql-basics', 'databases'
+               UNION ALL SELECT 'git-workflow', 'git') USING (slug);
+
+INSERT OR IGNORE INTO course_tags (course_id, tag)
+SELECT id, tag
+FROM course
+         JOIN (SELECT 'go-backend' AS slug, 'go' AS tag
+               UNION ALL SELECT 'go-backend', 'backend'
+               UNION ALL SELECT 'databases', 'sql'
+               UNION ALL SELECT 'databases', 'databases') USING (slug);
+
+INSERT OR IGNORE INTO feed_tags (feed_id, tag)
+SELECT id, 'databases'
+FROM feed
+WHERE slug = 'new-course-databases';
@@ -0,0 +1,40 @@
+-- Modules and lessons of the sample go-backend course from 1_content.sql.
+
+INSERT OR IGNORE INTO course_modules (course_id, position, title, description, created_at, updated_at)
+SELECT course.id, m.position, m.title, m.description, 1735689600, 1735689600
+FROM course
+         JOIN (SELECT 1 AS position, 'Основы языка' AS title, 'Синтаксис, типы и функции.' AS description
+               UNION ALL SELECT 2, 'HTTP-сервисы', 'Пишем и тестируем REST API.') m
+WHERE course.slug = 'go-backend';
+
+INSERT OR IGNORE INTO course_lessons (module_id, position, title, type, body, url, duration_minutes, created_at, updated_at)
+SELECT course_modules.id, l.position, l.title, l.type, l.body, l.url, l.duration, 1735689600, 1735689600
+FROM course_modules
+         JOIN course ON course.id = course_modules.course_id
+         JOIN (SELECT 1 AS module, 1 AS position, 'Установка Go' AS title, 'text' AS type,
+                      '## Установка' || char(10) || char(10) || 'Скачайте Go с go.dev.' AS body, '' AS url, 5 AS duration
+               UNION ALL SELECT 1, 2, 'Tour of Go', 'external', 'Интерактивный тур по языку.', 'https://go.dev/tour/', 60
+               UNION ALL SELECT 1, 3, 'Проверка знаний', 'quiz', 'Ответьте на вопросы по основам.', '', 5
+               UNION ALL SELECT 2, 1, 'Пакет net/http', 'video', 'Обзор стандартной библиотеки.',
+                                'https://www.youtube.com/watch?v=example', 20) l
+              ON l.module = course_modules.position
+WHERE course.slug = 'go-backend';
+
+INSERT OR IGNORE INTO lesson_quiz_questions (lesson_id, position, text)
+SELECT course_lessons.id, 1, 'Какой командой запускается программа?'
+FROM course_lessons
+         JOIN course_modules ON course_modules.id = course_lessons.module_id
+         JOIN course ON course.id = course_modules.course_id
+WHERE course.slug = 'go-backend' AND course_modules.position = 1 AND course_lessons.position = 3;
+
+INSERT OR IGNORE INTO lesson_quiz_options (question_id, position, text, correct)
+SELECT lesson_quiz_questions.id, o.position, o.text, o.correct
+FROM lesson_quiz_questions
+         JOIN course_lessons ON course_lessons.id = lesson_quiz_questions.lesson_id
+         JOIN course_modules ON course_modules.id = course_lessons.module_id
+         JOIN course ON course.id = course_modules.course_id
+         JOIN (SELECT 1 AS position, 'go run' AS text, 1 AS correct
+               UNION ALL SELECT 2, 'go start', 0
+               UNION ALL SELECT 3, 'go exec', 0) o
+WHERE course.slug = 'go-backend' AND course_modules.position = 1 AND course_lessons.position = 3
+  AND lesson_quiz_questions.position = 1;
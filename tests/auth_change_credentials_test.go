@@ -0,0 +1,111 @@
+package tests
+
+import (
+	ssov1 "github.com/DenisPopkov/IT-Navigator-Proto/gen/go/sso"
+	"github.com/brianvoe/gofakeit/v6"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"sso/tests/suite"
+	"testing"
+)
+
+func TestChangePassword_RevokesOtherSessions(t *testing.T) {
+	ctx, st := suite.New(t)
+
+	email := gofakeit.Email()
+	pass := randomFakePassword()
+	newPass := randomFakePassword()
+
+	_, err := st.AuthClient.Register(ctx, &ssov1.RegisterRequest{
+		Email:    email,
+		Password: pass,
+	})
+	require.NoError(t, err)
+
+	respCurrent, err := st.AuthClient.Login(ctx, &ssov1.LoginRequest{
+		Email:    email,
+		Password: pass,
+		AppId:    appID,
+	})
+	require.NoError(t, err)
+
+	respOther, err := st.AuthClient.Login(ctx, &ssov1.LoginRequest{
+		Email:    email,
+		Password: pass,
+		AppId:    appID,
+	})
+	require.NoError(t, err)
+
+	authCtx := withToken(ctx, respCurrent.GetToken())
+
+	_, err = st.AuthClient.ChangePassword(authCtx, &ssov1.ChangePasswordRequest{
+		CurrentPassword: newPass,
+		NewPassword:     newPass,
+	})
+	require.Error(t, err)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = st.AuthClient.ChangePassword(authCtx, &ssov1.ChangePasswordRequest{
+		CurrentPassword: pass,
+		NewPassword:     newPass,
+	})
+	require.NoError(t, err)
+
+	_, err = st.AuthClient.Refresh(ctx, &ssov1.RefreshRequest{
+		RefreshToken: respOther.GetRefreshToken(),
+	})
+	require.Error(t, err)
+
+	_, err = st.AuthClient.Refresh(ctx, &ssov1.RefreshRequest{
+		RefreshToken: respCurrent.GetRefreshToken(),
+	})
+	require.NoError(t, err)
+
+	_, err = st.AuthClient.Login(ctx, &ssov1.LoginRequest{
+		Email:    email,
+		Password: newPass,
+		AppId:    appID,
+	})
+	require.NoError(t, err)
+}
+
+func TestChangeEmail_Taken(t *testing.T) {
+	ctx, st := suite.New(t)
+
+	email := gofakeit.Email()
+	takenEmail := gofakeit.Email()
+	pass := randomFakePassword()
+
+	for _, e := range []string{email, takenEmail} {
+		_, err := st.AuthClient.Register(ctx, &ssov1.RegisterRequest{
+			Email:    e,
+			Password: pass,
+		})
+		require.NoError(t, err)
+	}
+
+	respLogin, err := st.AuthClient.Login(ctx, &ssov1.LoginRequest{
+		Email:    email,
+		Password: pass,
+		AppId:    appID,
+	})
+	require.NoError(t, err)
+
+	_, err = st.AuthClient.ChangeEmail(withToken(ctx, respLogin.GetToken()), &ssov1.ChangeEmailRequest{
+		Email: takenEmail,
+	})
+	require.Error(t, err)
+	assert.Equal(t, codes.AlreadyExists, status.Code(err))
+}
+
+func TestConfirmEmailChange_InvalidToken(t *testing.T) {
+	ctx, st := suite.New(t)
+
+	_, err := st.AuthClient.ConfirmEmailChange(ctx, &ssov1.ConfirmEmailChangeRequest{
+		Token: "invalid",
+	})
+	require.Error(t, err)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
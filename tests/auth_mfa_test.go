@@ -0,0 +1,86 @@
+package tests
+
+import (
+	"context"
+	ssov1 "github.com/DenisPopkov/IT-Navigator-Proto/gen/go/sso"
+	"github.com/brianvoe/gofakeit/v6"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"sso/internal/lib/totp"
+	"sso/tests/suite"
+	"testing"
+	"time"
+)
+
+func TestMFA_HappyPath(t *testing.T) {
+	ctx, st := suite.New(t)
+
+	email := gofakeit.Email()
+	pass := randomFakePassword()
+
+	_, err := st.AuthClient.Register(ctx, &ssov1.RegisterRequest{
+		Email:    email,
+		Password: pass,
+	})
+	require.NoError(t, err)
+
+	respLogin, err := st.AuthClient.Login(ctx, &ssov1.LoginRequest{
+		Email:    email,
+		Password: pass,
+		AppId:    appID,
+	})
+	require.NoError(t, err)
+
+	authCtx := withToken(ctx, respLogin.GetToken())
+
+	respEnroll, err := st.AuthClient.EnrollTOTP(authCtx, &ssov1.EnrollTOTPRequest{})
+	require.NoError(t, err)
+	assert.Contains(t, respEnroll.GetUri(), "otpauth://totp/")
+
+	code, err := totp.Code(respEnroll.GetSecret(), totp.Step(time.Now()))
+	require.NoError(t, err)
+
+	respConfirm, err := st.AuthClient.ConfirmTOTP(authCtx, &ssov1.ConfirmTOTPRequest{Code: code})
+	require.NoError(t, err)
+	require.NotEmpty(t, respConfirm.GetRecoveryCodes())
+
+	respLogin, err = st.AuthClient.Login(ctx, &ssov1.LoginRequest{
+		Email:    email,
+		Password: pass,
+		AppId:    appID,
+	})
+	require.NoError(t, err)
+	assert.Empty(t, respLogin.GetToken())
+	require.NotEmpty(t, respLogin.GetMfaToken())
+
+	respVerify, err := st.AuthClient.VerifyMFA(ctx, &ssov1.VerifyMFARequest{
+		MfaToken: respLogin.GetMfaToken(),
+		Code:     respConfirm.GetRecoveryCodes()[0],
+	})
+	require.NoError(t, err)
+	assert.NotEmpty(t, respVerify.GetToken())
+	assert.NotEmpty(t, respVerify.GetRefreshToken())
+
+	// Challenge is single-use.
+	_, err = st.AuthClient.VerifyMFA(ctx, &ssov1.VerifyMFARequest{
+		MfaToken: respLogin.GetMfaToken(),
+		Code:     respConfirm.GetRecoveryCodes()[1],
+	})
+	require.Error(t, err)
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+}
+
+func TestMFA_EnrollWithoutToken(t *testing.T) {
+	ctx, st := suite.New(t)
+
+	_, err := st.AuthClient.EnrollTOTP(ctx, &ssov1.EnrollTOTPRequest{})
+	require.Error(t, err)
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+}
+
+func withToken(ctx context.Context, token string) context.Context {
+	return metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+token)
+}
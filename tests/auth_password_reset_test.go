@@ -0,0 +1,78 @@
+package tests
+
+import (
+	ssov1 "github.com/DenisPopkov/IT-Navigator-Proto/gen/go/sso"
+	"github.com/brianvoe/gofakeit/v6"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"sso/tests/suite"
+	"testing"
+)
+
+func TestRequestPasswordReset_DoesNotRevealEmail(t *testing.T) {
+	ctx, st := suite.New(t)
+
+	email := gofakeit.Email()
+
+	_, err := st.AuthClient.Register(ctx, &ssov1.RegisterRequest{
+		Email:    email,
+		Password: randomFakePassword(),
+	})
+	require.NoError(t, err)
+
+	// Registered and unknown emails get the same response.
+	for _, e := range []string{email, gofakeit.Email()} {
+		_, err := st.AuthClient.RequestPasswordReset(ctx, &ssov1.RequestPasswordResetRequest{
+			Email: e,
+		})
+		require.NoError(t, err)
+	}
+}
+
+func TestResetPassword_FailCases(t *testing.T) {
+	ctx, st := suite.New(t)
+
+	tests := []struct {
+		name         string
+		code         string
+		password     string
+		expectedCode codes.Code
+		expectedErr  string
+	}{
+		{
+			name:         "Reset with Empty Code",
+			code:         "",
+			password:     randomFakePassword(),
+			expectedCode: codes.InvalidArgument,
+			expectedErr:  "code is required",
+		},
+		{
+			name:         "Reset with Empty Password",
+			code:         gofakeit.UUID(),
+			password:     "",
+			expectedCode: codes.InvalidArgument,
+			expectedErr:  "new_password is required",
+		},
+		{
+			name:         "Reset with Unknown Code",
+			code:         gofakeit.UUID(),
+			password:     randomFakePassword(),
+			expectedCode: codes.InvalidArgument,
+			expectedErr:  "invalid or expired code",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := st.AuthClient.ResetPassword(ctx, &ssov1.ResetPasswordRequest{
+				Code:        tt.code,
+				NewPassword: tt.password,
+			})
+			require.Error(t, err)
+			assert.Equal(t, tt.expectedCode, status.Code(err))
+			require.Contains(t, err.Error(), tt.expectedErr)
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package tests
+
+import (
+	ssov1 "github.com/DenisPopkov/IT-Navigator-Proto/gen/go/sso"
+	"github.com/brianvoe/gofakeit/v6"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"sso/tests/suite"
+	"testing"
+)
+
+func TestRBAC_AssignRoleWithoutToken(t *testing.T) {
+	ctx, st := suite.New(t)
+
+	_, err := st.AuthClient.AssignRole(ctx, &ssov1.AssignRoleRequest{
+		UserId: 1,
+		Role:   "admin",
+	})
+	require.Error(t, err)
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+}
+
+func TestRBAC_AssignRoleWithoutPermission(t *testing.T) {
+	ctx, st := suite.New(t)
+
+	email := gofakeit.Email()
+	pass := randomFakePassword()
+
+	respReg, err := st.AuthClient.Register(ctx, &ssov1.RegisterRequest{
+		Email:    email,
+		Password: pass,
+	})
+	require.NoError(t, err)
+
+	respLogin, err := st.AuthClient.Login(ctx, &ssov1.LoginRequest{
+		Email:    email,
+		Password: pass,
+		AppId:    appID,
+	})
+	require.NoError(t, err)
+
+	authCtx := withToken(ctx, respLogin.GetToken())
+
+	_, err = st.AuthClient.AssignRole(authCtx, &ssov1.AssignRoleRequest{
+		UserId: respReg.GetUserId(),
+		Role:   "admin",
+	})
+	require.Error(t, err)
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	_, err = st.AuthClient.UserRoles(authCtx, &ssov1.UserRolesRequest{
+		UserId: respReg.GetUserId(),
+	})
+	require.Error(t, err)
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+}
@@ -1,6 +1,9 @@
 package tests
 
 import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"fmt"
 	ssov1 "github.com/DenisPopkov/IT-Navigator-Proto/gen/go/sso"
 	"github.com/brianvoe/gofakeit/v6"
 	"github.com/golang-jwt/jwt/v5"
@@ -12,7 +15,9 @@ import (
 )
 
 const (
-	appSecret      = "test-secret"
+	emptyAppID = 0
+	appID      = 1
+
 	passDefaultLen = 8
 )
 
@@ -33,6 +38,7 @@ func TestRegisterLogin_Login_HappyPath(t *testing.T) {
 	respLogin, err := st.AuthClient.Login(ctx, &ssov1.LoginRequest{
 		Email:    email,
 		Password: pass,
+		AppId:    appID,
 	})
 	require.NoError(t, err)
 
@@ -41,8 +47,22 @@ func TestRegisterLogin_Login_HappyPath(t *testing.T) {
 
 	loginTime := time.Now()
 
+	jwks, err := st.JWKS(ctx)
+	require.NoError(t, err)
+
 	tokenParsed, err := jwt.Parse(token, func(token *jwt.Token) (interface{}, error) {
-		return []byte(appSecret), nil
+		for _, key := range jwks.Keys {
+			if key.Kid == token.Header["kid"] && key.Crv == "Ed25519" {
+				x, err := base64.RawURLEncoding.DecodeString(key.X)
+				if err != nil {
+					return nil, err
+				}
+
+				return ed25519.PublicKey(x), nil
+			}
+		}
+
+		return nil, fmt.Errorf("key %v not found in JWKS", token.Header["kid"])
 	})
 	require.NoError(t, err)
 
@@ -51,6 +71,7 @@ func TestRegisterLogin_Login_HappyPath(t *testing.T) {
 
 	assert.Equal(t, respReg.GetUserId(), int64(claims["uid"].(float64)))
 	assert.Equal(t, email, claims["email"].(string))
+	assert.Equal(t, appID, int(claims["app_id"].(float64)))
 
 	const deltaSeconds = 1
 
@@ -107,6 +128,12 @@ func TestRegister_FailCases(t *testing.T) {
 			password:    "",
 			expectedErr: "email is required",
 		},
+		{
+			name:        "Register with Invalid Email",
+			email:       "not-an-email",
+			password:    randomFakePassword(),
+			expectedErr: "invalid email",
+		},
 	}
 
 	for _, tt := range tests {
@@ -129,32 +156,44 @@ func TestLogin_FailCases(t *testing.T) {
 		name        string
 		email       string
 		password    string
+		appID       int32
 		expectedErr string
 	}{
 		{
 			name:        "Login with Empty Password",
 			email:       gofakeit.Email(),
 			password:    "",
+			appID:       appID,
 			expectedErr: "password is required",
 		},
 		{
 			name:        "Login with Empty Email",
 			email:       "",
 			password:    randomFakePassword(),
+			appID:       appID,
 			expectedErr: "email is required",
 		},
 		{
 			name:        "Login with Both Empty Email and Password",
 			email:       "",
 			password:    "",
+			appID:       appID,
 			expectedErr: "email is required",
 		},
 		{
 			name:        "Login with Non-Matching Password",
 			email:       gofakeit.Email(),
 			password:    randomFakePassword(),
+			appID:       appID,
 			expectedErr: "invalid email or password",
 		},
+		{
+			name:        "Login without AppID",
+			email:       gofakeit.Email(),
+			password:    randomFakePassword(),
+			appID:       emptyAppID,
+			expectedErr: "app_id is required",
+		},
 	}
 
 	for _, tt := range tests {
@@ -168,6 +207,7 @@ func TestLogin_FailCases(t *testing.T) {
 			_, err = st.AuthClient.Login(ctx, &ssov1.LoginRequest{
 				Email:    tt.email,
 				Password: tt.password,
+				AppId:    tt.appID,
 			})
 			require.Error(t, err)
 			require.Contains(t, err.Error(), tt.expectedErr)
@@ -0,0 +1,80 @@
+package tests
+
+import (
+	ssov1 "github.com/DenisPopkov/IT-Navigator-Proto/gen/go/sso"
+	"github.com/brianvoe/gofakeit/v6"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"sso/tests/suite"
+	"testing"
+)
+
+func TestSessions_ListAndRevoke(t *testing.T) {
+	ctx, st := suite.New(t)
+
+	email := gofakeit.Email()
+	pass := randomFakePassword()
+
+	_, err := st.AuthClient.Register(ctx, &ssov1.RegisterRequest{
+		Email:    email,
+		Password: pass,
+	})
+	require.NoError(t, err)
+
+	phoneCtx := metadata.AppendToOutgoingContext(ctx, "x-device-name", "Phone")
+
+	respPhone, err := st.AuthClient.Login(phoneCtx, &ssov1.LoginRequest{
+		Email:    email,
+		Password: pass,
+		AppId:    appID,
+	})
+	require.NoError(t, err)
+
+	respLaptop, err := st.AuthClient.Login(ctx, &ssov1.LoginRequest{
+		Email:    email,
+		Password: pass,
+		AppId:    appID,
+	})
+	require.NoError(t, err)
+
+	laptopCtx := withToken(ctx, respLaptop.GetToken())
+
+	respList, err := st.AuthClient.ListSessions(laptopCtx, &ssov1.ListSessionsRequest{})
+	require.NoError(t, err)
+	require.Len(t, respList.GetSessions(), 2)
+
+	var phoneSessionID int64
+	for _, session := range respList.GetSessions() {
+		assert.NotEmpty(t, session.GetUserAgent())
+		if session.GetDeviceName() == "Phone" {
+			phoneSessionID = session.GetId()
+			assert.False(t, session.GetCurrent())
+		} else {
+			assert.True(t, session.GetCurrent())
+		}
+	}
+	require.NotZero(t, phoneSessionID)
+
+	_, err = st.AuthClient.RevokeSession(laptopCtx, &ssov1.RevokeSessionRequest{SessionId: phoneSessionID})
+	require.NoError(t, err)
+
+	_, err = st.AuthClient.ListSessions(withToken(ctx, respPhone.GetToken()), &ssov1.ListSessionsRequest{})
+	require.Error(t, err)
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	_, err = st.AuthClient.Refresh(ctx, &ssov1.RefreshRequest{
+		RefreshToken: respPhone.GetRefreshToken(),
+	})
+	require.Error(t, err)
+
+	respList, err = st.AuthClient.ListSessions(laptopCtx, &ssov1.ListSessionsRequest{})
+	require.NoError(t, err)
+	assert.Len(t, respList.GetSessions(), 1)
+
+	_, err = st.AuthClient.RevokeSession(laptopCtx, &ssov1.RevokeSessionRequest{SessionId: phoneSessionID})
+	require.Error(t, err)
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
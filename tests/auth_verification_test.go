@@ -0,0 +1,57 @@
+package tests
+
+import (
+	ssov1 "github.com/DenisPopkov/IT-Navigator-Proto/gen/go/sso"
+	"github.com/brianvoe/gofakeit/v6"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"sso/tests/suite"
+	"testing"
+)
+
+func TestResendVerification_UnknownEmail(t *testing.T) {
+	ctx, st := suite.New(t)
+
+	// Unknown emails are accepted silently, so registered ones can't be enumerated.
+	_, err := st.AuthClient.ResendVerification(ctx, &ssov1.ResendVerificationRequest{
+		Email: gofakeit.Email(),
+	})
+	require.NoError(t, err)
+}
+
+func TestConfirmEmail_FailCases(t *testing.T) {
+	ctx, st := suite.New(t)
+
+	tests := []struct {
+		name         string
+		token        string
+		expectedCode codes.Code
+		expectedErr  string
+	}{
+		{
+			name:         "Confirm with Empty Token",
+			token:        "",
+			expectedCode: codes.InvalidArgument,
+			expectedErr:  "token is required",
+		},
+		{
+			name:         "Confirm with Unknown Token",
+			token:        gofakeit.UUID(),
+			expectedCode: codes.InvalidArgument,
+			expectedErr:  "invalid or expired token",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := st.AuthClient.ConfirmEmail(ctx, &ssov1.ConfirmEmailRequest{
+				Token: tt.token,
+			})
+			require.Error(t, err)
+			assert.Equal(t, tt.expectedCode, status.Code(err))
+			require.Contains(t, err.Error(), tt.expectedErr)
+		})
+	}
+}
@@ -2,12 +2,16 @@ package suite
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
 	ssov1 "github.com/DenisPopkov/IT-Navigator-Proto/gen/go/sso"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
 	"net"
+	"net/http"
 	"os"
 	"sso/internal/config"
+	"sso/internal/lib/jwt"
 	"strconv"
 	"testing"
 )
@@ -20,6 +24,7 @@ type Suite struct {
 
 const (
 	grpcHost = "localhost"
+	restHost = "localhost"
 )
 
 // New creates new test suite.
@@ -63,3 +68,30 @@ func configPath() string {
 func grpcAddress(cfg *config.Config) string {
 	return net.JoinHostPort(grpcHost, strconv.Itoa(cfg.GRPC.Port))
 }
+
+// JWKS fetches public signing keys published by the REST server.
+func (s *Suite) JWKS(ctx context.Context) (jwt.JWKS, error) {
+	url := fmt.Sprintf("http://%s/.well-known/jwks.json", net.JoinHostPort(restHost, strconv.Itoa(s.Cfg.REST.Port)))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return jwt.JWKS{}, err
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return jwt.JWKS{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return jwt.JWKS{}, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	var jwks jwt.JWKS
+	if err := json.NewDecoder(resp.Body).Decode(&jwks); err != nil {
+		return jwt.JWKS{}, err
+	}
+
+	return jwks, nil
+}